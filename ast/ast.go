@@ -2,6 +2,7 @@ package ast
 
 import (
 	"compiler/lexer"
+	"compiler/types"
 	"fmt"
 )
 
@@ -12,12 +13,28 @@ func (p *Program) String() string {
 func (ds DeclStatement) statementNode()       {}
 func (ds DeclStatement) TokenLiteral() string { return "DeclStatement" }
 
+func (dg DeclGroup) statementNode()       {}
+func (dg DeclGroup) TokenLiteral() string { return "DeclGroup" }
+
+func (es EnumStatement) statementNode()       {}
+func (es EnumStatement) TokenLiteral() string { return "EnumStatement" }
+
+func (ss StructStatement) statementNode()       {}
+func (ss StructStatement) TokenLiteral() string { return "StructStatement" }
+
+func (ts TypedefStatement) statementNode()       {}
+func (ts TypedefStatement) TokenLiteral() string { return "TypedefStatement" }
+
 func (es ExpStatement) statementNode()       {}
 func (es ExpStatement) TokenLiteral() string { return "ExpStatement" }
 
 func (ae AssignExpression) expressionNode()      {}
+func (ae AssignExpression) String() string       { return ExprString(&ae) }
 func (ae AssignExpression) TokenLiteral() string { return "AssignExpression" }
 
+func (as AsmStatement) statementNode()       {}
+func (as AsmStatement) TokenLiteral() string { return "AsmStatement" }
+
 func (fs FunctionStatement) statementNode()       {}
 func (fs FunctionStatement) TokenLiteral() string { return "FunctionStatement" }
 
@@ -31,25 +48,70 @@ func (is IfStatement) statementNode()       {}
 func (is IfStatement) TokenLiteral() string { return "IfStatement" }
 
 func (il IntegerLiteral) expressionNode()      {}
+func (il IntegerLiteral) String() string       { return ExprString(&il) }
 func (il IntegerLiteral) TokenLiteral() string { return "IntegerLiteral" }
 
 func (pe PrefixExpression) expressionNode()      {}
+func (pe PrefixExpression) String() string       { return ExprString(&pe) }
 func (pe PrefixExpression) TokenLiteral() string { return "PrefixExpression" }
 
+func (be BuiltinExpression) expressionNode()      {}
+func (be BuiltinExpression) String() string       { return ExprString(&be) }
+func (be BuiltinExpression) TokenLiteral() string { return "BuiltinExpression" }
+
+func (pe PostfixExpression) expressionNode()      {}
+func (pe PostfixExpression) String() string       { return ExprString(&pe) }
+func (pe PostfixExpression) TokenLiteral() string { return "PostfixExpression" }
+
 func (ie InfixExpression) expressionNode()      {}
+func (ie InfixExpression) String() string       { return ExprString(&ie) }
 func (ie InfixExpression) TokenLiteral() string { return "InfixExpression" }
 
+func (ce CommaExpression) expressionNode()      {}
+func (ce CommaExpression) String() string       { return ExprString(&ce) }
+func (ce CommaExpression) TokenLiteral() string { return "CommaExpression" }
+
+func (ce CastExpression) expressionNode()      {}
+func (ce CastExpression) String() string       { return ExprString(&ce) }
+func (ce CastExpression) TokenLiteral() string { return "CastExpression" }
+
+func (se SizeofExpression) expressionNode()      {}
+func (se SizeofExpression) String() string       { return ExprString(&se) }
+func (se SizeofExpression) TokenLiteral() string { return "SizeofExpression" }
+
+func (ce CallExpression) expressionNode()      {}
+func (ce CallExpression) String() string       { return ExprString(&ce) }
+func (ce CallExpression) TokenLiteral() string { return "CallExpression" }
+
+func (ie IndexExpression) expressionNode()      {}
+func (ie IndexExpression) String() string       { return ExprString(&ie) }
+func (ie IndexExpression) TokenLiteral() string { return "IndexExpression" }
+
+func (me MemberExpression) expressionNode()      {}
+func (me MemberExpression) String() string       { return ExprString(&me) }
+func (me MemberExpression) TokenLiteral() string { return "MemberExpression" }
+
+func (il InitializerList) expressionNode()      {}
+func (il InitializerList) String() string       { return ExprString(&il) }
+func (il InitializerList) TokenLiteral() string { return "InitializerList" }
+
 func (i Identifier) expressionNode()      {}
+func (i Identifier) String() string       { return ExprString(&i) }
 func (i Identifier) TokenLiteral() string { return "Identifier" }
 
+// invalidAttribError reports an Attrib that could not be asserted to the type a constructor expects
+func invalidAttribError(fn, expected, name string, got Attrib) error {
+	return fmt.Errorf("%s: expected %s for '%s', got %T", fn, expected, name, got)
+}
+
 func NewProgram(funcs, stmts Attrib) (*Program, error) {
 	s, ok := stmts.([]Statement)
 	if !ok {
-		return nil, fmt.Errorf("NewProgram", "[]Statement", "stmts", stmts)
+		return nil, invalidAttribError("NewProgram", "[]Statement", "stmts", stmts)
 	}
 	f, ok := funcs.([]Statement)
 	if !ok {
-		return nil, fmt.Errorf("NewProgram", "[]Statement", "funcs", funcs)
+		return nil, invalidAttribError("NewProgram", "[]Statement", "funcs", funcs)
 	}
 	return &Program{Functions: f, Statements: s}, nil
 }
@@ -61,63 +123,357 @@ func NewStatementList() ([]Statement, error) {
 func AppendStatement(stmtList, stmt Attrib) ([]Statement, error) {
 	s, ok := stmt.(Statement)
 	if !ok {
-		return nil, fmt.Errorf("AppendStatement", "Statement", "stmt", stmt)
+		return nil, invalidAttribError("AppendStatement", "Statement", "stmt", stmt)
 	}
 	return append(stmtList.([]Statement), s), nil
 }
 
-func NewBlockStatement(stmts Attrib) (*BlockStatement, error) {
+func NewBlockStatement(token, stmts, rbrace Attrib) (*BlockStatement, error) {
+	t, ok := token.(*lexer.Token)
+	if !ok {
+		return nil, invalidAttribError("NewBlockStatement", "*lexer.Token", "token", token)
+	}
 	s, ok := stmts.([]Statement)
 	if !ok {
-		return nil, fmt.Errorf("NewBlockStatement", "[]Statement", "stmts", stmts)
+		return nil, invalidAttribError("NewBlockStatement", "[]Statement", "stmts", stmts)
+	}
+	r, ok := rbrace.(*lexer.Token)
+	if !ok {
+		return nil, invalidAttribError("NewBlockStatement", "*lexer.Token", "rbrace", rbrace)
 	}
-	return &BlockStatement{Statements: s}, nil
+	return &BlockStatement{Token: t, Statements: s, Rbrace: r}, nil
 }
 
-func NewReturnStatement(exp Attrib) (Statement, error) {
+func NewReturnStatement(token, exp Attrib) (Statement, error) {
+	t, ok := token.(*lexer.Token)
+	if !ok {
+		return nil, invalidAttribError("NewReturnStatement", "*lexer.Token", "token", token)
+	}
 	e, ok := exp.(Expression)
 	if !ok {
-		return nil, fmt.Errorf("NewReturnStatement", "Expression", "exp", exp)
+		return nil, invalidAttribError("NewReturnStatement", "Expression", "exp", exp)
 	}
-	return &ReturnStatement{ReturnValue: e}, nil
+	return &ReturnStatement{Token: t, ReturnValue: e}, nil
 }
 
-func NewDeclStatement(varType, left, right Attrib) (Statement, error) {
-	t, ok := varType.(*lexer.Token)
+func NewDeclStatement(storage, varType, left, right Attrib) (Statement, error) {
+	sc, ok := storage.(string)
+	if !ok {
+		return nil, invalidAttribError("NewDeclStatement", "string", "storage", storage)
+	}
+	t, ok := varType.(types.Type)
 	if !ok {
-		return nil, fmt.Errorf("NewDeclStatement", "*lexer.Token", "varType", varType)
+		return nil, invalidAttribError("NewDeclStatement", "types.Type", "varType", varType)
 	}
 	l, ok := left.(*lexer.Token)
 	if !ok {
-		return nil, fmt.Errorf("NewDeclStatement", "*lexer.Token", "left", left)
+		return nil, invalidAttribError("NewDeclStatement", "*lexer.Token", "left", left)
 	}
 	id := Identifier{Token: l, Value: string(l.Value)}
-	stmt := &DeclStatement{Token: t, Left: id, Type: string(t.Value)}
+	stmt := &DeclStatement{Token: l, Left: id, Type: t, Storage: sc}
 	if right == nil {
 		return stmt, nil
 	}
 	r, ok := right.(Expression)
 	if !ok {
-		return nil, fmt.Errorf("NewDeclStatement", "Expression", "right", right)
+		return nil, invalidAttribError("NewDeclStatement", "Expression", "right", right)
 	}
 	stmt.Right = r
 	return stmt, nil
 }
 
+func NewDeclList() ([]Statement, error) {
+	return []Statement{}, nil
+}
+
+func AppendDecl(declList, decl Attrib) ([]Statement, error) {
+	l, ok := declList.([]Statement)
+	if !ok {
+		return nil, invalidAttribError("AppendDecl", "[]Statement", "declList", declList)
+	}
+	d, ok := decl.(Statement)
+	if !ok {
+		return nil, invalidAttribError("AppendDecl", "Statement", "decl", decl)
+	}
+	return append(l, d), nil
+}
+
+// NewDeclGroup returns the single declaration when only one declarator was found
+// and a DeclGroup wrapping all of them otherwise
+func NewDeclGroup(token, decls Attrib) (Statement, error) {
+	t, ok := token.(*lexer.Token)
+	if !ok {
+		return nil, invalidAttribError("NewDeclGroup", "*lexer.Token", "token", token)
+	}
+	d, ok := decls.([]Statement)
+	if !ok {
+		return nil, invalidAttribError("NewDeclGroup", "[]Statement", "decls", decls)
+	}
+	if len(d) == 1 {
+		return d[0], nil
+	}
+	return &DeclGroup{Token: t, Decls: d}, nil
+}
+
+func NewTypedefStatement(typeName, name Attrib) (Statement, error) {
+	t, ok := typeName.(types.Type)
+	if !ok {
+		return nil, invalidAttribError("NewTypedefStatement", "types.Type", "typeName", typeName)
+	}
+	n, ok := name.(*lexer.Token)
+	if !ok {
+		return nil, invalidAttribError("NewTypedefStatement", "*lexer.Token", "name", name)
+	}
+	return &TypedefStatement{Token: n, Name: string(n.Value), Type: t}, nil
+}
+
+func NewEnumeratorList() ([]Enumerator, error) {
+	return []Enumerator{}, nil
+}
+
+func AppendEnumerator(list, name, value Attrib) ([]Enumerator, error) {
+	l, ok := list.([]Enumerator)
+	if !ok {
+		return nil, invalidAttribError("AppendEnumerator", "[]Enumerator", "list", list)
+	}
+	n, ok := name.(*lexer.Token)
+	if !ok {
+		return nil, invalidAttribError("AppendEnumerator", "*lexer.Token", "name", name)
+	}
+	e := Enumerator{Token: n, Name: string(n.Value)}
+	if value != nil {
+		v, ok := value.(Expression)
+		if !ok {
+			return nil, invalidAttribError("AppendEnumerator", "Expression", "value", value)
+		}
+		e.Value = v
+	}
+	return append(l, e), nil
+}
+
+// NewEnumStatement accepts a nil name for anonymous enums
+func NewEnumStatement(token, name, enumerators, rbrace Attrib) (Statement, error) {
+	t, ok := token.(*lexer.Token)
+	if !ok {
+		return nil, invalidAttribError("NewEnumStatement", "*lexer.Token", "token", token)
+	}
+	e, ok := enumerators.([]Enumerator)
+	if !ok {
+		return nil, invalidAttribError("NewEnumStatement", "[]Enumerator", "enumerators", enumerators)
+	}
+	r, ok := rbrace.(*lexer.Token)
+	if !ok {
+		return nil, invalidAttribError("NewEnumStatement", "*lexer.Token", "rbrace", rbrace)
+	}
+	stmt := &EnumStatement{Token: t, Enumerators: e, Rbrace: r}
+	if name == nil {
+		return stmt, nil
+	}
+	n, ok := name.(*lexer.Token)
+	if !ok {
+		return nil, invalidAttribError("NewEnumStatement", "*lexer.Token", "name", name)
+	}
+	stmt.Name = string(n.Value)
+	return stmt, nil
+}
+
 func NewAssignExpression(operator, left, right Attrib) (Expression, error) {
 	op, ok := operator.(*lexer.Token)
 	if !ok {
-		return nil, fmt.Errorf("NewAssignStatement", "*lexer.Token", "operator", operator)
+		return nil, invalidAttribError("NewAssignStatement", "*lexer.Token", "operator", operator)
 	}
-	l, ok := left.(*lexer.Token)
+	l, ok := left.(Expression)
 	if !ok {
-		return nil, fmt.Errorf("NewAssignStatement", "*lexer.Token", "left", left)
+		return nil, invalidAttribError("NewAssignStatement", "Expression", "left", left)
 	}
 	r, ok := right.(Expression)
 	if !ok {
-		return nil, fmt.Errorf("NewAssignStatement", "Expression", "right", right)
+		return nil, invalidAttribError("NewAssignStatement", "Expression", "right", right)
+	}
+	return &AssignExpression{Token: op, Operator: string(op.Value), Left: l, Right: r}, nil
+}
+
+func NewStructFieldList() ([]StructField, error) {
+	return []StructField{}, nil
+}
+
+func AppendStructField(list, name, typeName Attrib) ([]StructField, error) {
+	l, ok := list.([]StructField)
+	if !ok {
+		return nil, invalidAttribError("AppendStructField", "[]StructField", "list", list)
+	}
+	n, ok := name.(*lexer.Token)
+	if !ok {
+		return nil, invalidAttribError("AppendStructField", "*lexer.Token", "name", name)
+	}
+	t, ok := typeName.(types.Type)
+	if !ok {
+		return nil, invalidAttribError("AppendStructField", "types.Type", "typeName", typeName)
+	}
+	return append(l, StructField{Token: n, Name: string(n.Value), Type: t}), nil
+}
+
+// NewStructStatement accepts a nil name for anonymous structs
+func NewStructStatement(token, name, fields, rbrace Attrib) (Statement, error) {
+	t, ok := token.(*lexer.Token)
+	if !ok {
+		return nil, invalidAttribError("NewStructStatement", "*lexer.Token", "token", token)
+	}
+	f, ok := fields.([]StructField)
+	if !ok {
+		return nil, invalidAttribError("NewStructStatement", "[]StructField", "fields", fields)
+	}
+	r, ok := rbrace.(*lexer.Token)
+	if !ok {
+		return nil, invalidAttribError("NewStructStatement", "*lexer.Token", "rbrace", rbrace)
+	}
+	stmt := &StructStatement{Token: t, Fields: f, Rbrace: r}
+	if name == nil {
+		return stmt, nil
+	}
+	n, ok := name.(*lexer.Token)
+	if !ok {
+		return nil, invalidAttribError("NewStructStatement", "*lexer.Token", "name", name)
+	}
+	stmt.Name = string(n.Value)
+	return stmt, nil
+}
+
+func NewIndexExpression(left, token, index Attrib) (Expression, error) {
+	l, ok := left.(Expression)
+	if !ok {
+		return nil, invalidAttribError("NewIndexExpression", "Expression", "left", left)
+	}
+	t, ok := token.(*lexer.Token)
+	if !ok {
+		return nil, invalidAttribError("NewIndexExpression", "*lexer.Token", "token", token)
+	}
+	i, ok := index.(Expression)
+	if !ok {
+		return nil, invalidAttribError("NewIndexExpression", "Expression", "index", index)
+	}
+	return &IndexExpression{Token: t, Left: l, Index: i}, nil
+}
+
+func NewMemberExpression(left, operator, member Attrib) (Expression, error) {
+	l, ok := left.(Expression)
+	if !ok {
+		return nil, invalidAttribError("NewMemberExpression", "Expression", "left", left)
+	}
+	op, ok := operator.(*lexer.Token)
+	if !ok {
+		return nil, invalidAttribError("NewMemberExpression", "*lexer.Token", "operator", operator)
+	}
+	m, ok := member.(*lexer.Token)
+	if !ok {
+		return nil, invalidAttribError("NewMemberExpression", "*lexer.Token", "member", member)
+	}
+	return &MemberExpression{Token: op, Operator: string(op.Value), Left: l, Member: string(m.Value)}, nil
+}
+
+func NewInitializerList(token, elements Attrib) (Expression, error) {
+	t, ok := token.(*lexer.Token)
+	if !ok {
+		return nil, invalidAttribError("NewInitializerList", "*lexer.Token", "token", token)
+	}
+	e, ok := elements.([]Initializer)
+	if !ok {
+		return nil, invalidAttribError("NewInitializerList", "[]Initializer", "elements", elements)
 	}
-	return &AssignExpression{Token: l, Operator: string(op.Value), Left: Identifier{Token: l, Value: string(l.Value)}, Right: r}, nil
+	return &InitializerList{Token: t, Elements: e}, nil
+}
+
+func NewInitializerElementList() ([]Initializer, error) {
+	return []Initializer{}, nil
+}
+
+func AppendInitializer(list, designators, value Attrib) ([]Initializer, error) {
+	l, ok := list.([]Initializer)
+	if !ok {
+		return nil, invalidAttribError("AppendInitializer", "[]Initializer", "list", list)
+	}
+	d, ok := designators.([]Designator)
+	if !ok {
+		return nil, invalidAttribError("AppendInitializer", "[]Designator", "designators", designators)
+	}
+	v, ok := value.(Expression)
+	if !ok {
+		return nil, invalidAttribError("AppendInitializer", "Expression", "value", value)
+	}
+	return append(l, Initializer{Designators: d, Value: v}), nil
+}
+
+func NewDesignatorList() ([]Designator, error) {
+	return []Designator{}, nil
+}
+
+// AppendDesignator accepts either a field name token or an index expression
+func AppendDesignator(list, designator Attrib) ([]Designator, error) {
+	l, ok := list.([]Designator)
+	if !ok {
+		return nil, invalidAttribError("AppendDesignator", "[]Designator", "list", list)
+	}
+	switch d := designator.(type) {
+	case *lexer.Token:
+		return append(l, Designator{Field: string(d.Value)}), nil
+	case Expression:
+		return append(l, Designator{Index: d}), nil
+	}
+	return nil, invalidAttribError("AppendDesignator", "*lexer.Token or Expression", "designator", designator)
+}
+
+func NewAsmOperandList() ([]AsmOperand, error) {
+	return []AsmOperand{}, nil
+}
+
+func AppendAsmOperand(list, constraint, exp Attrib) ([]AsmOperand, error) {
+	l, ok := list.([]AsmOperand)
+	if !ok {
+		return nil, invalidAttribError("AppendAsmOperand", "[]AsmOperand", "list", list)
+	}
+	c, ok := constraint.(string)
+	if !ok {
+		return nil, invalidAttribError("AppendAsmOperand", "string", "constraint", constraint)
+	}
+	e, ok := exp.(Expression)
+	if !ok {
+		return nil, invalidAttribError("AppendAsmOperand", "Expression", "exp", exp)
+	}
+	return append(l, AsmOperand{Constraint: c, Expression: e}), nil
+}
+
+// NewAsmStatement builds a basic asm statement when outputs, inputs and clobbers are all nil
+func NewAsmStatement(token, volatile, template, outputs, inputs, clobbers Attrib) (Statement, error) {
+	t, ok := token.(*lexer.Token)
+	if !ok {
+		return nil, invalidAttribError("NewAsmStatement", "*lexer.Token", "token", token)
+	}
+	v, ok := volatile.(bool)
+	if !ok {
+		return nil, invalidAttribError("NewAsmStatement", "bool", "volatile", volatile)
+	}
+	tmpl, ok := template.(string)
+	if !ok {
+		return nil, invalidAttribError("NewAsmStatement", "string", "template", template)
+	}
+	stmt := &AsmStatement{Token: t, Volatile: v, Template: tmpl}
+	if outputs == nil && inputs == nil && clobbers == nil {
+		return stmt, nil
+	}
+	stmt.Extended = true
+	stmt.Outputs, ok = outputs.([]AsmOperand)
+	if !ok {
+		return nil, invalidAttribError("NewAsmStatement", "[]AsmOperand", "outputs", outputs)
+	}
+	stmt.Inputs, ok = inputs.([]AsmOperand)
+	if !ok {
+		return nil, invalidAttribError("NewAsmStatement", "[]AsmOperand", "inputs", inputs)
+	}
+	stmt.Clobbers, ok = clobbers.([]string)
+	if !ok {
+		return nil, invalidAttribError("NewAsmStatement", "[]string", "clobbers", clobbers)
+	}
+	return stmt, nil
 }
 
 func NewIdentifier(id *lexer.Token) Expression {
@@ -127,7 +483,7 @@ func NewIdentifier(id *lexer.Token) Expression {
 func NewExpStatement(exp Attrib) (Statement, error) {
 	e, ok := exp.(Expression)
 	if !ok {
-		return nil, fmt.Errorf("NewExpStatement", "Expression", "exp", exp)
+		return nil, invalidAttribError("NewExpStatement", "Expression", "exp", exp)
 	}
 	return &ExpStatement{Expression: e}, nil
 }
@@ -135,7 +491,7 @@ func NewExpStatement(exp Attrib) (Statement, error) {
 func NewIntegerLiteral(integer Attrib) (*IntegerLiteral, error) {
 	intLit, ok := integer.(*lexer.Token)
 	if !ok {
-		return nil, fmt.Errorf("NewIntegerLiteral", "*lexer.Token", "integer", integer)
+		return nil, invalidAttribError("NewIntegerLiteral", "*lexer.Token", "integer", integer)
 	}
 	return &IntegerLiteral{Token: intLit, Value: string(intLit.Value)}, nil
 }
@@ -143,71 +499,228 @@ func NewIntegerLiteral(integer Attrib) (*IntegerLiteral, error) {
 func NewPrefixExpression(operator, expression Attrib) (*PrefixExpression, error) {
 	op, ok := operator.(*lexer.Token)
 	if !ok {
-		return nil, fmt.Errorf("NewPrefixExpression", "*lexer.Token", "operator", operator)
+		return nil, invalidAttribError("NewPrefixExpression", "*lexer.Token", "operator", operator)
 	}
 	exp, ok := expression.(Expression)
 	if !ok {
-		return nil, fmt.Errorf("NewPrefixExpression", "Expression", "expression", expression)
+		return nil, invalidAttribError("NewPrefixExpression", "Expression", "expression", expression)
+	}
+	return &PrefixExpression{Token: op, Operator: string(op.Value), Expression: exp}, nil
+}
+
+func NewBuiltinExpression(name, args, typeName Attrib) (*BuiltinExpression, error) {
+	n, ok := name.(*lexer.Token)
+	if !ok {
+		return nil, invalidAttribError("NewBuiltinExpression", "*lexer.Token", "name", name)
+	}
+	a, ok := args.([]Expression)
+	if !ok {
+		return nil, invalidAttribError("NewBuiltinExpression", "[]Expression", "args", args)
+	}
+	e := &BuiltinExpression{Token: n, Name: string(n.Value), Arguments: a}
+	if typeName == nil {
+		return e, nil
+	}
+	t, ok := typeName.(types.Type)
+	if !ok {
+		return nil, invalidAttribError("NewBuiltinExpression", "types.Type", "typeName", typeName)
+	}
+	e.Type = t
+	return e, nil
+}
+
+func NewPostfixExpression(expression, operator Attrib) (*PostfixExpression, error) {
+	exp, ok := expression.(Expression)
+	if !ok {
+		return nil, invalidAttribError("NewPostfixExpression", "Expression", "expression", expression)
+	}
+	op, ok := operator.(*lexer.Token)
+	if !ok {
+		return nil, invalidAttribError("NewPostfixExpression", "*lexer.Token", "operator", operator)
 	}
-	return &PrefixExpression{Operator: string(op.Value), Expression: exp}, nil
+	return &PostfixExpression{Token: op, Operator: string(op.Value), Expression: exp}, nil
 }
 
 func NewInfixExpression(operator, left Attrib, right Attrib) (*InfixExpression, error) {
 	op, ok := operator.(*lexer.Token)
 	if !ok {
-		return nil, fmt.Errorf("NewInfixExpression", "*lexer.Token", "operator", operator)
+		return nil, invalidAttribError("NewInfixExpression", "*lexer.Token", "operator", operator)
 	}
 	l, ok := left.(Expression)
 	if !ok {
-		return nil, fmt.Errorf("NewInfixExpression", "*lexer.Token", "left", left)
+		return nil, invalidAttribError("NewInfixExpression", "*lexer.Token", "left", left)
 	}
 	r, ok := right.(Expression)
 	if !ok {
-		return nil, fmt.Errorf("NewInfixExpression", "*lexer.Token", "right", right)
+		return nil, invalidAttribError("NewInfixExpression", "*lexer.Token", "right", right)
 	}
-	return &InfixExpression{Operator: string(op.Value), Left: l, Right: r}, nil
+	return &InfixExpression{Token: op, Operator: string(op.Value), Left: l, Right: r}, nil
 }
 
-func NewFunctionStatement(name, args, ret, block Attrib) (Statement, error) {
-	n, ok := name.(*lexer.Token)
+func NewCommaExpression(operator, left, right Attrib) (Expression, error) {
+	t, ok := operator.(*lexer.Token)
+	if !ok {
+		return nil, invalidAttribError("NewCommaExpression", "*lexer.Token", "operator", operator)
+	}
+	l, ok := left.(Expression)
+	if !ok {
+		return nil, invalidAttribError("NewCommaExpression", "Expression", "left", left)
+	}
+	r, ok := right.(Expression)
+	if !ok {
+		return nil, invalidAttribError("NewCommaExpression", "Expression", "right", right)
+	}
+	return &CommaExpression{Token: t, Left: l, Right: r}, nil
+}
+
+func NewCastExpression(token, typeName, expression Attrib) (Expression, error) {
+	t, ok := token.(*lexer.Token)
+	if !ok {
+		return nil, invalidAttribError("NewCastExpression", "*lexer.Token", "token", token)
+	}
+	ty, ok := typeName.(types.Type)
+	if !ok {
+		return nil, invalidAttribError("NewCastExpression", "types.Type", "typeName", typeName)
+	}
+	e, ok := expression.(Expression)
+	if !ok {
+		return nil, invalidAttribError("NewCastExpression", "Expression", "expression", expression)
+	}
+	return &CastExpression{Token: t, Type: ty, Expression: e}, nil
+}
+
+// NewSizeofExpression accepts either a type name or an expression as operand
+func NewSizeofExpression(token, operand Attrib) (Expression, error) {
+	t, ok := token.(*lexer.Token)
+	if !ok {
+		return nil, invalidAttribError("NewSizeofExpression", "*lexer.Token", "token", token)
+	}
+	switch o := operand.(type) {
+	case types.Type:
+		return &SizeofExpression{Token: t, Type: o}, nil
+	case Expression:
+		return &SizeofExpression{Token: t, Expression: o}, nil
+	}
+	return nil, invalidAttribError("NewSizeofExpression", "types.Type or Expression", "operand", operand)
+}
+
+func NewFormalArgList() ([]FormalArg, error) {
+	return []FormalArg{}, nil
+}
+
+// AppendFormalArg accepts a nil name for unnamed parameters
+func AppendFormalArg(list, name, typeName Attrib) ([]FormalArg, error) {
+	l, ok := list.([]FormalArg)
+	if !ok {
+		return nil, invalidAttribError("AppendFormalArg", "[]FormalArg", "list", list)
+	}
+	t, ok := typeName.(types.Type)
+	if !ok {
+		return nil, invalidAttribError("AppendFormalArg", "types.Type", "typeName", typeName)
+	}
+	arg := FormalArg{Type: t}
+	if name != nil {
+		n, ok := name.(*lexer.Token)
+		if !ok {
+			return nil, invalidAttribError("AppendFormalArg", "*lexer.Token", "name", name)
+		}
+		arg.Arg = string(n.Value)
+	}
+	return append(l, arg), nil
+}
+
+func NewExpressionList() ([]Expression, error) {
+	return []Expression{}, nil
+}
+
+func AppendExpression(expList, exp Attrib) ([]Expression, error) {
+	l, ok := expList.([]Expression)
+	if !ok {
+		return nil, invalidAttribError("AppendExpression", "[]Expression", "expList", expList)
+	}
+	e, ok := exp.(Expression)
+	if !ok {
+		return nil, invalidAttribError("AppendExpression", "Expression", "exp", exp)
+	}
+	return append(l, e), nil
+}
+
+func NewCallExpression(function, token, args Attrib) (Expression, error) {
+	f, ok := function.(Expression)
+	if !ok {
+		return nil, invalidAttribError("NewCallExpression", "Expression", "function", function)
+	}
+	t, ok := token.(*lexer.Token)
 	if !ok {
-		return nil, fmt.Errorf("NewFunctionStatement", "*lexer.Token", "name", name)
+		return nil, invalidAttribError("NewCallExpression", "*lexer.Token", "token", token)
 	}
-	b, ok := block.(*BlockStatement)
+	a, ok := args.([]Expression)
 	if !ok {
-		return nil, fmt.Errorf("NewFunctionStatement", "*BlockStatement", "block", block)
+		return nil, invalidAttribError("NewCallExpression", "[]Expression", "args", args)
+	}
+	return &CallExpression{Token: t, Function: f, Arguments: a}, nil
+}
+
+func NewFunctionStatement(storage, name, args, variadic, unprototyped, ret, block Attrib) (Statement, error) {
+	sc, ok := storage.(string)
+	if !ok {
+		return nil, invalidAttribError("NewFunctionStatement", "string", "storage", storage)
+	}
+	n, ok := name.(*lexer.Token)
+	if !ok {
+		return nil, invalidAttribError("NewFunctionStatement", "*lexer.Token", "name", name)
+	}
+	var b *BlockStatement
+	if block != nil {
+		b, ok = block.(*BlockStatement)
+		if !ok {
+			return nil, invalidAttribError("NewFunctionStatement", "*BlockStatement", "block", block)
+		}
 	}
 	a := []FormalArg{}
 	if args != nil {
 		a, ok = args.([]FormalArg)
 		if !ok {
-			return nil, fmt.Errorf("NewFunctionStatement", "[]FormalArg", "args", args)
+			return nil, invalidAttribError("NewFunctionStatement", "[]FormalArg", "args", args)
 		}
 	}
 
-	r, ok := ret.(*lexer.Token)
+	v, ok := variadic.(bool)
+	if !ok {
+		return nil, invalidAttribError("NewFunctionStatement", "bool", "variadic", variadic)
+	}
+	u, ok := unprototyped.(bool)
 	if !ok {
-		return nil, fmt.Errorf("NewFunctionStatement", "*lexer.Token", "ret", ret)
+		return nil, invalidAttribError("NewFunctionStatement", "bool", "unprototyped", unprototyped)
 	}
-	return &FunctionStatement{Name: string(n.Value), Body: b, Parameters: a, Return: string(r.Value)}, nil
+	r, ok := ret.(types.Type)
+	if !ok {
+		return nil, invalidAttribError("NewFunctionStatement", "types.Type", "ret", ret)
+	}
+	return &FunctionStatement{Token: n, Name: string(n.Value), Body: b, Parameters: a, Variadic: v, Unprototyped: u,
+		Return: r, Storage: sc}, nil
 }
 
-func NewIfStatement(cond Attrib, body Attrib, elseBody Attrib) (Statement, error) {
+func NewIfStatement(token, cond, body, elseBody Attrib) (Statement, error) {
+	t, ok := token.(*lexer.Token)
+	if !ok {
+		return nil, invalidAttribError("NewIfStatement", "*lexer.Token", "token", token)
+	}
 	c, ok := cond.(Expression)
 	if !ok {
-		return nil, fmt.Errorf("NewIfStatement", c)
+		return nil, invalidAttribError("NewIfStatement", "Expression", "cond", cond)
 	}
 	b, ok := body.(Statement)
 	if !ok {
-		return nil, fmt.Errorf("NewIfStatement", b)
+		return nil, invalidAttribError("NewIfStatement", "Statement", "body", body)
 	}
-	stmt := &IfStatement{Condition: c, Body: b}
+	stmt := &IfStatement{Token: t, Condition: c, Body: b}
 	if elseBody == nil {
 		return stmt, nil
 	}
 	e, ok := elseBody.(Statement)
 	if !ok {
-		return nil, fmt.Errorf("Fail")
+		return nil, invalidAttribError("NewIfStatement", "Statement", "elseBody", elseBody)
 	}
 	stmt.ElseBody = e
 	return stmt, nil
@@ -0,0 +1,120 @@
+package ast
+
+import (
+	"compiler/lexer"
+	"compiler/types"
+	"reflect"
+)
+
+var (
+	tokenType = reflect.TypeOf((*lexer.Token)(nil))
+	typeType  = reflect.TypeOf((*types.Type)(nil)).Elem()
+)
+
+// Clone returns a deep copy of the tree rooted at node
+// The tokens and the types are shared with node, a pass replaces them rather than modifying them,
+// except for the types holding array lengths written as expressions which are copied with their lengths
+func Clone(node Node) Node {
+	if node == nil {
+		return nil
+	}
+	return cloneValue(reflect.ValueOf(node)).Interface().(Node)
+}
+
+func cloneValue(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() || v.Type() == tokenType {
+			return v
+		}
+		c := reflect.New(v.Type().Elem())
+		c.Elem().Set(cloneValue(v.Elem()))
+		return c
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		if v.Type() == typeType {
+			t := types.MapLengths(v.Interface().(types.Type), func(e types.Expr) types.Expr {
+				if n, ok := e.(Node); ok {
+					return Clone(n).(types.Expr)
+				}
+				return e
+			})
+			c := reflect.New(typeType).Elem()
+			c.Set(reflect.ValueOf(t))
+			return c
+		}
+		c := reflect.New(v.Type()).Elem()
+		c.Set(cloneValue(v.Elem()))
+		return c
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(cloneValue(v.Index(i)))
+		}
+		return c
+	case reflect.Struct:
+		c := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.NumField(); i++ {
+			c.Field(i).Set(cloneValue(v.Field(i)))
+		}
+		return c
+	}
+	return v
+}
+
+// Equal returns whether or not the trees rooted at a and b have the same structure and values
+// The tokens are ignored so the positions do not matter, types are equal when they are written the same way
+// and empty lists are equal to nil ones
+func Equal(a, b Node) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return equalValue(reflect.ValueOf(a), reflect.ValueOf(b))
+}
+
+func equalValue(a, b reflect.Value) bool {
+	if a.Type() != b.Type() {
+		return false
+	}
+	switch a.Kind() {
+	case reflect.Ptr:
+		if a.Type() == tokenType {
+			return true
+		}
+		if a.IsNil() || b.IsNil() {
+			return a.IsNil() == b.IsNil()
+		}
+		return equalValue(a.Elem(), b.Elem())
+	case reflect.Interface:
+		if a.IsNil() || b.IsNil() {
+			return a.IsNil() == b.IsNil()
+		}
+		if a.Type() == typeType {
+			return a.Interface().(types.Type).String() == b.Interface().(types.Type).String()
+		}
+		return equalValue(a.Elem(), b.Elem())
+	case reflect.Slice:
+		if a.Len() != b.Len() {
+			return false
+		}
+		for i := 0; i < a.Len(); i++ {
+			if !equalValue(a.Index(i), b.Index(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Struct:
+		for i := 0; i < a.NumField(); i++ {
+			if !equalValue(a.Field(i), b.Field(i)) {
+				return false
+			}
+		}
+		return true
+	}
+	return a.Interface() == b.Interface()
+}
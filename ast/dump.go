@@ -0,0 +1,352 @@
+package ast
+
+import (
+	"bufio"
+	"compiler/source"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// dumpNode is a node of the tree printed by FprintSExpr, FprintTree and FprintDot
+// head is the operator or the keyword of the node and attrs its values that are not nodes, such as a type
+// Identifiers and constants are atoms, written without parentheses in S-expressions
+type dumpNode struct {
+	head     string
+	attrs    []string
+	pos      source.Pos
+	atom     bool
+	children []*dumpNode
+}
+
+func atom(value string, pos source.Pos) *dumpNode {
+	return &dumpNode{head: value, pos: pos, atom: true}
+}
+
+// add appends the dump of the nodes that are not nil to the children of d
+func (d *dumpNode) add(nodes ...Node) {
+	for _, n := range nodes {
+		if c := dump(n); c != nil {
+			d.children = append(d.children, c)
+		}
+	}
+}
+
+// dump builds the tree printed for a node, nil for a nil node
+func dump(node Node) *dumpNode {
+	if node == nil {
+		return nil
+	}
+	d := &dumpNode{pos: Pos(node)}
+	switch n := node.(type) {
+	case *Program:
+		d.head = "program"
+		for _, s := range append(append([]Statement(nil), n.Statements...), n.Functions...) {
+			d.add(s)
+		}
+	case *DeclStatement:
+		d.head = "decl"
+		if n.Storage != "" {
+			d.attrs = append(d.attrs, n.Storage)
+		}
+		d.attrs = append(d.attrs, typeName(n.Type))
+		d.children = append(d.children, dump(&n.Left))
+		d.add(optional(n.Right)...)
+	case *DeclGroup:
+		d.head = "decls"
+		for _, s := range n.Decls {
+			d.add(s)
+		}
+	case *TypedefStatement:
+		d.head = "typedef"
+		d.attrs = []string{n.Name, typeName(n.Type)}
+	case *EnumStatement:
+		d.head = "enum"
+		if n.Name != "" {
+			d.attrs = []string{n.Name}
+		}
+		for _, e := range n.Enumerators {
+			if e.Value == nil {
+				d.children = append(d.children, atom(e.Name, source.NoPos))
+				continue
+			}
+			c := &dumpNode{head: "=", children: []*dumpNode{atom(e.Name, source.NoPos)}}
+			c.add(e.Value)
+			d.children = append(d.children, c)
+		}
+	case *StructStatement:
+		d.head = "struct"
+		if n.Name != "" {
+			d.attrs = []string{n.Name}
+		}
+		for _, f := range n.Fields {
+			d.children = append(d.children, &dumpNode{head: "field", attrs: []string{f.Name, typeName(f.Type)}})
+		}
+	case *ExpStatement:
+		d.head = "expr"
+		d.add(optional(n.Expression)...)
+	case *FunctionStatement:
+		d.head = "function"
+		if n.Storage != "" {
+			d.attrs = append(d.attrs, n.Storage)
+		}
+		d.attrs = append(d.attrs, n.Name, typeName(n.Return))
+		for _, p := range n.Parameters {
+			d.children = append(d.children, &dumpNode{head: "param", attrs: []string{p.Arg, typeName(p.Type)}})
+		}
+		if n.Variadic {
+			d.children = append(d.children, atom("...", source.NoPos))
+		}
+		if n.Body != nil {
+			d.add(n.Body)
+		}
+	case *ReturnStatement:
+		d.head = "return"
+		d.add(optional(n.ReturnValue)...)
+	case *BlockStatement:
+		d.head = "block"
+		for _, s := range n.Statements {
+			d.add(s)
+		}
+	case *IfStatement:
+		d.head = "if"
+		d.add(optional(n.Condition)...)
+		if n.Body != nil {
+			d.add(n.Body)
+		}
+		if n.ElseBody != nil {
+			d.add(n.ElseBody)
+		}
+	case *AsmStatement:
+		d.head = "asm"
+		if n.Volatile {
+			d.attrs = append(d.attrs, "volatile")
+		}
+		d.attrs = append(d.attrs, strconv.Quote(n.Template))
+		for _, o := range n.Outputs {
+			c := &dumpNode{head: "out", attrs: []string{strconv.Quote(o.Constraint)}}
+			c.add(optional(o.Expression)...)
+			d.children = append(d.children, c)
+		}
+		for _, o := range n.Inputs {
+			c := &dumpNode{head: "in", attrs: []string{strconv.Quote(o.Constraint)}}
+			c.add(optional(o.Expression)...)
+			d.children = append(d.children, c)
+		}
+		if len(n.Clobbers) != 0 {
+			c := &dumpNode{head: "clobbers"}
+			for _, r := range n.Clobbers {
+				c.attrs = append(c.attrs, strconv.Quote(r))
+			}
+			d.children = append(d.children, c)
+		}
+	case *Identifier:
+		return atom(n.Value, d.pos)
+	case *IntegerLiteral:
+		return atom(n.Value, d.pos)
+	case *AssignExpression:
+		d.head = n.Operator
+		d.add(optional(n.Left, n.Right)...)
+	case *PrefixExpression:
+		d.head = n.Operator
+		d.add(optional(n.Expression)...)
+	case *PostfixExpression:
+		d.head = "post" + n.Operator
+		d.add(optional(n.Expression)...)
+	case *InfixExpression:
+		d.head = n.Operator
+		d.add(optional(n.Left, n.Right)...)
+	case *CommaExpression:
+		d.head = ","
+		d.add(optional(n.Left, n.Right)...)
+	case *CastExpression:
+		d.head = "cast"
+		d.attrs = []string{typeName(n.Type)}
+		d.add(optional(n.Expression)...)
+	case *SizeofExpression:
+		d.head = "sizeof"
+		if n.Type != nil {
+			d.attrs = []string{typeName(n.Type)}
+		}
+		d.add(optional(n.Expression)...)
+	case *BuiltinExpression:
+		d.head = n.Name
+		if n.Type != nil {
+			d.attrs = []string{typeName(n.Type)}
+		}
+		d.add(optional(n.Arguments...)...)
+	case *CallExpression:
+		d.head = "call"
+		d.add(optional(n.Function)...)
+		d.add(optional(n.Arguments...)...)
+	case *IndexExpression:
+		d.head = "[]"
+		d.add(optional(n.Left, n.Index)...)
+	case *MemberExpression:
+		d.head = n.Operator
+		d.add(optional(n.Left)...)
+		d.children = append(d.children, atom(n.Member, source.NoPos))
+	case *InitializerList:
+		d.head = "init"
+		for _, e := range n.Elements {
+			if len(e.Designators) == 0 {
+				d.add(optional(e.Value)...)
+				continue
+			}
+			c := &dumpNode{head: "="}
+			for _, des := range e.Designators {
+				if des.Index == nil {
+					c.children = append(c.children, atom("."+des.Field, source.NoPos))
+					continue
+				}
+				index := &dumpNode{head: "[]"}
+				index.add(des.Index)
+				c.children = append(c.children, index)
+			}
+			c.add(optional(e.Value)...)
+			d.children = append(d.children, c)
+		}
+	default:
+		panic(fmt.Sprintf("ast: unexpected node type %T", n))
+	}
+	return d
+}
+
+// optional returns the expressions that are not nil as nodes
+func optional(list ...Expression) []Node {
+	var nodes []Node
+	for _, e := range list {
+		if e != nil {
+			nodes = append(nodes, e)
+		}
+	}
+	return nodes
+}
+
+func typeName(t fmt.Stringer) string {
+	if t == nil {
+		return "?"
+	}
+	return t.String()
+}
+
+// quote quotes the values that would not read as a single word, such as the type "unsigned int"
+func quote(s string) string {
+	if s == "" || strings.ContainsAny(s, " ()\t\n\"") {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// label returns the head and the attributes of a node separated by spaces
+func (d *dumpNode) label() string {
+	parts := []string{d.head}
+	for _, a := range d.attrs {
+		if strings.HasPrefix(a, `"`) {
+			parts = append(parts, a)
+		} else {
+			parts = append(parts, quote(a))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// FprintSExpr writes the tree rooted at node as an S-expression such as (return (+ 1 (* 2 3)))
+// followed by a newline, statements are written one per line
+func FprintSExpr(w io.Writer, node Node) error {
+	b := bufio.NewWriter(w)
+	writeSExpr(b, dump(node), 0)
+	b.WriteByte('\n')
+	return b.Flush()
+}
+
+func writeSExpr(b *bufio.Writer, d *dumpNode, depth int) {
+	if d == nil {
+		b.WriteString("()")
+		return
+	}
+	if d.atom && len(d.children) == 0 {
+		b.WriteString(quote(d.head))
+		return
+	}
+	b.WriteByte('(')
+	b.WriteString(d.label())
+	for _, c := range d.children {
+		// Statements and the members of declarations start lines, expressions stay on the line of their parent
+		if !c.atom && isBlock(d.head) {
+			b.WriteByte('\n')
+			b.WriteString(strings.Repeat("  ", depth+1))
+		} else {
+			b.WriteByte(' ')
+		}
+		writeSExpr(b, c, depth+1)
+	}
+	b.WriteByte(')')
+}
+
+// isBlock returns whether or not the children of a node with this head are written one per line
+func isBlock(head string) bool {
+	switch head {
+	case "program", "function", "block", "decls", "struct", "enum", "if":
+		return true
+	}
+	return false
+}
+
+// FprintTree writes the tree rooted at node with one node per line, indented by its depth
+// and followed by its position when fset is not nil
+func FprintTree(w io.Writer, fset *source.FileSet, node Node) error {
+	b := bufio.NewWriter(w)
+	writeTree(b, fset, dump(node), 0)
+	return b.Flush()
+}
+
+func writeTree(b *bufio.Writer, fset *source.FileSet, d *dumpNode, depth int) {
+	if d == nil {
+		return
+	}
+	b.WriteString(strings.Repeat("  ", depth))
+	if d.atom {
+		b.WriteString(quote(d.head))
+	} else {
+		b.WriteString(d.label())
+	}
+	if fset != nil && d.pos.IsValid() {
+		b.WriteString("  ")
+		b.WriteString(fset.Position(d.pos).String())
+	}
+	b.WriteByte('\n')
+	for _, c := range d.children {
+		writeTree(b, fset, c, depth+1)
+	}
+}
+
+// FprintDot writes the tree rooted at node as a Graphviz digraph, the children of a node are ordered
+// left to right as they are written in the source
+func FprintDot(w io.Writer, node Node) error {
+	b := bufio.NewWriter(w)
+	b.WriteString("digraph AST {\n")
+	b.WriteString("  graph [ordering=out];\n")
+	b.WriteString("  node [shape=box, fontname=monospace];\n")
+	id := 0
+	var write func(d *dumpNode) int
+	write = func(d *dumpNode) int {
+		n := id
+		id++
+		shape := ""
+		if d.atom {
+			shape = ", shape=ellipse"
+		}
+		fmt.Fprintf(b, "  n%d [label=%s%s];\n", n, strconv.Quote(d.label()), shape)
+		for _, c := range d.children {
+			fmt.Fprintf(b, "  n%d -> n%d;\n", n, write(c))
+		}
+		return n
+	}
+	if d := dump(node); d != nil {
+		write(d)
+	}
+	b.WriteString("}\n")
+	return b.Flush()
+}
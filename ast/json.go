@@ -0,0 +1,506 @@
+package ast
+
+import (
+	"bytes"
+	"compiler/lexer"
+	"compiler/source"
+	"compiler/types"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// JSONVersion is the version of the JSON form of the AST written by MarshalJSON
+// It changes whenever a node or a field is renamed or removed
+const JSONVersion = 1
+
+// The JSON form of the AST is a document holding the version, the source files and the program
+// {"version": 1, "files": [{"name": "main.c", "size": 120, "lines": [0, 18, 40]}], "program": {"kind": "Program", ...}}
+// Every node is an object with its type name as "kind", its position as "pos" written file:line:col
+// when it has one, then its fields named as in the JSON tags of the node types
+// The other tokens, such as the "}" of a block, are written as positions too
+// Types are written as C type names, see types.Parse, a type with array lengths written as expressions
+// is an object holding its name and the expressions in the order of types.Lengths
+// {"name": "int [N + 1]", "lengths": [{"kind": "InfixExpression", ...}]}
+// The fields tagged required, such as the operands of an operator, cannot be left out or null
+type jsonDocument struct {
+	Version int             `json:"version"`
+	Files   []jsonFile      `json:"files"`
+	Program json.RawMessage `json:"program"`
+}
+
+// jsonFile holds what is needed to decode the positions of a source file
+type jsonFile struct {
+	Name  string `json:"name"`
+	Size  int    `json:"size"`
+	Lines []int  `json:"lines"`
+}
+
+// nodeTypes maps the kinds of the JSON form to the types of the nodes
+var nodeTypes = map[string]reflect.Type{}
+
+func init() {
+	nodes := []Node{
+		&Program{}, &DeclStatement{}, &DeclGroup{}, &TypedefStatement{}, &EnumStatement{}, &StructStatement{},
+		&ExpStatement{}, &FunctionStatement{}, &ReturnStatement{}, &BlockStatement{}, &IfStatement{}, &AsmStatement{},
+		&Identifier{}, &IntegerLiteral{}, &AssignExpression{}, &PrefixExpression{}, &PostfixExpression{},
+		&InfixExpression{}, &CommaExpression{}, &CastExpression{}, &SizeofExpression{}, &BuiltinExpression{},
+		&CallExpression{}, &IndexExpression{}, &MemberExpression{}, &InitializerList{},
+	}
+	for _, n := range nodes {
+		t := reflect.TypeOf(n).Elem()
+		nodeTypes[t.Name()] = t
+	}
+}
+
+var nodeType = reflect.TypeOf((*Node)(nil)).Elem()
+
+// Pos returns the position of the token of a node, an ExpStatement is at its expression
+// NoPos is returned for the Program and for the nodes built without tokens
+func Pos(node Node) source.Pos {
+	if s, ok := node.(*ExpStatement); ok && s.Token == nil {
+		if s.Expression == nil {
+			return source.NoPos
+		}
+		return Pos(s.Expression)
+	}
+	v := reflect.ValueOf(node)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return source.NoPos
+	}
+	f := v.Elem().FieldByName("Token")
+	if !f.IsValid() || f.IsNil() {
+		return source.NoPos
+	}
+	return f.Interface().(*lexer.Token).Pos
+}
+
+// MarshalJSON returns the JSON form of a program, fset decodes the positions of its tokens
+// Positions are left out when fset is nil
+func MarshalJSON(fset *source.FileSet, program *Program) ([]byte, error) {
+	e := &encoder{fset: fset}
+	if err := e.node(program); err != nil {
+		return nil, err
+	}
+	doc := jsonDocument{Version: JSONVersion, Files: make([]jsonFile, 0), Program: e.buf.Bytes()}
+	if fset != nil {
+		for _, f := range fset.Files() {
+			doc.Files = append(doc.Files, jsonFile{Name: f.Name(), Size: f.Size(), Lines: f.Lines()})
+		}
+	}
+	return json.Marshal(doc)
+}
+
+type encoder struct {
+	fset *source.FileSet
+	buf  bytes.Buffer
+}
+
+func (e *encoder) node(n Node) error {
+	v := reflect.ValueOf(n).Elem()
+	e.buf.WriteString(`{"kind":`)
+	e.buf.WriteString(strconv.Quote(v.Type().Name()))
+	if pos := Pos(n); pos.IsValid() && e.fset != nil {
+		e.buf.WriteString(`,"pos":`)
+		e.buf.WriteString(strconv.Quote(e.fset.Position(pos).String()))
+	}
+	if err := e.fields(v, false); err != nil {
+		return err
+	}
+	e.buf.WriteByte('}')
+	return nil
+}
+
+// fields writes the fields of a struct, first is set when no field was written before in the object
+func (e *encoder) fields(v reflect.Value, first bool) error {
+	for i := 0; i < v.NumField(); i++ {
+		name, opts := jsonName(v.Type().Field(i))
+		f := v.Field(i)
+		if name == "" || opts.omitEmpty && isEmpty(f) {
+			continue
+		}
+		if !first {
+			e.buf.WriteByte(',')
+		}
+		first = false
+		e.buf.WriteString(strconv.Quote(name))
+		e.buf.WriteByte(':')
+		if err := e.value(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *encoder) value(v reflect.Value) error {
+	if v.Type() == tokenType {
+		// Tokens other than the one of a node are written as their position, like "pos"
+		pos := "-"
+		if !v.IsNil() && e.fset != nil {
+			pos = e.fset.Position(v.Interface().(*lexer.Token).Pos).String()
+		}
+		e.buf.WriteString(strconv.Quote(pos))
+		return nil
+	}
+	if v.Type() == typeType && !v.IsNil() {
+		if arrays := types.Lengths(v.Interface().(types.Type)); len(arrays) != 0 {
+			return e.typeLengths(v.Interface().(types.Type), arrays)
+		}
+	}
+	switch v.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		if v.IsNil() {
+			e.buf.WriteString("null")
+			return nil
+		}
+		if n, ok := v.Interface().(Node); ok {
+			return e.node(n)
+		}
+	case reflect.Struct:
+		if v.Addr().Type().Implements(nodeType) {
+			return e.node(v.Addr().Interface().(Node))
+		}
+		e.buf.WriteByte('{')
+		if err := e.fields(v, true); err != nil {
+			return err
+		}
+		e.buf.WriteByte('}')
+		return nil
+	case reflect.Slice:
+		if v.IsNil() {
+			e.buf.WriteString("null")
+			return nil
+		}
+		e.buf.WriteByte('[')
+		for i := 0; i < v.Len(); i++ {
+			if i != 0 {
+				e.buf.WriteByte(',')
+			}
+			if err := e.value(v.Index(i)); err != nil {
+				return err
+			}
+		}
+		e.buf.WriteByte(']')
+		return nil
+	}
+	b, err := json.Marshal(v.Interface())
+	if err != nil {
+		return err
+	}
+	e.buf.Write(b)
+	return nil
+}
+
+// typeLengths writes a type along the expressions of the lengths of its arrays
+func (e *encoder) typeLengths(t types.Type, arrays []*types.Array) error {
+	e.buf.WriteString(`{"name":`)
+	e.buf.WriteString(strconv.Quote(t.String()))
+	e.buf.WriteString(`,"lengths":[`)
+	for i, a := range arrays {
+		if i != 0 {
+			e.buf.WriteByte(',')
+		}
+		n, ok := a.LenExpr.(Node)
+		if !ok {
+			return fmt.Errorf("Array size '%s' of '%s' is not a node", a.LenExpr, t)
+		}
+		if err := e.node(n); err != nil {
+			return err
+		}
+	}
+	e.buf.WriteString("]}")
+	return nil
+}
+
+// jsonName returns the name of a field in the JSON form, "" for the fields left out such as tokens,
+// and the options of its tag such as "omitempty"
+func jsonName(f reflect.StructField) (string, jsonOptions) {
+	tag := f.Tag.Get("json")
+	if tag == "-" || tag == "" {
+		return "", jsonOptions{}
+	}
+	parts := strings.Split(tag, ",")
+	var opts jsonOptions
+	for _, opt := range parts[1:] {
+		switch opt {
+		case "omitempty":
+			opts.omitEmpty = true
+		case "required":
+			opts.required = true
+		}
+	}
+	return parts[0], opts
+}
+
+// jsonOptions are the options of a JSON tag, a required field is a child without which the node
+// cannot be compiled, such as the operands of an InfixExpression, UnmarshalJSON rejects a node without it
+type jsonOptions struct {
+	omitEmpty bool
+	required  bool
+}
+
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	case reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	}
+	return false
+}
+
+// UnmarshalJSON rebuilds a program from its JSON form, the files of the document are added to fset
+// so the positions of the nodes can be decoded
+// The tokens of the nodes only hold their position
+func UnmarshalJSON(fset *source.FileSet, data []byte) (*Program, error) {
+	var doc jsonDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Version != JSONVersion {
+		return nil, fmt.Errorf("Unsupported AST version %d, expected %d", doc.Version, JSONVersion)
+	}
+	d := &decoder{files: map[string]*source.File{}}
+	for _, f := range doc.Files {
+		file := fset.AddFile(f.Name, f.Size)
+		for _, offset := range f.Lines {
+			if offset > f.Size {
+				return nil, fmt.Errorf("Line offset %d is outside of file '%s'", offset, f.Name)
+			}
+			file.AddLine(offset)
+		}
+		if _, ok := d.files[f.Name]; !ok {
+			d.files[f.Name] = file
+		}
+	}
+	n, err := d.node(doc.Program)
+	if err != nil {
+		return nil, err
+	}
+	program, ok := n.(*Program)
+	if !ok {
+		return nil, fmt.Errorf("Expected a Program got '%s'", n.TokenLiteral())
+	}
+	for _, fn := range program.Functions {
+		if _, ok := fn.(*FunctionStatement); !ok {
+			return nil, fmt.Errorf("Program: 'functions': Expected FunctionStatement got '%s'", fn.TokenLiteral())
+		}
+	}
+	return program, nil
+}
+
+type decoder struct {
+	files map[string]*source.File
+}
+
+func isNull(raw json.RawMessage) bool {
+	return len(raw) == 0 || string(raw) == "null"
+}
+
+func (d *decoder) node(raw json.RawMessage) (Node, error) {
+	if isNull(raw) {
+		return nil, nil
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+	var kind string
+	if err := json.Unmarshal(fields["kind"], &kind); err != nil {
+		return nil, fmt.Errorf("Node without kind: %s", err)
+	}
+	t, ok := nodeTypes[kind]
+	if !ok {
+		return nil, fmt.Errorf("Unknown node kind '%s'", kind)
+	}
+	v := reflect.New(t)
+	if pos, ok := fields["pos"]; ok {
+		var s string
+		if err := json.Unmarshal(pos, &s); err != nil {
+			return nil, err
+		}
+		p, err := d.pos(s)
+		if err != nil {
+			return nil, err
+		}
+		if f := v.Elem().FieldByName("Token"); f.IsValid() && p.IsValid() {
+			f.Set(reflect.ValueOf(&lexer.Token{Pos: p}))
+		}
+	}
+	if err := d.fields(fields, v.Elem()); err != nil {
+		return nil, fmt.Errorf("%s: %s", kind, err)
+	}
+	return v.Interface().(Node), nil
+}
+
+// pos returns the position written file:line:col, the file name can hold colons
+func (d *decoder) pos(s string) (source.Pos, error) {
+	if s == "-" {
+		return source.NoPos, nil
+	}
+	invalid := fmt.Errorf("Invalid position '%s'", s)
+	i := strings.LastIndexByte(s, ':')
+	if i < 0 {
+		return source.NoPos, invalid
+	}
+	col, err := strconv.Atoi(s[i+1:])
+	if err != nil {
+		return source.NoPos, invalid
+	}
+	name := ""
+	j := strings.LastIndexByte(s[:i], ':')
+	if j >= 0 {
+		name = s[:j]
+	}
+	line, err := strconv.Atoi(s[j+1 : i])
+	if err != nil {
+		return source.NoPos, invalid
+	}
+	f, ok := d.files[name]
+	if !ok {
+		return source.NoPos, fmt.Errorf("Unknown file in position '%s'", s)
+	}
+	lines := f.Lines()
+	if line < 1 || line > len(lines) || col < 1 {
+		return source.NoPos, invalid
+	}
+	offset := lines[line-1] + col - 1
+	if offset > f.Size() {
+		return source.NoPos, invalid
+	}
+	return f.Pos(offset), nil
+}
+
+// fields sets the fields of the struct v from the fields of a JSON object, missing fields are left empty
+func (d *decoder) fields(fields map[string]json.RawMessage, v reflect.Value) error {
+	for i := 0; i < v.NumField(); i++ {
+		name, opts := jsonName(v.Type().Field(i))
+		raw, ok := fields[name]
+		if name != "" && opts.required && isNull(raw) {
+			return fmt.Errorf("Missing '%s'", name)
+		}
+		if name == "" || !ok {
+			continue
+		}
+		if err := d.value(raw, v.Field(i)); err != nil {
+			return fmt.Errorf("'%s': %s", name, err)
+		}
+	}
+	return nil
+}
+
+// typ reads a type written as its name or as an object holding its name and the lengths of its arrays
+func (d *decoder) typ(raw json.RawMessage) (types.Type, error) {
+	var name string
+	if err := json.Unmarshal(raw, &name); err == nil {
+		return types.Parse(name)
+	}
+	var named struct {
+		Name    string            `json:"name"`
+		Lengths []json.RawMessage `json:"lengths"`
+	}
+	if err := json.Unmarshal(raw, &named); err != nil {
+		return nil, err
+	}
+	t, err := types.Parse(named.Name)
+	if err != nil {
+		return nil, err
+	}
+	arrays := types.Lengths(t)
+	if len(arrays) != len(named.Lengths) {
+		return nil, fmt.Errorf("Expected %d array sizes for '%s' got %d", len(arrays), named.Name, len(named.Lengths))
+	}
+	for i, a := range arrays {
+		n, err := d.node(named.Lengths[i])
+		if err != nil {
+			return nil, err
+		}
+		x, ok := n.(Expression)
+		if !ok {
+			return nil, fmt.Errorf("Array size %d of '%s' is not an expression", i+1, named.Name)
+		}
+		a.LenExpr = x
+	}
+	return t, nil
+}
+
+func (d *decoder) value(raw json.RawMessage, v reflect.Value) error {
+	switch {
+	case v.Type() == tokenType:
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return err
+		}
+		pos, err := d.pos(s)
+		if err != nil {
+			return err
+		}
+		if pos.IsValid() {
+			v.Set(reflect.ValueOf(&lexer.Token{Pos: pos}))
+		}
+		return nil
+	case v.Type() == typeType:
+		if isNull(raw) {
+			return nil
+		}
+		t, err := d.typ(raw)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(&t).Elem())
+		return nil
+	case v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr && v.Type().Implements(nodeType):
+		n, err := d.node(raw)
+		if err != nil || n == nil {
+			return err
+		}
+		nv := reflect.ValueOf(n)
+		if !nv.Type().AssignableTo(v.Type()) {
+			return fmt.Errorf("Unexpected '%s'", n.TokenLiteral())
+		}
+		v.Set(nv)
+		return nil
+	case v.Kind() == reflect.Struct && v.Addr().Type().Implements(nodeType):
+		n, err := d.node(raw)
+		if err != nil {
+			return err
+		}
+		nv := reflect.ValueOf(n)
+		if n == nil || nv.Type() != v.Addr().Type() {
+			return fmt.Errorf("Expected %s", v.Type().Name())
+		}
+		v.Set(nv.Elem())
+		return nil
+	case v.Kind() == reflect.Struct:
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &fields); err != nil {
+			return err
+		}
+		return d.fields(fields, v)
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8:
+		if isNull(raw) {
+			return nil
+		}
+		var elems []json.RawMessage
+		if err := json.Unmarshal(raw, &elems); err != nil {
+			return err
+		}
+		s := reflect.MakeSlice(v.Type(), len(elems), len(elems))
+		for i, elem := range elems {
+			// A list of nodes has no optional element
+			if v.Type().Elem().Kind() == reflect.Interface && isNull(elem) {
+				return fmt.Errorf("Missing element %d", i)
+			}
+			if err := d.value(elem, s.Index(i)); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+		return nil
+	}
+	return json.Unmarshal(raw, v.Addr().Interface())
+}
@@ -0,0 +1,77 @@
+package ast_test
+
+import (
+	"compiler/ast"
+	"compiler/generator"
+	"compiler/lexer"
+	"compiler/parser"
+	"compiler/source"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// corpus returns the paths of the sources the tests read, the ones of parser/testdata and files
+func corpus(t *testing.T) []string {
+	t.Helper()
+	paths := make([]string, 0)
+	for _, pattern := range []string{"../parser/testdata/*.c", "../files/*.c"} {
+		matches, err := filepath.Glob(filepath.FromSlash(pattern))
+		if err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, matches...)
+	}
+	if len(paths) == 0 {
+		t.Fatal("No source in the corpus")
+	}
+	return paths
+}
+
+// parseFile reads the program of a source file with the recursive descent parser
+func parseFile(t *testing.T, fset *source.FileSet, path string) *ast.Program {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	program, err := parser.NewParser(lexer.NewFileLexer(f, fset.AddFile(path, -1))).ParseProgram()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return program
+}
+
+// TestJSONRoundTrip writes the programs of the corpus as JSON and reads them back,
+// the trees have to be the same and give the same assembly
+func TestJSONRoundTrip(t *testing.T) {
+	for _, path := range corpus(t) {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			fset := source.NewFileSet()
+			program := parseFile(t, fset, path)
+			data, err := ast.MarshalJSON(fset, program)
+			if err != nil {
+				t.Fatal(err)
+			}
+			decoded, err := ast.UnmarshalJSON(source.NewFileSet(), data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !ast.Equal(program, decoded) {
+				t.Fatalf("The decoded program differs from the source\n%s", data)
+			}
+			asm, err := generator.NewAssemblyGenerator().FromProgram(program)
+			if err != nil {
+				t.Fatal(err)
+			}
+			decodedAsm, err := generator.NewAssemblyGenerator().FromProgram(decoded)
+			if err != nil {
+				t.Fatalf("Decoded program: %s", err)
+			}
+			if asm != decodedAsm {
+				t.Errorf("The decoded program gives another assembly\n%s\nthen\n%s", asm, decodedAsm)
+			}
+		})
+	}
+}
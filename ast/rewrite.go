@@ -0,0 +1,324 @@
+package ast
+
+import (
+	"compiler/types"
+	"errors"
+	"fmt"
+)
+
+// ApplyFunc is called by Apply for the node of a Cursor
+type ApplyFunc func(c *Cursor) bool
+
+// Apply traverses the tree rooted at root in depth-first order like Walk and returns the possibly replaced root
+// pre is called on a node before its children and post after them, either can be nil
+// When pre returns false the children of the node and post are skipped, when post returns false Apply stops
+// Optional children such as the else branch of an IfStatement are visited even when they are nil
+// so a node can be added with Replace
+func Apply(root Node, pre, post ApplyFunc) (result Node) {
+	a := &application{pre: pre, post: post}
+	defer func() {
+		if r := recover(); r != nil && r != errAbort {
+			panic(r)
+		}
+	}()
+	result = root
+	a.apply(nil, "Root", root, func(n Node) { result = n })
+	return result
+}
+
+// errAbort unwinds Apply when post returns false
+var errAbort = errors.New("abort")
+
+// Cursor describes a node found by Apply and the field of its parent holding it
+type Cursor struct {
+	parent Node
+	name   string
+	node   Node
+	// set replaces the node in its parent, list is used instead when the node is an element of a list
+	set  func(Node)
+	list nodeList
+	iter *iterator
+}
+
+// iterator is the position in a list, step is how much to move to reach the next element once the cursor
+// is done, it changes when elements are deleted or inserted after the current one
+type iterator struct {
+	index, step int
+}
+
+// Node returns the current node, it is nil for an optional child that is not set
+func (c *Cursor) Node() Node { return c.node }
+
+// Parent returns the node holding the current node, nil for the root
+func (c *Cursor) Parent() Node { return c.parent }
+
+// Name returns the name of the field of the parent holding the current node, such as "Right" or "Statements"
+func (c *Cursor) Name() string { return c.name }
+
+// Index returns the index of the current node in the list of its parent, -1 when it is not in a list
+func (c *Cursor) Index() int {
+	if c.list == nil {
+		return -1
+	}
+	return c.iter.index
+}
+
+// Replace replaces the current node with n, the children of n are the ones visited next
+// It panics when n cannot be stored in the field, such as an Expression in a list of statements
+func (c *Cursor) Replace(n Node) {
+	if c.list != nil {
+		c.list.set(c.iter.index, n)
+	} else {
+		c.set(n)
+	}
+	c.node = n
+}
+
+// Delete removes the current node from its list, the children of the node are still visited
+func (c *Cursor) Delete() {
+	if c.list == nil {
+		panic("ast: Delete of a node that is not in a list")
+	}
+	c.list.delete(c.iter.index)
+	c.iter.step--
+}
+
+// InsertAfter inserts n after the current node in its list, n is not visited, the traversal goes on
+// with the node that followed the current one
+func (c *Cursor) InsertAfter(n Node) {
+	if c.list == nil {
+		panic("ast: InsertAfter of a node that is not in a list")
+	}
+	c.list.insert(c.iter.index+1, n)
+	c.iter.step++
+}
+
+// InsertBefore inserts n before the current node in its list, n is not visited
+func (c *Cursor) InsertBefore(n Node) {
+	if c.list == nil {
+		panic("ast: InsertBefore of a node that is not in a list")
+	}
+	c.list.insert(c.iter.index, n)
+	c.iter.index++
+}
+
+// nodeList gives access to a list of statements or expressions of a node
+type nodeList interface {
+	len() int
+	at(i int) Node
+	set(i int, n Node)
+	delete(i int)
+	insert(i int, n Node)
+}
+
+type statementList struct{ l *[]Statement }
+
+func (s statementList) len() int { return len(*s.l) }
+
+func (s statementList) at(i int) Node { return (*s.l)[i] }
+
+func (s statementList) set(i int, n Node) { (*s.l)[i] = toStatement(n) }
+
+func (s statementList) delete(i int) { *s.l = append((*s.l)[:i], (*s.l)[i+1:]...) }
+
+func (s statementList) insert(i int, n Node) {
+	*s.l = append(*s.l, nil)
+	copy((*s.l)[i+1:], (*s.l)[i:])
+	(*s.l)[i] = toStatement(n)
+}
+
+type expressionList struct{ l *[]Expression }
+
+func (e expressionList) len() int { return len(*e.l) }
+
+func (e expressionList) at(i int) Node { return (*e.l)[i] }
+
+func (e expressionList) set(i int, n Node) { (*e.l)[i] = toExpression(n) }
+
+func (e expressionList) delete(i int) { *e.l = append((*e.l)[:i], (*e.l)[i+1:]...) }
+
+func (e expressionList) insert(i int, n Node) {
+	*e.l = append(*e.l, nil)
+	copy((*e.l)[i+1:], (*e.l)[i:])
+	(*e.l)[i] = toExpression(n)
+}
+
+func toStatement(n Node) Statement {
+	if n == nil {
+		return nil
+	}
+	s, ok := n.(Statement)
+	if !ok {
+		panic(fmt.Sprintf("ast: %T is not a Statement", n))
+	}
+	return s
+}
+
+func toExpression(n Node) Expression {
+	if n == nil {
+		return nil
+	}
+	e, ok := n.(Expression)
+	if !ok {
+		panic(fmt.Sprintf("ast: %T is not an Expression", n))
+	}
+	return e
+}
+
+type application struct {
+	pre, post ApplyFunc
+}
+
+func (a *application) apply(parent Node, name string, node Node, set func(Node)) {
+	a.visit(&Cursor{parent: parent, name: name, node: node, set: set})
+}
+
+func (a *application) applyList(parent Node, name string, list nodeList) {
+	iter := &iterator{}
+	for iter.index < list.len() {
+		iter.step = 1
+		a.visit(&Cursor{parent: parent, name: name, node: list.at(iter.index), list: list, iter: iter})
+		iter.index += iter.step
+	}
+}
+
+func (a *application) visit(c *Cursor) {
+	if a.pre != nil && !a.pre(c) {
+		return
+	}
+	if c.node != nil {
+		a.children(c.node)
+	}
+	if a.post != nil && !a.post(c) {
+		panic(errAbort)
+	}
+}
+
+// applyType applies the functions to the lengths of the arrays of a type written as expressions,
+// replacing one changes the array holding it
+func (a *application) applyType(parent Node, name string, t types.Type) {
+	for _, arr := range types.Lengths(t) {
+		arr := arr
+		if e, ok := arr.LenExpr.(Expression); ok {
+			a.apply(parent, name, e, func(x Node) { arr.LenExpr = toExpression(x) })
+		}
+	}
+}
+
+// children applies the functions to the children of node in the order of Walk
+func (a *application) children(node Node) {
+	switch n := node.(type) {
+	case *Program:
+		a.applyList(n, "Statements", statementList{&n.Statements})
+		a.applyList(n, "Functions", statementList{&n.Functions})
+	case *DeclStatement:
+		a.apply(n, "Left", &n.Left, func(x Node) {
+			id, ok := x.(*Identifier)
+			if !ok {
+				panic(fmt.Sprintf("ast: %T is not an *Identifier", x))
+			}
+			n.Left = *id
+		})
+		a.applyType(n, "Type", n.Type)
+		a.apply(n, "Right", n.Right, func(x Node) { n.Right = toExpression(x) })
+	case *DeclGroup:
+		a.applyList(n, "Decls", statementList{&n.Decls})
+	case *TypedefStatement:
+		a.applyType(n, "Type", n.Type)
+	case *StructStatement:
+		for _, f := range n.Fields {
+			a.applyType(n, "Fields", f.Type)
+		}
+	case *Identifier, *IntegerLiteral:
+	case *EnumStatement:
+		for i := range n.Enumerators {
+			e := &n.Enumerators[i]
+			a.apply(n, "Enumerators", e.Value, func(x Node) { e.Value = toExpression(x) })
+		}
+	case *ExpStatement:
+		a.apply(n, "Expression", n.Expression, func(x Node) { n.Expression = toExpression(x) })
+	case *FunctionStatement:
+		a.applyType(n, "Return", n.Return)
+		for _, arg := range n.Parameters {
+			a.applyType(n, "Parameters", arg.Type)
+		}
+		var body Node
+		if n.Body != nil {
+			body = n.Body
+		}
+		a.apply(n, "Body", body, func(x Node) {
+			if x == nil {
+				n.Body = nil
+				return
+			}
+			b, ok := x.(*BlockStatement)
+			if !ok {
+				panic(fmt.Sprintf("ast: %T is not a *BlockStatement", x))
+			}
+			n.Body = b
+		})
+	case *ReturnStatement:
+		a.apply(n, "ReturnValue", n.ReturnValue, func(x Node) { n.ReturnValue = toExpression(x) })
+	case *BlockStatement:
+		a.applyList(n, "Statements", statementList{&n.Statements})
+	case *IfStatement:
+		a.apply(n, "Condition", n.Condition, func(x Node) { n.Condition = toExpression(x) })
+		a.apply(n, "Body", n.Body, func(x Node) { n.Body = toStatement(x) })
+		a.apply(n, "ElseBody", n.ElseBody, func(x Node) { n.ElseBody = toStatement(x) })
+	case *AsmStatement:
+		for i := range n.Outputs {
+			o := &n.Outputs[i]
+			a.apply(n, "Outputs", o.Expression, func(x Node) { o.Expression = toExpression(x) })
+		}
+		for i := range n.Inputs {
+			o := &n.Inputs[i]
+			a.apply(n, "Inputs", o.Expression, func(x Node) { o.Expression = toExpression(x) })
+		}
+	case *AssignExpression:
+		a.apply(n, "Left", n.Left, func(x Node) { n.Left = toExpression(x) })
+		a.apply(n, "Right", n.Right, func(x Node) { n.Right = toExpression(x) })
+	case *PrefixExpression:
+		a.apply(n, "Expression", n.Expression, func(x Node) { n.Expression = toExpression(x) })
+	case *PostfixExpression:
+		a.apply(n, "Expression", n.Expression, func(x Node) { n.Expression = toExpression(x) })
+	case *InfixExpression:
+		a.apply(n, "Left", n.Left, func(x Node) { n.Left = toExpression(x) })
+		a.apply(n, "Right", n.Right, func(x Node) { n.Right = toExpression(x) })
+	case *CommaExpression:
+		a.apply(n, "Left", n.Left, func(x Node) { n.Left = toExpression(x) })
+		a.apply(n, "Right", n.Right, func(x Node) { n.Right = toExpression(x) })
+	case *CastExpression:
+		a.applyType(n, "Type", n.Type)
+		a.apply(n, "Expression", n.Expression, func(x Node) { n.Expression = toExpression(x) })
+	case *SizeofExpression:
+		a.applyType(n, "Type", n.Type)
+		// A sizeof of a type name has no expression to add
+		if n.Expression != nil {
+			a.apply(n, "Expression", n.Expression, func(x Node) { n.Expression = toExpression(x) })
+		}
+	case *BuiltinExpression:
+		a.applyList(n, "Arguments", expressionList{&n.Arguments})
+		a.applyType(n, "Type", n.Type)
+	case *CallExpression:
+		a.apply(n, "Function", n.Function, func(x Node) { n.Function = toExpression(x) })
+		a.applyList(n, "Arguments", expressionList{&n.Arguments})
+	case *IndexExpression:
+		a.apply(n, "Left", n.Left, func(x Node) { n.Left = toExpression(x) })
+		a.apply(n, "Index", n.Index, func(x Node) { n.Index = toExpression(x) })
+	case *MemberExpression:
+		a.apply(n, "Left", n.Left, func(x Node) { n.Left = toExpression(x) })
+	case *InitializerList:
+		for i := range n.Elements {
+			e := &n.Elements[i]
+			for j := range e.Designators {
+				d := &e.Designators[j]
+				if d.Index != nil {
+					a.apply(n, "Elements", d.Index, func(x Node) { d.Index = toExpression(x) })
+				}
+			}
+			a.apply(n, "Elements", e.Value, func(x Node) { e.Value = toExpression(x) })
+		}
+	default:
+		panic(fmt.Sprintf("ast.Apply: unexpected node type %T", n))
+	}
+}
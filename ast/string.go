@@ -0,0 +1,148 @@
+package ast
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Binding powers of the C operators used by ExprString, from the loosest to the tightest
+const (
+	precComma = iota + 1
+	precAssign
+	precLogicalOr
+	precLogicalAnd
+	precEquality
+	precRelational
+	precAdditive
+	precMultiplicative
+	precUnary
+	precPostfix
+	precPrimary
+)
+
+// infixPrecedences holds the binding power of the binary operators of C
+var infixPrecedences = map[string]int{
+	"||": precLogicalOr,
+	"&&": precLogicalAnd,
+	"==": precEquality, "!=": precEquality,
+	"<": precRelational, ">": precRelational, "<=": precRelational, ">=": precRelational,
+	"+": precAdditive, "-": precAdditive,
+	"*": precMultiplicative, "/": precMultiplicative, "%": precMultiplicative,
+}
+
+// ExprString returns the C source of an expression with only the parentheses the precedence of C needs,
+// it is how an expression written in a type, such as the length of an array, is shown in the type name
+// The printer uses the operator table of the parser instead so the operators of a dialect are known
+func ExprString(e Expression) string {
+	return exprString(e, precComma)
+}
+
+// exprPrecedence returns the binding power of the operator at the root of an expression
+func exprPrecedence(e Expression) int {
+	switch e := e.(type) {
+	case *CommaExpression:
+		return precComma
+	case *AssignExpression:
+		return precAssign
+	case *InfixExpression:
+		if prec, ok := infixPrecedences[e.Operator]; ok {
+			return prec
+		}
+		// An operator of a dialect is always parenthesized
+		return precComma - 1
+	case *PrefixExpression, *CastExpression, *SizeofExpression:
+		return precUnary
+	case *PostfixExpression, *CallExpression, *IndexExpression, *MemberExpression:
+		return precPostfix
+	}
+	return precPrimary
+}
+
+// exprString returns the source of e, parenthesized when its operator binds looser than prec
+func exprString(e Expression, prec int) string {
+	s := exprOperand(e)
+	if exprPrecedence(e) < prec {
+		return "(" + s + ")"
+	}
+	return s
+}
+
+func exprOperand(e Expression) string {
+	switch e := e.(type) {
+	case *Identifier:
+		return e.Value
+	case *IntegerLiteral:
+		return e.Value
+	case *CommaExpression:
+		return exprString(e.Left, precComma) + ", " + exprString(e.Right, precComma+1)
+	case *AssignExpression:
+		// Assignments group to the right
+		return exprString(e.Left, precAssign+1) + " " + e.Operator + " " + exprString(e.Right, precAssign)
+	case *InfixExpression:
+		prec := exprPrecedence(e)
+		return exprString(e.Left, prec) + " " + e.Operator + " " + exprString(e.Right, prec+1)
+	case *PrefixExpression:
+		operand := exprString(e.Expression, precUnary)
+		// "- -x" is not "--x" and "& &x" is not "&&x"
+		last := e.Operator[len(e.Operator)-1:]
+		if strings.ContainsAny(last, "+-&") && strings.HasPrefix(operand, last) {
+			return e.Operator + " " + operand
+		}
+		return e.Operator + operand
+	case *PostfixExpression:
+		return exprString(e.Expression, precPostfix) + e.Operator
+	case *CastExpression:
+		return "(" + e.Type.String() + ")" + exprString(e.Expression, precUnary)
+	case *SizeofExpression:
+		if e.Expression == nil {
+			return "sizeof(" + e.Type.String() + ")"
+		}
+		if exprPrecedence(e.Expression) < precPostfix {
+			return "sizeof(" + ExprString(e.Expression) + ")"
+		}
+		return "sizeof " + exprString(e.Expression, precPostfix)
+	case *BuiltinExpression:
+		args := exprList(e.Arguments)
+		if e.Type != nil {
+			args = append(args, e.Type.String())
+		}
+		return e.Name + "(" + strings.Join(args, ", ") + ")"
+	case *CallExpression:
+		return exprString(e.Function, precPostfix) + "(" + strings.Join(exprList(e.Arguments), ", ") + ")"
+	case *IndexExpression:
+		return exprString(e.Left, precPostfix) + "[" + ExprString(e.Index) + "]"
+	case *MemberExpression:
+		return exprString(e.Left, precPostfix) + e.Operator + e.Member
+	case *InitializerList:
+		if len(e.Elements) == 0 {
+			return "{}"
+		}
+		elements := make([]string, len(e.Elements))
+		for i, el := range e.Elements {
+			var b strings.Builder
+			for _, d := range el.Designators {
+				if d.Index != nil {
+					b.WriteString("[" + exprString(d.Index, precLogicalOr) + "]")
+				} else {
+					b.WriteString("." + d.Field)
+				}
+			}
+			if b.Len() != 0 {
+				b.WriteString(" = ")
+			}
+			b.WriteString(exprString(el.Value, precAssign))
+			elements[i] = b.String()
+		}
+		return "{ " + strings.Join(elements, ", ") + " }"
+	}
+	panic(fmt.Sprintf("ast: unexpected expression %T", e))
+}
+
+// exprList returns the arguments of a call, a comma expression among them is parenthesized
+func exprList(args []Expression) []string {
+	list := make([]string, len(args))
+	for i, arg := range args {
+		list[i] = exprString(arg, precAssign)
+	}
+	return list
+}
@@ -1,6 +1,9 @@
 package ast
 
-import "compiler/lexer"
+import (
+	"compiler/lexer"
+	"compiler/types"
+)
 
 // Attrib represent any node of the tree
 type Attrib interface{}
@@ -22,10 +25,11 @@ type Statement interface {
 	statementNode()
 }
 
-// Expression is an expression node in the tree
+// Expression is an expression node in the tree, String returns its source as written by ExprString
 type Expression interface {
 	Node
 	expressionNode()
+	String() string
 }
 
 type Identifier struct {
@@ -33,52 +37,110 @@ type Identifier struct {
 	Value string       `json:"value"`
 }
 
+// DeclStatement declares a single name, Storage holds the storage class specifier if any
 type DeclStatement struct {
+	Token   *lexer.Token `json:"-"`
+	Left    Identifier   `json:"left"`
+	Right   Expression   `json:"right"`
+	Type    types.Type   `json:"type,required"`
+	Storage string       `json:"storage,omitempty"`
+}
+
+// DeclGroup holds every declarator of a single declaration
+// such as `int a, b = 2;`, an enum defined by the declaration comes first
+type DeclGroup struct {
 	Token *lexer.Token `json:"-"`
-	Left  Identifier   `json:"left"`
-	Right Expression   `json:"right"`
-	Type  string       `json:"type"`
+	Decls []Statement  `json:"decls"`
 }
 
+// TypedefStatement declares Name as an alias for Type
+type TypedefStatement struct {
+	Token *lexer.Token `json:"-"`
+	Name  string       `json:"name"`
+	Type  types.Type   `json:"type,required"`
+}
+
+// EnumStatement declares an enum type and its enumerators
+// Name is empty for anonymous enums, Rbrace is the "}" ending the enumerators
+type EnumStatement struct {
+	Token       *lexer.Token `json:"-"`
+	Name        string       `json:"name"`
+	Enumerators []Enumerator `json:"enumerators"`
+	Rbrace      *lexer.Token `json:"rbrace,omitempty"`
+}
+
+// Enumerator is a single constant of an enum, Value is nil when not provided
+// Token is the name of the enumerator
+type Enumerator struct {
+	Token *lexer.Token `json:"pos,omitempty"`
+	Name  string       `json:"name"`
+	Value Expression   `json:"value"`
+}
+
+// StructStatement defines a struct type and its members
+// Name is empty for anonymous structs, Rbrace is the "}" ending the members
+type StructStatement struct {
+	Token  *lexer.Token  `json:"-"`
+	Name   string        `json:"name"`
+	Fields []StructField `json:"fields"`
+	Rbrace *lexer.Token  `json:"rbrace,omitempty"`
+}
+
+// StructField is a single member of a struct, Token is its name
+type StructField struct {
+	Token *lexer.Token `json:"pos,omitempty"`
+	Name  string       `json:"name"`
+	Type  types.Type   `json:"type"`
+}
+
+// AssignExpression stores Right into Left, which can be any expression designating an object
 type AssignExpression struct {
 	Token    *lexer.Token `json:"-"`
 	Operator string       `json:"operator"`
-	Left     Identifier   `json:"left"`
-	Right    Expression   `json:"right"`
+	Left     Expression   `json:"left,required"`
+	Right    Expression   `json:"right,required"`
 }
 
 type ExpStatement struct {
 	Token      *lexer.Token `json:"-"`
-	Expression Expression   `json:"expression"`
+	Expression Expression   `json:"expression,required"`
 }
 
+// FunctionStatement declares a function and defines it when Body is set
+// Unprototyped is set when the parameters are written "()", which leaves them unspecified
 type FunctionStatement struct {
-	Token      *lexer.Token    `json:"-"`
-	Name       string          `json:"name"`
-	Parameters []FormalArg     `json:"params"`
-	Body       *BlockStatement `json:"body"`
-	Return     string          `json:"return"`
+	Token        *lexer.Token    `json:"-"`
+	Name         string          `json:"name"`
+	Parameters   []FormalArg     `json:"params"`
+	Variadic     bool            `json:"variadic,omitempty"`
+	Unprototyped bool            `json:"unprototyped,omitempty"`
+	Body         *BlockStatement `json:"body"`
+	Return       types.Type      `json:"return,required"`
+	Storage      string          `json:"storage,omitempty"`
 }
 
 type FormalArg struct {
-	Arg  string `json:"arg"`
-	Type string `json:"type"`
+	Arg  string     `json:"arg"`
+	Type types.Type `json:"type,required"`
 }
 
 type ReturnStatement struct {
 	Token       *lexer.Token `json:"-"`
-	ReturnValue Expression   `json:"return"`
+	ReturnValue Expression   `json:"return,required"`
 }
 
+// BlockStatement is a list of statements between braces, Rbrace is the closing one
 type BlockStatement struct {
 	Token      *lexer.Token `json:"-"`
 	Statements []Statement  `json:"statements"`
+	Rbrace     *lexer.Token `json:"rbrace,omitempty"`
 }
 
 type IfStatement struct {
-	Condition Expression `json:"condition"`
-	Body      Statement  `json:"statement"`
-	ElseBody  Statement  `json:"else"`
+	Token     *lexer.Token `json:"-"`
+	Condition Expression   `json:"condition,required"`
+	Body      Statement    `json:"statement,required"`
+	ElseBody  Statement    `json:"else"`
 }
 
 type IntegerLiteral struct {
@@ -89,12 +151,110 @@ type IntegerLiteral struct {
 type PrefixExpression struct {
 	Token      *lexer.Token `json:"-"`
 	Operator   string       `json:"operator"`
-	Expression Expression   `json:"expression"`
+	Expression Expression   `json:"expression,required"`
+}
+
+// BuiltinExpression is a call to a builtin of the compiler such as va_arg
+// Type is only set for the builtins taking a type name
+type BuiltinExpression struct {
+	Token     *lexer.Token `json:"-"`
+	Name      string       `json:"name"`
+	Arguments []Expression `json:"arguments"`
+	Type      types.Type   `json:"type,omitempty"`
+}
+
+// PostfixExpression is an increment or decrement giving the value from before the operation
+type PostfixExpression struct {
+	Token      *lexer.Token `json:"-"`
+	Operator   string       `json:"operator"`
+	Expression Expression   `json:"expression,required"`
 }
 
 type InfixExpression struct {
 	Token    *lexer.Token `json:"-"`
 	Operator string       `json:"operator"`
-	Left     Expression   `json:"left"`
-	Right    Expression   `json:"right"`
+	Left     Expression   `json:"left,required"`
+	Right    Expression   `json:"right,required"`
+}
+
+// CommaExpression evaluates Left, discards its value then evaluates Right
+type CommaExpression struct {
+	Token *lexer.Token `json:"-"`
+	Left  Expression   `json:"left,required"`
+	Right Expression   `json:"right,required"`
+}
+
+// CastExpression converts the value of Expression to Type
+type CastExpression struct {
+	Token      *lexer.Token `json:"-"`
+	Type       types.Type   `json:"type,required"`
+	Expression Expression   `json:"expression,required"`
+}
+
+// SizeofExpression holds either a type name or an expression, never both
+type SizeofExpression struct {
+	Token      *lexer.Token `json:"-"`
+	Type       types.Type   `json:"type,omitempty"`
+	Expression Expression   `json:"expression,omitempty"`
+}
+
+// CallExpression calls Function, either a function name or a function pointer
+type CallExpression struct {
+	Token     *lexer.Token `json:"-"`
+	Function  Expression   `json:"function,required"`
+	Arguments []Expression `json:"arguments"`
+}
+
+// IndexExpression reads the element Index of an array or pointer
+type IndexExpression struct {
+	Token *lexer.Token `json:"-"`
+	Left  Expression   `json:"left,required"`
+	Index Expression   `json:"index,required"`
+}
+
+// MemberExpression reads a member of a struct, Operator is "->" when Left is a pointer to the struct
+type MemberExpression struct {
+	Token    *lexer.Token `json:"-"`
+	Operator string       `json:"operator"`
+	Left     Expression   `json:"left,required"`
+	Member   string       `json:"member"`
+}
+
+// InitializerList is a brace enclosed initializer such as `{ 1, [3] = 7, .name = { 2 } }`
+// It only appears as the initializer of a declaration or nested in another InitializerList
+type InitializerList struct {
+	Token    *lexer.Token  `json:"-"`
+	Elements []Initializer `json:"elements"`
+}
+
+// Initializer is an element of an InitializerList, Value is either an expression or an InitializerList
+// Without Designators it initializes the member following the one initialized by the previous element
+type Initializer struct {
+	Designators []Designator `json:"designators,omitempty"`
+	Value       Expression   `json:"value,required"`
+}
+
+// Designator selects either the member Field of a struct or the element Index of an array
+type Designator struct {
+	Field string     `json:"field,omitempty"`
+	Index Expression `json:"index,omitempty"`
+}
+
+// AsmStatement is an inline assembly statement, Template is the assembly with its escape sequences decoded
+// Basic asm has no operand list, its template is emitted as written
+// Extended asm refers to its Outputs then its Inputs as %0, %1...
+type AsmStatement struct {
+	Token    *lexer.Token `json:"-"`
+	Volatile bool         `json:"volatile,omitempty"`
+	Extended bool         `json:"extended,omitempty"`
+	Template string       `json:"template"`
+	Outputs  []AsmOperand `json:"outputs,omitempty"`
+	Inputs   []AsmOperand `json:"inputs,omitempty"`
+	Clobbers []string     `json:"clobbers,omitempty"`
+}
+
+// AsmOperand binds Expression to the template of an AsmStatement as allowed by Constraint, such as "=r" or "m"
+type AsmOperand struct {
+	Constraint string     `json:"constraint"`
+	Expression Expression `json:"expression,required"`
 }
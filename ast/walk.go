@@ -0,0 +1,184 @@
+package ast
+
+import (
+	"compiler/types"
+	"fmt"
+)
+
+// TokenLiteral makes the Program the root Node of the tree
+func (p *Program) TokenLiteral() string { return "Program" }
+
+// Visitor is called by Walk for every node of the tree
+// Walk visits the children of a node with the visitor w returned by Visit(node) unless w is nil,
+// then calls w.Visit(nil)
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+// Walk traverses the tree rooted at node in depth-first order, calling v.Visit(node) first
+// Nil children are skipped, the identifiers declared by a DeclStatement are visited as *Identifier
+// and the expressions held by enumerators, initializers and asm operands are visited in the order they are written
+// The lengths of the arrays of the types held by a node are visited as expressions, see walkType
+func Walk(v Visitor, node Node) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+	switch n := node.(type) {
+	case *Program:
+		walkStatements(v, n.Statements)
+		walkStatements(v, n.Functions)
+	case *DeclStatement:
+		Walk(v, &n.Left)
+		walkType(v, n.Type)
+		walkExpression(v, n.Right)
+	case *DeclGroup:
+		walkStatements(v, n.Decls)
+	case *TypedefStatement:
+		walkType(v, n.Type)
+	case *EnumStatement:
+		for _, e := range n.Enumerators {
+			walkExpression(v, e.Value)
+		}
+	case *StructStatement:
+		for _, f := range n.Fields {
+			walkType(v, f.Type)
+		}
+	case *ExpStatement:
+		walkExpression(v, n.Expression)
+	case *FunctionStatement:
+		walkType(v, n.Return)
+		for _, arg := range n.Parameters {
+			walkType(v, arg.Type)
+		}
+		if n.Body != nil {
+			Walk(v, n.Body)
+		}
+	case *ReturnStatement:
+		walkExpression(v, n.ReturnValue)
+	case *BlockStatement:
+		walkStatements(v, n.Statements)
+	case *IfStatement:
+		walkExpression(v, n.Condition)
+		walkStatement(v, n.Body)
+		walkStatement(v, n.ElseBody)
+	case *AsmStatement:
+		for _, o := range n.Outputs {
+			walkExpression(v, o.Expression)
+		}
+		for _, o := range n.Inputs {
+			walkExpression(v, o.Expression)
+		}
+	case *Identifier, *IntegerLiteral:
+	case *AssignExpression:
+		walkExpression(v, n.Left)
+		walkExpression(v, n.Right)
+	case *PrefixExpression:
+		walkExpression(v, n.Expression)
+	case *PostfixExpression:
+		walkExpression(v, n.Expression)
+	case *InfixExpression:
+		walkExpression(v, n.Left)
+		walkExpression(v, n.Right)
+	case *CommaExpression:
+		walkExpression(v, n.Left)
+		walkExpression(v, n.Right)
+	case *CastExpression:
+		walkType(v, n.Type)
+		walkExpression(v, n.Expression)
+	case *SizeofExpression:
+		walkType(v, n.Type)
+		walkExpression(v, n.Expression)
+	case *BuiltinExpression:
+		walkExpressions(v, n.Arguments)
+		walkType(v, n.Type)
+	case *CallExpression:
+		walkExpression(v, n.Function)
+		walkExpressions(v, n.Arguments)
+	case *IndexExpression:
+		walkExpression(v, n.Left)
+		walkExpression(v, n.Index)
+	case *MemberExpression:
+		walkExpression(v, n.Left)
+	case *InitializerList:
+		for _, e := range n.Elements {
+			for _, d := range e.Designators {
+				walkExpression(v, d.Index)
+			}
+			walkExpression(v, e.Value)
+		}
+	default:
+		panic(fmt.Sprintf("ast.Walk: unexpected node type %T", n))
+	}
+	v.Visit(nil)
+}
+
+func walkStatement(v Visitor, s Statement) {
+	if s != nil {
+		Walk(v, s)
+	}
+}
+
+func walkStatements(v Visitor, list []Statement) {
+	for _, s := range list {
+		walkStatement(v, s)
+	}
+}
+
+func walkExpression(v Visitor, e Expression) {
+	if e != nil {
+		Walk(v, e)
+	}
+}
+
+// walkType visits the lengths of the arrays of a type written as expressions, in the order of types.Lengths
+func walkType(v Visitor, t types.Type) {
+	for _, a := range types.Lengths(t) {
+		if e, ok := a.LenExpr.(Expression); ok {
+			Walk(v, e)
+		}
+	}
+}
+
+func walkExpressions(v Visitor, list []Expression) {
+	for _, e := range list {
+		walkExpression(v, e)
+	}
+}
+
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect traverses the tree rooted at node in depth-first order, calling f(node) before the children of node
+// The children are skipped when f returns false, f(nil) is called after the children like with Walk
+func Inspect(node Node, f func(Node) bool) {
+	Walk(inspector(f), node)
+}
+
+// postorder calls f on a node once all of its children were visited, the stack holds the nodes being visited
+type postorder struct {
+	f     func(Node)
+	stack []Node
+}
+
+func (p *postorder) Visit(node Node) Visitor {
+	if node != nil {
+		p.stack = append(p.stack, node)
+		return p
+	}
+	last := len(p.stack) - 1
+	p.f(p.stack[last])
+	p.stack = p.stack[:last]
+	return nil
+}
+
+// InspectPost traverses the tree rooted at node in depth-first order, calling f(node) after the children of node
+// such as for analyses computing the value of a node from the ones of its children
+func InspectPost(node Node, f func(Node)) {
+	Walk(&postorder{f: f}, node)
+}
@@ -0,0 +1,64 @@
+package ast_test
+
+import (
+	"compiler/ast"
+	"compiler/lexer"
+	"compiler/parser"
+	"compiler/source"
+	"strings"
+	"testing"
+)
+
+// lengthsSource uses N in array lengths only, in every kind of node holding a type
+const lengthsSource = `enum { N = 2 };
+typedef int row[N];
+struct S { char name[N]; };
+int a[N + 1];
+int f(int p[N]) { return sizeof(int [N]) + (int)(char (*)[N])0 + va_arg(p, int [N]); }
+`
+
+func parseString(t *testing.T, src string) *ast.Program {
+	t.Helper()
+	l := lexer.NewFileLexer(strings.NewReader(src), source.NewFileSet().AddFile("lengths.c", -1))
+	program, err := parser.NewParser(l).ParseProgram()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return program
+}
+
+// countN returns the number of identifiers N found by Inspect
+func countN(node ast.Node) int {
+	count := 0
+	ast.Inspect(node, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Identifier); ok && id.Value == "N" {
+			count++
+		}
+		return true
+	})
+	return count
+}
+
+// TestWalkArrayLengths checks that the lengths of the arrays written in types are visited, rewritten and copied
+func TestWalkArrayLengths(t *testing.T) {
+	program := parseString(t, lengthsSource)
+	if n := countN(program); n != 7 {
+		t.Fatalf("Expected 7 uses of N, Inspect found %d", n)
+	}
+	clone := ast.Clone(program)
+	ast.Apply(clone, nil, func(c *ast.Cursor) bool {
+		if id, ok := c.Node().(*ast.Identifier); ok && id.Value == "N" {
+			c.Replace(&ast.Identifier{Value: "M"})
+		}
+		return true
+	})
+	if n := countN(clone); n != 0 {
+		t.Errorf("Expected Apply to replace every N, %d left", n)
+	}
+	if n := countN(program); n != 7 {
+		t.Errorf("Rewriting the clone changed the original, %d uses of N left", n)
+	}
+	if !strings.Contains(clone.(*ast.Program).Statements[1].(*ast.TypedefStatement).Type.String(), "[M]") {
+		t.Errorf("Expected the length of the typedef to be M")
+	}
+}
@@ -2,39 +2,45 @@ package buffer
 
 import (
 	"io"
-	"io/ioutil"
+	"unicode/utf8"
 )
 
 var nullBuffer = []byte{0}
 
+// minReadSize is the initial size of the window of a streaming Lexer and the least it reads at once
+const minReadSize = 4096
+
 // Lexer holds the input buffer
+// A Lexer reading from an io.Reader only keeps a window of the input, starting at the current
+// token, and reads more on demand when Peek goes past the end of the window
 type Lexer struct {
 	buf     []byte
 	pos     int
 	start   int
 	err     error
 	restore func()
+	// r is the reader the window is filled from, it is nil once the whole input was read
+	r io.Reader
+	// offset is the position in the input of the first byte of buf
+	offset int
 }
 
+// NewLexer creates a Lexer reading from r
+// The whole input is used at once when r exposes a Bytes method, it is streamed otherwise
 func NewLexer(r io.Reader) *Lexer {
-	var b []byte
-	if r != nil {
-		if buffer, ok := r.(interface {
-			Bytes() []byte
-		}); ok {
-			b = buffer.Bytes()
-		} else {
-			var err error
-			b, err = ioutil.ReadAll(r)
-			if err != nil {
-				return &Lexer{
-					buf: []byte{0},
-					err: err,
-				}
-			}
-		}
+	if r == nil {
+		return NewLexerBytes(nil)
+	}
+	if buffer, ok := r.(interface {
+		Bytes() []byte
+	}); ok {
+		return NewLexerBytes(buffer.Bytes())
+	}
+	buf := make([]byte, 1, minReadSize+1)
+	return &Lexer{
+		buf: buf,
+		r:   r,
 	}
-	return NewLexerBytes(b)
 }
 
 func NewLexerBytes(b []byte) *Lexer {
@@ -61,6 +67,36 @@ func NewLexerBytes(b []byte) *Lexer {
 	return z
 }
 
+// read fills the window until it holds the byte at pos from the cursor or the input is exhausted
+// The bytes before the current token are dropped first, the window only grows when the token
+// does not leave room for another read
+func (z *Lexer) read(pos int) {
+	for z.r != nil && z.pos+pos >= len(z.buf)-1 {
+		data := z.buf[:len(z.buf)-1]
+		if z.start > 0 {
+			data = data[:copy(data, data[z.start:])]
+			z.offset += z.start
+			z.pos -= z.start
+			z.start = 0
+		}
+		if cap(z.buf)-len(data) < minReadSize+1 {
+			grown := make([]byte, len(data), 2*cap(z.buf)+minReadSize)
+			copy(grown, data)
+			data = grown
+		}
+		n, err := z.r.Read(data[len(data) : cap(data)-1])
+		data = data[:len(data)+n]
+		if err != nil {
+			if err != io.EOF {
+				z.err = err
+			}
+			z.r = nil
+		}
+		// Keep the NUL sentinel after the data
+		z.buf = append(data, 0)
+	}
+}
+
 // Err returns the error returned from io.Reader or io.EOF when the end has been reached.
 func (z *Lexer) Err() error {
 	return z.PeekErr(0)
@@ -74,6 +110,7 @@ func (z *Lexer) Restore() {
 }
 
 func (z *Lexer) PeekErr(pos int) error {
+	z.read(pos)
 	if z.err != nil {
 		return z.err
 	} else if z.pos+pos >= len(z.buf)-1 {
@@ -83,20 +120,25 @@ func (z *Lexer) PeekErr(pos int) error {
 }
 
 func (z *Lexer) Peek(pos int) byte {
+	z.read(pos)
 	pos += z.pos
 	return z.buf[pos]
 }
 
+// PeekRune decodes the UTF-8 encoded rune at pos and returns it with its length in bytes
+// An invalid encoding, including a sequence cut by the end of the input, gives utf8.RuneError with a length of 1
 func (z *Lexer) PeekRune(pos int) (rune, int) {
 	c := z.Peek(pos)
-	if c < 0xC0 || z.Peek(pos+1) == 0 {
+	if c < utf8.RuneSelf {
 		return rune(c), 1
-	} else if c < 0xE0 || z.Peek(pos+2) == 0 {
-		return rune(c&0xF0)<<6 | rune(z.Peek(pos+1)&0x3F), 2
-	} else if c < 0xF0 || z.Peek(pos+3) == 0 {
-		return rune(c&0xF0)<<12 | rune(z.Peek(pos+1)&0x3F)<<6 | rune(z.Peek(pos+2)&0x3F), 3
 	}
-	return rune(c&0x07)<<18 | rune(z.Peek(pos+1)&0x3F)<<12 | rune(z.Peek(pos+2)&0x3F)<<6 | rune(z.Peek(pos+3)&0x3F), 4
+	var b [utf8.UTFMax]byte
+	n := 0
+	// Stop at a NUL, it is either the end of the input or an invalid continuation byte
+	for ; n < utf8.UTFMax && (n == 0 || z.Peek(pos+n) != 0); n++ {
+		b[n] = z.Peek(pos + n)
+	}
+	return utf8.DecodeRune(b[:n])
 }
 
 func (z *Lexer) Move(n int) {
@@ -111,6 +153,8 @@ func (z *Lexer) Rewind(pos int) {
 	z.pos = z.start + pos
 }
 
+// Lexeme returns the bytes of the current token
+// When streaming they are only valid until the next Peek
 func (z *Lexer) Lexeme() []byte {
 	return z.buf[z.start:z.pos]
 }
@@ -119,16 +163,23 @@ func (z *Lexer) Skip() {
 	z.start = z.pos
 }
 
+// Shift returns the bytes of the current token and starts the next one
+// The bytes are copied while the window can still be overwritten by a read
 func (z *Lexer) Shift() []byte {
 	b := z.buf[z.start:z.pos]
+	if z.r != nil {
+		b = append([]byte{}, b...)
+	}
 	z.start = z.pos
 	return b
 }
 
+// Offset returns the position of the cursor from the start of the input
 func (z *Lexer) Offset() int {
-	return z.pos
+	return z.offset + z.pos
 }
 
+// Bytes returns the input, only the current window when streaming
 func (z *Lexer) Bytes() []byte {
 	return z.buf[:len(z.buf)-1]
 }
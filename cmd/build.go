@@ -1,19 +1,22 @@
 package cmd
 
 import (
+	"fmt"
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 
+	"compiler/ast"
 	"compiler/generator"
-	"compiler/lexer"
-	"compiler/parser"
+	"compiler/source"
 
 	"github.com/spf13/cobra"
 )
 
 var (
 	output   string
+	fromAST  bool
 	buildCmd = &cobra.Command{
 		Use:   "build",
 		Short: "Sample compiler written in go",
@@ -25,20 +28,29 @@ var (
 			src := args[0]
 			if output == "" {
 				output = filepath.Base(src)
+				if fromAST {
+					// Do not write over the AST being read
+					output = strings.TrimSuffix(output, filepath.Ext(output)) + ".s"
+				}
 			}
-			absSrc, err := ResolvePath(src)
-			checkErr(err)
 			absOutput, err := ResolvePath(output)
 			checkErr(err)
-			srcFile, err := os.Open(absSrc)
-			checkErr(err)
-			defer srcFile.Close()
-			l := lexer.NewLexer(srcFile)
-			p := parser.NewParser(l)
-			program, err := p.ParseProgram()
-			checkErr(err)
+			var program *ast.Program
+			if fromAST {
+				program, err = ReadAST(source.NewFileSet(), src)
+				checkErr(err)
+			} else {
+				l, srcFile, err := OpenSource(source.NewFileSet(), src)
+				checkErr(err)
+				defer srcFile.Close()
+				program, err = Parse(l, parserName)
+				checkErr(err)
+			}
 			gen := generator.NewAssemblyGenerator()
 			s, err := gen.FromProgram(program)
+			for _, w := range gen.Warnings {
+				fmt.Fprintf(os.Stderr, "%s: warning: %s\n", src, w)
+			}
 			checkErr(err)
 			log.Println(s)
 			outFile, err := os.Create(absOutput)
@@ -50,6 +62,8 @@ var (
 )
 
 func init() {
+	buildCmd.Flags().StringVar(&parserName, "parser", "rd", "Parser to use, rd for the recursive descent parser or lr for the generated LALR parser")
+	buildCmd.Flags().BoolVar(&fromAST, "from-ast", false, "Read the program from the JSON AST written by print-ast instead of a source file")
 	buildCmd.Flags().StringVarP(&output, "output", "o", "", "Output")
 	rootCmd.AddCommand(buildCmd)
 }
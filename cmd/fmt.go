@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+
+	"compiler/lexer"
+	"compiler/printer"
+	"compiler/source"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	fmtWrite bool
+	fmtDiff  bool
+	fmtCmd   = &cobra.Command{
+		Use:   "fmt [-w] [-d] files...",
+		Short: "Formats C sources",
+		Long:  "Prints C sources in the canonical layout of the compiler, like gofmt. The standard input is read when no file is given",
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) == 0 {
+				args = []string{"-"}
+			}
+			for _, src := range args {
+				checkErr(formatFile(src))
+			}
+		},
+	}
+)
+
+func init() {
+	fmtCmd.Flags().StringVar(&parserName, "parser", "rd", "Parser to use, rd for the recursive descent parser or lr for the generated LALR parser")
+	fmtCmd.Flags().BoolVarP(&fmtWrite, "write", "w", false, "Write the result to the source file instead of the standard output")
+	fmtCmd.Flags().BoolVarP(&fmtDiff, "diff", "d", false, "Print the changes as a diff instead of the result")
+	rootCmd.AddCommand(fmtCmd)
+}
+
+// formatFile formats a source file, or the standard input for "-", as asked by the flags of fmt
+func formatFile(src string) error {
+	name, path := src, src
+	var data []byte
+	var err error
+	if src == "-" {
+		name = "<stdin>"
+		data, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		if path, err = ResolvePath(src); err != nil {
+			return err
+		}
+		data, err = ioutil.ReadFile(path)
+	}
+	if err != nil {
+		return err
+	}
+	fset := source.NewFileSet()
+	l := lexer.NewFileLexer(bytes.NewReader(data), fset.AddFile(name, -1))
+	program, comments, err := ParseWithComments(l, parserName)
+	if err != nil {
+		return err
+	}
+	res := printer.Source(fset, program, comments)
+	if !fmtWrite && !fmtDiff {
+		_, err = os.Stdout.Write(res)
+		return err
+	}
+	if bytes.Equal(data, res) {
+		return nil
+	}
+	if fmtDiff {
+		if err := diff(name, data, res); err != nil {
+			return err
+		}
+	}
+	if fmtWrite && src != "-" {
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(path, res, info.Mode().Perm())
+	}
+	return nil
+}
+
+// diff prints the changes from a to b as a unified diff with the diff command
+func diff(name string, a, b []byte) error {
+	files := make([]string, 2)
+	for i, content := range [][]byte{a, b} {
+		f, err := ioutil.TempFile("", "compiler-fmt")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(f.Name())
+		if _, err := f.Write(content); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+		files[i] = f.Name()
+	}
+	out, err := exec.Command("diff", "-u", "--label", name+".orig", "--label", name, files[0], files[1]).Output()
+	// diff exits with 1 when the files differ
+	if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+		err = nil
+	}
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(out)
+	return err
+}
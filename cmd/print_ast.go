@@ -1,17 +1,20 @@
 package cmd
 
 import (
+	"bytes"
 	"encoding/json"
-	"log"
+	"fmt"
 	"os"
 
-	"compiler/lexer"
-	"compiler/parser"
+	"compiler/ast"
+	"compiler/cst"
+	"compiler/source"
 
 	"github.com/spf13/cobra"
 )
 
 var (
+	printFormat string
 	printASTCmd = &cobra.Command{
 		Use:   "print-ast",
 		Short: "Sample compiler written in go",
@@ -21,22 +24,45 @@ var (
 				return
 			}
 			src := args[0]
-			absSrc, err := ResolvePath(src)
-			checkErr(err)
-			srcFile, err := os.Open(absSrc)
+			switch printFormat {
+			case "json", "sexpr", "tree", "dot", "cst":
+			default:
+				checkErr(fmt.Errorf("Unknown format '%s', expected json, sexpr, tree, dot or cst", printFormat))
+			}
+			fset := source.NewFileSet()
+			l, srcFile, err := OpenSource(fset, src)
 			checkErr(err)
 			defer srcFile.Close()
-			l := lexer.NewLexer(srcFile)
-			p := parser.NewParser(l)
-			program, err := p.ParseProgram()
-			checkErr(err)
-			j, err := json.MarshalIndent(program, "", "  ")
+			if printFormat == "cst" {
+				root, err := ParseCST(l, parserName)
+				checkErr(err)
+				checkErr(cst.FprintTree(os.Stdout, root))
+				return
+			}
+			program, err := Parse(l, parserName)
 			checkErr(err)
-			log.Println(string(j))
+			switch printFormat {
+			case "json":
+				j, err := ast.MarshalJSON(fset, program)
+				checkErr(err)
+				var out bytes.Buffer
+				checkErr(json.Indent(&out, j, "", "  "))
+				out.WriteByte('\n')
+				_, err = out.WriteTo(os.Stdout)
+				checkErr(err)
+			case "sexpr":
+				checkErr(ast.FprintSExpr(os.Stdout, program))
+			case "tree":
+				checkErr(ast.FprintTree(os.Stdout, fset, program))
+			case "dot":
+				checkErr(ast.FprintDot(os.Stdout, program))
+			}
 		},
 	}
 )
 
 func init() {
+	printASTCmd.Flags().StringVar(&parserName, "parser", "rd", "Parser to use, rd for the recursive descent parser or lr for the generated LALR parser")
+	printASTCmd.Flags().StringVar(&printFormat, "format", "json", "Format of the AST, json, sexpr for S-expressions, tree for an indented tree with positions, dot for a Graphviz digraph or cst for the tokens of every node with their whitespace and comments")
 	rootCmd.AddCommand(printASTCmd)
 }
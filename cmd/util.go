@@ -1,10 +1,22 @@
 package cmd
 
 import (
+	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+
+	"compiler/ast"
+	"compiler/cst"
+	"compiler/lexer"
+	"compiler/parser"
+	"compiler/source"
 )
 
+// parserName selects the parser of the commands reading a source, see Parse
+var parserName string
+
 // ResolvePath returns the absolute path for a provided relative or avsolute path
 // If relative will resolve from the current working directory
 // All paths will be cleaned
@@ -28,3 +40,89 @@ func checkErr(err error) {
 		panic(err)
 	}
 }
+
+// OpenSource opens a source file, or the standard input for "-", and creates a lexer for it
+// The file is added to fset so the positions of the tokens can be decoded
+func OpenSource(fset *source.FileSet, src string) (*lexer.Lexer, io.Closer, error) {
+	if src == "-" {
+		return lexer.NewFileLexer(os.Stdin, fset.AddFile("<stdin>", -1)), ioutil.NopCloser(nil), nil
+	}
+	absSrc, err := ResolvePath(src)
+	if err != nil {
+		return nil, nil, err
+	}
+	srcFile, err := os.Open(absSrc)
+	if err != nil {
+		return nil, nil, err
+	}
+	return lexer.NewFileLexer(srcFile, fset.AddFile(src, -1)), srcFile, nil
+}
+
+// Parse reads the program of l with the parser called name, "rd" for the recursive descent parser
+// and "lr" for the LALR parser generated from parser/c.bnf
+func Parse(l *lexer.Lexer, name string) (*ast.Program, error) {
+	program, _, err := ParseWithComments(l, name)
+	return program, err
+}
+
+// ParseWithComments reads the program of l like Parse and returns its comments as well
+func ParseWithComments(l *lexer.Lexer, name string) (*ast.Program, []*lexer.Token, error) {
+	var p interface {
+		ParseProgram() (*ast.Program, error)
+		Comments() []*lexer.Token
+	}
+	switch name {
+	case "rd":
+		p = parser.NewParser(l)
+	case "lr":
+		p = parser.NewLRParser(l)
+	default:
+		return nil, nil, fmt.Errorf("Unknown parser '%s', expected rd or lr", name)
+	}
+	program, err := p.ParseProgram()
+	return program, p.Comments(), err
+}
+
+// ParseCST reads the program of l like Parse and returns its concrete syntax tree, which holds every token
+// of the source with its whitespace and comments
+func ParseCST(l *lexer.Lexer, name string) (*cst.Node, error) {
+	var p interface {
+		ParseProgram() (*ast.Program, error)
+		KeepTokens()
+		Tokens() []*lexer.Token
+	}
+	switch name {
+	case "rd":
+		p = parser.NewParser(l)
+	case "lr":
+		p = parser.NewLRParser(l)
+	default:
+		return nil, fmt.Errorf("Unknown parser '%s', expected rd or lr", name)
+	}
+	p.KeepTokens()
+	program, err := p.ParseProgram()
+	if err != nil {
+		return nil, err
+	}
+	return cst.Build(program, p.Tokens())
+}
+
+// ReadAST reads a program from the JSON form written by print-ast, in a file or the standard input for "-"
+// The source files of the program are added to fset
+func ReadAST(fset *source.FileSet, src string) (*ast.Program, error) {
+	var data []byte
+	var err error
+	if src == "-" {
+		data, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		var absSrc string
+		if absSrc, err = ResolvePath(src); err != nil {
+			return nil, err
+		}
+		data, err = ioutil.ReadFile(absSrc)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ast.UnmarshalJSON(fset, data)
+}
@@ -0,0 +1,386 @@
+package cst
+
+import (
+	"compiler/ast"
+	"compiler/lexer"
+	"compiler/source"
+	"compiler/types"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// span is the range of the significant tokens of a node, from first to last included
+type span struct {
+	first, last int
+}
+
+func (s *span) include(o span) {
+	if o.first < s.first {
+		s.first = o.first
+	}
+	if o.last > s.last {
+		s.last = o.last
+	}
+}
+
+// builder finds the tokens of every node, sig holds the tokens that are not trivia and index
+// gives the place of a token among them from its position
+type builder struct {
+	sig   []*Token
+	index map[source.Pos]int
+	// match gives the index of the bracket closing or opening the one at an index, -1 for other tokens
+	match []int
+	spans map[ast.Node]span
+	// children holds the children of the nodes in the order they are written
+	children map[ast.Node][]ast.Node
+	err      error
+}
+
+// Build attaches the tokens read by a parser in KeepTokens mode to the nodes of the program it returned
+// A node gets the tokens from its first to its last one that are not part of its children, such as
+// the operator of an expression or the ";" of a statement, and each token its trivia
+// Parentheses that are not part of the grammar of a node belong to the expression they enclose,
+// the specifiers of a declaration to the declaration following them in a block
+func Build(program *ast.Program, tokens []*lexer.Token) (*Node, error) {
+	b := &builder{
+		index:    map[source.Pos]int{},
+		spans:    map[ast.Node]span{},
+		children: map[ast.Node][]ast.Node{},
+	}
+	var leading []*Token
+	// line is the last token read while the line it ends is not over
+	var line *Token
+	for _, lt := range tokens {
+		t := &Token{Type: lt.Type, Pos: lt.Pos, Text: string(lt.Text())}
+		switch lt.Type {
+		case lexer.WhitespaceToken, lexer.CommentToken, lexer.ErrToken:
+			if line != nil {
+				line.Trailing = append(line.Trailing, t)
+			} else {
+				leading = append(leading, t)
+			}
+		case lexer.LineTerminatorToken:
+			line = nil
+			leading = append(leading, t)
+		default:
+			t.Leading, leading = leading, nil
+			b.index[t.Pos] = len(b.sig)
+			b.sig = append(b.sig, t)
+			line = t
+		}
+	}
+	if len(b.sig) == 0 || b.sig[len(b.sig)-1].Type != lexer.EOFToken {
+		return nil, errors.New("Expected the tokens up to the end of input")
+	}
+	if err := b.matchBrackets(); err != nil {
+		return nil, err
+	}
+	b.visit(program)
+	if b.err != nil {
+		return nil, b.err
+	}
+	root := span{first: 0, last: len(b.sig) - 1}
+	if err := b.check(program, root); err != nil {
+		return nil, err
+	}
+	return b.node(program, root), nil
+}
+
+// matchBrackets pairs the parentheses, brackets and braces of the source
+func (b *builder) matchBrackets() error {
+	b.match = make([]int, len(b.sig))
+	var stack []int
+	for i, t := range b.sig {
+		b.match[i] = -1
+		switch t.Text {
+		case "(", "[", "{":
+			stack = append(stack, i)
+		case ")", "]", "}":
+			if len(stack) == 0 {
+				return fmt.Errorf("Unbalanced '%s'", t.Text)
+			}
+			open := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			b.match[open], b.match[i] = i, open
+		}
+	}
+	if len(stack) != 0 {
+		return fmt.Errorf("Unbalanced '%s'", b.sig[stack[len(stack)-1]].Text)
+	}
+	return nil
+}
+
+// is returns whether or not the significant token at i is written val
+func (b *builder) is(i int, val string) bool {
+	return i >= 0 && i < len(b.sig) && b.sig[i].Text == val
+}
+
+// pos returns the index of a token among the significant tokens, -1 if it is not one of them
+func (b *builder) pos(t *lexer.Token) int {
+	if t == nil {
+		return -1
+	}
+	if i, ok := b.index[t.Pos]; ok {
+		return i
+	}
+	return -1
+}
+
+// visit returns the span of a node once the spans of its children are known
+func (b *builder) visit(node ast.Node) span {
+	s := span{first: len(b.sig), last: -1}
+	for _, i := range b.own(node) {
+		s.include(span{first: i, last: i})
+	}
+	var children []ast.Node
+	ast.Inspect(node, func(n ast.Node) bool {
+		// The array lengths of a struct shared by the declarators of a declaration belong to the first one
+		if _, done := b.spans[n]; n != nil && n != node && !done {
+			children = append(children, n)
+		}
+		return n == node
+	})
+	list := children[:0]
+	for _, c := range children {
+		cs := b.adjust(node, c, b.visit(c))
+		if cs.first > cs.last {
+			b.err = fmt.Errorf("No token for %T", c)
+			continue
+		}
+		b.spans[c] = cs
+		list = append(list, c)
+	}
+	children = list
+	sort.SliceStable(children, func(i, j int) bool {
+		return b.spans[children[i]].first < b.spans[children[j]].first
+	})
+	b.children[node] = children
+	// The tokens between the statements of a list, such as the specifiers of a declaration, start the next one
+	prev, isList := -1, false
+	switch n := node.(type) {
+	case *ast.Program:
+		isList = true
+	case *ast.BlockStatement:
+		prev, isList = b.pos(n.Token), true
+	}
+	for _, c := range children {
+		cs := b.spans[c]
+		if isList {
+			cs.first = prev + 1
+			b.spans[c] = cs
+		}
+		prev = cs.last
+		s.include(cs)
+	}
+	return b.extend(node, s)
+}
+
+// own returns the indexes of the tokens held by a node
+func (b *builder) own(node ast.Node) []int {
+	tokens := []*lexer.Token{}
+	switch n := node.(type) {
+	case *ast.BlockStatement:
+		tokens = append(tokens, n.Rbrace)
+	case *ast.EnumStatement:
+		tokens = append(tokens, n.Rbrace)
+		for _, e := range n.Enumerators {
+			tokens = append(tokens, e.Token)
+		}
+	case *ast.StructStatement:
+		tokens = append(tokens, n.Rbrace)
+		for _, f := range n.Fields {
+			tokens = append(tokens, f.Token)
+		}
+	}
+	var list []int
+	if i, ok := b.index[ast.Pos(node)]; ok {
+		list = append(list, i)
+	}
+	for _, t := range tokens {
+		if i := b.pos(t); i >= 0 {
+			list = append(list, i)
+		}
+	}
+	return list
+}
+
+// adjust returns the span of a child once the tokens it shares with its parent are given to it
+func (b *builder) adjust(parent, child ast.Node, s span) span {
+	if s.first > s.last {
+		return s
+	}
+	if _, ok := child.(ast.Expression); ok && !isDeclaredName(parent, child) {
+		for b.is(s.first-1, "(") && b.match[s.first-1] == s.last+1 && !b.isGrammarParen(parent, s.first-1) {
+			s.first, s.last = s.first-1, s.last+1
+		}
+	}
+	// The declarations of a group share the ";" ending it
+	if _, ok := child.(ast.Statement); ok && b.is(s.last+1, ";") {
+		if _, ok := parent.(*ast.DeclGroup); !ok {
+			s.last++
+		}
+	}
+	return s
+}
+
+// isDeclaredName returns whether or not child is the identifier declared by parent,
+// the parentheses around it belong to its declarator
+func isDeclaredName(parent, child ast.Node) bool {
+	d, ok := parent.(*ast.DeclStatement)
+	return ok && child == ast.Node(&d.Left)
+}
+
+// isGrammarParen returns whether or not the "(" at i is written by the grammar of parent
+// around one of its children, such as the condition of an if or the argument of a call
+func (b *builder) isGrammarParen(parent ast.Node, i int) bool {
+	switch n := parent.(type) {
+	case *ast.CallExpression:
+		return i == b.pos(n.Token)
+	case *ast.IfStatement:
+		return i == b.pos(n.Token)+1
+	case *ast.BuiltinExpression:
+		return i == b.pos(n.Token)+1
+	case *ast.AsmStatement:
+		// The operands of an asm are written after their constraint
+		return i > 0 && b.sig[i-1].Type == lexer.StringToken
+	}
+	return false
+}
+
+// extend returns the span of a node with the tokens ending it that are not held by the AST,
+// such as the ")" of a call
+func (b *builder) extend(node ast.Node, s span) span {
+	last := -1
+	switch n := node.(type) {
+	case *ast.DeclStatement:
+		return b.declarator(s, b.pos(n.Token))
+	case *ast.TypedefStatement:
+		return b.declarator(s, b.pos(n.Token))
+	case *ast.FunctionStatement:
+		return b.declarator(s, b.pos(n.Token))
+	case *ast.CallExpression:
+		last = b.closing(b.pos(n.Token))
+	case *ast.IndexExpression:
+		last = b.closing(b.pos(n.Token))
+	case *ast.InitializerList:
+		last = b.closing(b.pos(n.Token))
+	case *ast.MemberExpression:
+		last = b.pos(n.Token) + 1
+	case *ast.BuiltinExpression:
+		last = b.closing(b.pos(n.Token) + 1)
+	case *ast.SizeofExpression:
+		if n.Expression == nil {
+			last = b.closing(b.pos(n.Token) + 1)
+		}
+	case *ast.AsmStatement:
+		i := b.pos(n.Token) + 1
+		if !b.is(i, "(") {
+			// asm volatile (
+			i++
+		}
+		last = b.closing(i)
+	}
+	if last > s.last {
+		s.last = last
+	}
+	return s
+}
+
+// closing returns the index of the bracket closing the one at i, -1 if there is none
+func (b *builder) closing(i int) int {
+	if i < 0 || i >= len(b.match) {
+		return -1
+	}
+	return b.match[i]
+}
+
+// declarator returns the span of a declaration including the declarator of the name at i,
+// the pointers and parentheses before the name and the parentheses, parameters and array sizes after it
+func (b *builder) declarator(s span, i int) span {
+	if i < 0 {
+		return s
+	}
+	first, last, open := i, i, 0
+scan:
+	for j := i - 1; j >= 0; j-- {
+		switch {
+		case b.is(j, "("):
+			open++
+		case b.is(j, "*"):
+		case types.IsQualifier(b.sig[j].Text):
+			// Qualifiers after a "*" qualify the pointer, the ones before are specifiers
+			k := j
+			for k >= 0 && types.IsQualifier(b.sig[k].Text) {
+				k--
+			}
+			if !b.is(k, "*") {
+				break scan
+			}
+			j = k
+		default:
+			break scan
+		}
+		first = j
+	}
+	for {
+		if b.is(last+1, "(") || b.is(last+1, "[") {
+			last = b.match[last+1]
+		} else if b.is(last+1, ")") && open > 0 {
+			last++
+			open--
+		} else {
+			break
+		}
+	}
+	s.include(span{first: first, last: last})
+	return s
+}
+
+// check returns an error if the tokens of the children of a node are not inside its span or overlap
+func (b *builder) check(node ast.Node, s span) error {
+	next := s.first
+	for _, c := range b.children[node] {
+		cs := b.spans[c]
+		if cs.first < next || cs.last > s.last {
+			return fmt.Errorf("Tokens of %T overlap the ones of %T", c, node)
+		}
+		if err := b.check(c, cs); err != nil {
+			return err
+		}
+		next = cs.last + 1
+	}
+	// A node can share its token with a child, such as a declaration and the identifier it declares
+	shared := map[int]bool{}
+	for _, c := range b.children[node] {
+		for _, i := range b.own(c) {
+			shared[i] = true
+		}
+	}
+	for _, i := range b.own(node) {
+		for _, c := range b.children[node] {
+			if cs := b.spans[c]; i >= cs.first && i <= cs.last && !shared[i] {
+				return fmt.Errorf("Token '%s' of %T is inside %T", b.sig[i].Text, node, c)
+			}
+		}
+	}
+	return nil
+}
+
+// node returns the node of the tree for an AST node and its span
+func (b *builder) node(node ast.Node, s span) *Node {
+	n := &Node{AST: node}
+	i := s.first
+	for _, c := range b.children[node] {
+		cs := b.spans[c]
+		for ; i < cs.first; i++ {
+			n.Children = append(n.Children, b.sig[i])
+		}
+		n.Children = append(n.Children, b.node(c, cs))
+		i = cs.last + 1
+	}
+	for ; i <= s.last; i++ {
+		n.Children = append(n.Children, b.sig[i])
+	}
+	return n
+}
@@ -0,0 +1,128 @@
+// Package cst keeps the concrete syntax of a program, every token it is written with, whitespace and
+// comments included, attached to the nodes of its AST so that a tool can edit a small region of a
+// source and write it back without changing the rest
+package cst
+
+import (
+	"bufio"
+	"compiler/ast"
+	"compiler/lexer"
+	"compiler/source"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Token is a token of the source with its trivia, the whitespace, line terminators, comments and
+// invalid tokens skipped by the parser
+// Trailing holds the trivia following the token up to the end of its line, Leading the trivia before it
+// that is not the trailing trivia of the previous token, trivia have no trivia of their own
+// Text is the token as written, changing it changes what Fprint writes
+type Token struct {
+	Type     lexer.TokenType
+	Pos      source.Pos
+	Text     string
+	Leading  []*Token
+	Trailing []*Token
+}
+
+// Element is a child of a Node, either a *Node or a *Token
+type Element interface {
+	element()
+}
+
+func (*Node) element()  {}
+func (*Token) element() {}
+
+// Node is the concrete syntax of the AST node AST, its children are its own tokens and the nodes
+// of its children in the order they are written
+// The root stands for the Program, its last token is the EOF token holding the trivia ending the source
+type Node struct {
+	AST      ast.Node
+	Children []Element
+}
+
+// Tokens returns the tokens of a node and of the nodes it holds in the order they are written
+func (n *Node) Tokens() []*Token {
+	var tokens []*Token
+	for _, c := range n.Children {
+		switch c := c.(type) {
+		case *Node:
+			tokens = append(tokens, c.Tokens()...)
+		case *Token:
+			tokens = append(tokens, c)
+		}
+	}
+	return tokens
+}
+
+// Find returns the node standing for an AST node in the tree rooted at n, nil if there is none
+func (n *Node) Find(node ast.Node) *Node {
+	if n.AST == node {
+		return n
+	}
+	for _, c := range n.Children {
+		if c, ok := c.(*Node); ok {
+			if found := c.Find(node); found != nil {
+				return found
+			}
+		}
+	}
+	return nil
+}
+
+// Fprint writes the source of a node, its tokens with their trivia
+// The root returned by Build writes the source it was built from byte for byte
+func Fprint(w io.Writer, n *Node) error {
+	b := bufio.NewWriter(w)
+	for _, t := range n.Tokens() {
+		for _, l := range t.Leading {
+			b.WriteString(l.Text)
+		}
+		b.WriteString(t.Text)
+		for _, l := range t.Trailing {
+			b.WriteString(l.Text)
+		}
+	}
+	return b.Flush()
+}
+
+// FprintTree writes the tree rooted at n with one node or token per line, indented by its depth
+// Tokens are followed by their trivia when they have some
+func FprintTree(w io.Writer, n *Node) error {
+	b := bufio.NewWriter(w)
+	writeTree(b, n, 0)
+	return b.Flush()
+}
+
+func writeTree(b *bufio.Writer, n *Node, depth int) {
+	b.WriteString(strings.Repeat("  ", depth))
+	b.WriteString(strings.TrimPrefix(fmt.Sprintf("%T", n.AST), "*ast."))
+	b.WriteByte('\n')
+	for _, c := range n.Children {
+		switch c := c.(type) {
+		case *Node:
+			writeTree(b, c, depth+1)
+		case *Token:
+			b.WriteString(strings.Repeat("  ", depth+1))
+			b.WriteString(c.Type.String() + " " + strconv.Quote(c.Text))
+			if len(c.Leading) != 0 {
+				b.WriteString(" leading " + strconv.Quote(trivia(c.Leading)))
+			}
+			if len(c.Trailing) != 0 {
+				b.WriteString(" trailing " + strconv.Quote(trivia(c.Trailing)))
+			}
+			b.WriteByte('\n')
+		}
+	}
+}
+
+// trivia returns the text of a list of trivia
+func trivia(list []*Token) string {
+	var s strings.Builder
+	for _, t := range list {
+		s.WriteString(t.Text)
+	}
+	return s.String()
+}
@@ -1,6 +1,6 @@
 int main() {
     int a = 8;
     int b = 7;
-    if (a <= b) return a else return b;
+    if (a <= b) return a; else return b;
     return b;
 }
@@ -0,0 +1,456 @@
+package generator
+
+import (
+	"compiler/ast"
+	"compiler/types"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// asmRegister holds the names of a general purpose register for operands of 1, 2, 4 and 8 bytes
+type asmRegister struct {
+	names       [4]string
+	calleeSaved bool
+}
+
+// asmRegisters lists the registers an asm statement can use, %rbp and %rsp hold the frame
+var asmRegisters = []asmRegister{
+	{names: [4]string{"al", "ax", "eax", "rax"}},
+	{names: [4]string{"bl", "bx", "ebx", "rbx"}, calleeSaved: true},
+	{names: [4]string{"cl", "cx", "ecx", "rcx"}},
+	{names: [4]string{"dl", "dx", "edx", "rdx"}},
+	{names: [4]string{"sil", "si", "esi", "rsi"}},
+	{names: [4]string{"dil", "di", "edi", "rdi"}},
+	{names: [4]string{"r8b", "r8w", "r8d", "r8"}},
+	{names: [4]string{"r9b", "r9w", "r9d", "r9"}},
+	{names: [4]string{"r10b", "r10w", "r10d", "r10"}},
+	{names: [4]string{"r11b", "r11w", "r11d", "r11"}},
+	{names: [4]string{"r12b", "r12w", "r12d", "r12"}, calleeSaved: true},
+	{names: [4]string{"r13b", "r13w", "r13d", "r13"}, calleeSaved: true},
+	{names: [4]string{"r14b", "r14w", "r14d", "r14"}, calleeSaved: true},
+	{names: [4]string{"r15b", "r15w", "r15d", "r15"}, calleeSaved: true},
+}
+
+// asmConstraintRegisters maps the constraint letters naming a single register to its position in asmRegisters
+var asmConstraintRegisters = map[byte]int{'a': 0, 'b': 1, 'c': 2, 'd': 3, 'S': 4, 'D': 5}
+
+// asmRegisterByName returns the position in asmRegisters of a register named in a clobber list
+func asmRegisterByName(name string) (int, bool) {
+	name = strings.TrimPrefix(name, "%")
+	for i, r := range asmRegisters {
+		for _, n := range r.names {
+			if n == name {
+				return i, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// asmRegisterName returns the name of a register for an operand of the given size
+func asmRegisterName(register int, size int) string {
+	switch size {
+	case 1:
+		return "%" + asmRegisters[register].names[0]
+	case 2:
+		return "%" + asmRegisters[register].names[1]
+	case 4:
+		return "%" + asmRegisters[register].names[2]
+	}
+	return "%" + asmRegisters[register].names[3]
+}
+
+// AsmOperandKind tells where the value of an asm operand is held
+type AsmOperandKind int
+
+// The locations of an asm operand
+const (
+	AsmRegisterOperand AsmOperandKind = iota
+	AsmMemoryOperand
+	AsmImmediateOperand
+)
+
+// asmDirection tells whether the operands using a register read it, write it or both
+type asmDirection int
+
+const (
+	asmOutput asmDirection = 1 << iota
+	asmInput
+)
+
+// AsmBinding is an operand of an asm statement bound to a location
+type AsmBinding struct {
+	Operand ast.AsmOperand
+	Type    types.Type
+	Kind    AsmOperandKind
+	Output  bool
+	// ReadWrite is set for "+" outputs, their value is loaded before the template runs
+	ReadWrite bool
+	// Register holds the value of a register operand, or the address of a memory operand without Memory
+	Register int
+	// Matches is the output an input shares its register with, -1 if none
+	Matches int
+	// Memory is set for memory operands addressed directly from the frame or from a label
+	Memory string
+	// Immediate is the value of an immediate operand
+	Immediate int64
+	// Variable is set when the operand is a plain variable
+	Variable *Variable
+}
+
+// Text returns the operand as written in the template, modifier is 0 or one of b, w, k, q and c
+func (b *AsmBinding) Text(modifier byte) string {
+	switch b.Kind {
+	case AsmImmediateOperand:
+		if modifier == 'c' {
+			return fmt.Sprint(b.Immediate)
+		}
+		return fmt.Sprintf("$%d", b.Immediate)
+	case AsmMemoryOperand:
+		if b.Memory != "" {
+			return b.Memory
+		}
+		return fmt.Sprintf("(%s)", asmRegisterName(b.Register, 8))
+	}
+	switch modifier {
+	case 'b':
+		return asmRegisterName(b.Register, 1)
+	case 'w':
+		return asmRegisterName(b.Register, 2)
+	case 'k':
+		return asmRegisterName(b.Register, 4)
+	case 'q':
+		return asmRegisterName(b.Register, 8)
+	}
+	return asmRegisterName(b.Register, types.Decay(b.Type).Size())
+}
+
+// FromAsmStatement emits the template of an asm statement
+// The input operands are evaluated and moved to their registers first, then the template
+// runs and the output registers are stored to their objects
+func (g *AssemblyGenerator) FromAsmStatement(s ast.AsmStatement) error {
+	if !s.Extended {
+		g.AddAsmTemplate(s.Template)
+		return nil
+	}
+	clobbered := make(map[int]bool)
+	for _, c := range s.Clobbers {
+		if c == "memory" || c == "cc" {
+			continue
+		}
+		r, ok := asmRegisterByName(c)
+		if !ok {
+			return fmt.Errorf("Unknown register name '%s' in asm", c)
+		}
+		clobbered[r] = true
+	}
+	bindings, err := g.BindAsmOperands(s, clobbered)
+	if err != nil {
+		return err
+	}
+	template, err := SubstituteAsmOperands(s.Template, bindings)
+	if err != nil {
+		return err
+	}
+
+	// The callee saved registers used by the statement are restored once it is done
+	saved := make([]int, 0)
+	for r := range asmRegisters {
+		used := clobbered[r]
+		for _, b := range bindings {
+			used = used || (b.Kind == AsmRegisterOperand || b.Kind == AsmMemoryOperand && b.Memory == "") && b.Register == r
+		}
+		if used && asmRegisters[r].calleeSaved {
+			saved = append(saved, r)
+			g.Push(asmRegisterName(r, 8), "/* Save the callee saved register used by asm */")
+		}
+	}
+
+	// Every value is pushed before any register is set since evaluating one may use the others
+	loaded := make([]*AsmBinding, 0)
+	for _, b := range bindings {
+		switch {
+		case b.Kind == AsmMemoryOperand && b.Memory == "":
+			err = g.GenerateAddressOf(b.Operand.Expression)
+		case b.Kind == AsmRegisterOperand && (!b.Output || b.ReadWrite):
+			err = g.FromExpression(b.Operand.Expression)
+		default:
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		g.Push("%rax", "/* Keep the asm operand */")
+		loaded = append(loaded, b)
+	}
+	for i := len(loaded) - 1; i >= 0; i-- {
+		g.Pop(asmRegisterName(loaded[i].Register, 8), "/* Move the asm operand into its register */")
+	}
+
+	g.AddAsmTemplate(template)
+
+	outputs := make([]*AsmBinding, 0)
+	for _, b := range bindings {
+		if b.Output && b.Kind == AsmRegisterOperand {
+			g.Push(asmRegisterName(b.Register, 8), "/* Keep the asm output */")
+			outputs = append(outputs, b)
+		}
+	}
+	for i := len(outputs) - 1; i >= 0; i-- {
+		err = g.GenerateAsmOutputStore(outputs[i])
+		if err != nil {
+			return err
+		}
+	}
+	for _, b := range bindings {
+		// A local variable always holds its value on 8 bytes, the template may only have written part of it
+		if b.Output && b.Kind == AsmMemoryOperand && b.Variable != nil && b.Variable.Kind == LocalVariable && b.Type.Size() < 8 {
+			g.GenerateLoadFrom(b.Type, b.Memory)
+			g.GenerateVariableStore(b.Variable)
+		}
+	}
+	for i := len(saved) - 1; i >= 0; i-- {
+		g.Pop(asmRegisterName(saved[i], 8), "/* Restore the callee saved register used by asm */")
+	}
+	return nil
+}
+
+// AddAsmTemplate adds every line of an asm template as it is
+func (g *AssemblyGenerator) AddAsmTemplate(template string) {
+	for _, line := range strings.Split(strings.TrimSuffix(template, "\n"), "\n") {
+		g.AddLine(line)
+	}
+}
+
+// GenerateAsmOutputStore pops the value of an output register and stores it to its object
+func (g *AssemblyGenerator) GenerateAsmOutputStore(b *AsmBinding) error {
+	if b.Variable != nil {
+		g.Pop("%rax", "/* Get the asm output */")
+		g.GenerateConversion(b.Type)
+		g.GenerateVariableStore(b.Variable)
+		return nil
+	}
+	err := g.GenerateAddressOf(b.Operand.Expression)
+	if err != nil {
+		return err
+	}
+	g.AddLine("mov", "%rax, %rcx", "/* Move the address of the asm output into RCX */")
+	g.Pop("%rax", "/* Get the asm output */")
+	g.GenerateConversion(b.Type)
+	g.GenerateStoreTo(b.Type, "(%rcx)")
+	return nil
+}
+
+// BindAsmOperands binds the outputs then the inputs of an asm statement to registers, memory or immediates
+// Operands needing any register get one once the registers named by the constraints are known
+func (g *AssemblyGenerator) BindAsmOperands(s ast.AsmStatement, clobbered map[int]bool) ([]*AsmBinding, error) {
+	bindings := make([]*AsmBinding, 0, len(s.Outputs)+len(s.Inputs))
+	// used records for each register whether outputs or inputs name it, an input can share its register with an output
+	used := make(map[int]asmDirection)
+	operands := append(append([]ast.AsmOperand{}, s.Outputs...), s.Inputs...)
+	for i, operand := range operands {
+		b := &AsmBinding{Operand: operand, Output: i < len(s.Outputs), Register: -1, Matches: -1}
+		bindings = append(bindings, b)
+		t, err := g.TypeOf(operand.Expression)
+		if err != nil {
+			return nil, err
+		}
+		b.Type = t
+		if id, ok := operand.Expression.(*ast.Identifier); ok {
+			v, err := g.Variables.GetVariable(id.Value)
+			if err == nil && (v.Kind == LocalVariable || v.Kind == StaticVariable) {
+				b.Variable = v
+			}
+		}
+		constraint := strings.ReplaceAll(operand.Constraint, "&", "")
+		if b.Output {
+			switch {
+			case strings.HasPrefix(constraint, "="):
+			case strings.HasPrefix(constraint, "+"):
+				b.ReadWrite = true
+			default:
+				return nil, fmt.Errorf("Output operand constraint lacks '=' in \"%s\"", operand.Constraint)
+			}
+			constraint = constraint[1:]
+			err = g.CheckAsmOutput(operand.Expression, t)
+			if err != nil {
+				return nil, err
+			}
+		} else if strings.ContainsAny(constraint, "=+") {
+			return nil, fmt.Errorf("Input operand constraint contains '=' or '+' in \"%s\"", operand.Constraint)
+		}
+		if len(constraint) == 1 && constraint[0] >= '0' && constraint[0] <= '9' {
+			// The input starts in the register of the output it matches
+			n := int(constraint[0] - '0')
+			if b.Output || n >= len(s.Outputs) {
+				return nil, fmt.Errorf("Matching constraint references invalid operand number in \"%s\"", operand.Constraint)
+			}
+			if bindings[n].Kind != AsmRegisterOperand {
+				return nil, fmt.Errorf("Matching constraint does not allow a register in \"%s\"", operand.Constraint)
+			}
+			b.Kind, b.Matches = AsmRegisterOperand, n
+			continue
+		}
+		err = g.BindAsmOperand(b, constraint, clobbered, used)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, b := range bindings {
+		if b.Register >= 0 || b.Kind == AsmImmediateOperand || b.Kind == AsmMemoryOperand && b.Memory != "" {
+			continue
+		}
+		r := -1
+		for i, reg := range asmRegisters {
+			if !reg.calleeSaved && used[i] == 0 && !clobbered[i] {
+				r = i
+				break
+			}
+		}
+		if r < 0 {
+			return nil, errors.New("Impossible register constraint in asm, out of registers")
+		}
+		used[r] = asmOutput | asmInput
+		b.Register = r
+	}
+	for _, b := range bindings {
+		if b.Matches >= 0 {
+			b.Register = bindings[b.Matches].Register
+		}
+	}
+	return bindings, nil
+}
+
+// BindAsmOperand picks the location of an operand among the ones its constraint allows
+// A register named by the constraint is reserved right away
+func (g *AssemblyGenerator) BindAsmOperand(b *AsmBinding, constraint string, clobbered map[int]bool, used map[int]asmDirection) error {
+	direction := asmInput
+	if b.Output {
+		direction = asmOutput
+		if b.ReadWrite {
+			direction |= asmInput
+		}
+	}
+	allowRegister, allowMemory, allowImmediate := false, false, false
+	for i := 0; i < len(constraint); i++ {
+		c := constraint[i]
+		if r, ok := asmConstraintRegisters[c]; ok {
+			if used[r]&direction != 0 {
+				return fmt.Errorf("Register '%s' is used by several asm operands", asmRegisterName(r, 8))
+			}
+			if clobbered[r] {
+				return fmt.Errorf("Register '%s' of an asm operand conflicts with the clobber list", asmRegisterName(r, 8))
+			}
+			used[r] |= direction
+			b.Kind, b.Register = AsmRegisterOperand, r
+			return g.CheckAsmRegisterType(b)
+		}
+		switch c {
+		case 'r', 'q', 'R':
+			allowRegister = true
+		case 'm', 'o', 'V':
+			allowMemory = true
+		case 'i', 'n':
+			allowImmediate = true
+		case 'g':
+			allowRegister, allowMemory, allowImmediate = true, true, true
+		default:
+			return fmt.Errorf("Impossible constraint \"%s\" in asm", b.Operand.Constraint)
+		}
+	}
+	if allowImmediate && !b.Output {
+		if v, err := g.EvalConstant(b.Operand.Expression); err == nil {
+			b.Kind, b.Immediate = AsmImmediateOperand, v
+			return nil
+		}
+	}
+	if allowMemory && g.IsAsmAddressable(b.Operand.Expression) {
+		b.Kind = AsmMemoryOperand
+		if b.Variable != nil && b.Variable.Kind == LocalVariable {
+			b.Memory = fmt.Sprintf("%d(%%rbp)", b.Variable.StackIndex)
+		} else if b.Variable != nil {
+			b.Memory = fmt.Sprintf("%s(%%rip)", b.Variable.Label)
+		}
+		return nil
+	}
+	if allowRegister {
+		b.Kind = AsmRegisterOperand
+		return g.CheckAsmRegisterType(b)
+	}
+	if allowMemory {
+		return fmt.Errorf("Memory input \"%s\" is not directly addressable", b.Operand.Constraint)
+	}
+	return fmt.Errorf("Impossible constraint \"%s\" in asm", b.Operand.Constraint)
+}
+
+// CheckAsmRegisterType makes sure the value of an operand fits in a register
+func (g *AssemblyGenerator) CheckAsmRegisterType(b *AsmBinding) error {
+	switch types.Unqualified(types.Decay(b.Type)).(type) {
+	case *types.Struct:
+		return fmt.Errorf("Asm operand of type '%s' cannot be held in a register", b.Type)
+	}
+	return nil
+}
+
+// CheckAsmOutput makes sure the object designated by an output operand can be written to
+func (g *AssemblyGenerator) CheckAsmOutput(e ast.Expression, t types.Type) error {
+	if id, ok := e.(*ast.Identifier); ok {
+		_, err := g.GetModifiableVariable(id.Value)
+		return err
+	}
+	return g.CheckModifiable(e, t)
+}
+
+// IsAsmAddressable returns whether or not the operand designates an object a memory operand can refer to
+func (g *AssemblyGenerator) IsAsmAddressable(e ast.Expression) bool {
+	switch e := e.(type) {
+	case *ast.Identifier:
+		v, err := g.Variables.GetVariable(e.Value)
+		return err == nil && (v.Kind == LocalVariable || v.Kind == StaticVariable)
+	case *ast.PrefixExpression:
+		return e.Operator == "*"
+	case *ast.IndexExpression, *ast.MemberExpression:
+		return true
+	}
+	return false
+}
+
+// SubstituteAsmOperands replaces the operand references of an extended asm template
+// %N is operand N, %bN %wN %kN and %qN name its register with another size,
+// %cN is an immediate without "$" and %% is a single %
+func SubstituteAsmOperands(template string, bindings []*AsmBinding) (string, error) {
+	var sb strings.Builder
+	for i := 0; i < len(template); i++ {
+		if template[i] != '%' {
+			sb.WriteByte(template[i])
+			continue
+		}
+		i++
+		if i < len(template) && template[i] == '%' {
+			sb.WriteByte('%')
+			continue
+		}
+		var modifier byte
+		if i < len(template) && strings.IndexByte("bwkqc", template[i]) >= 0 {
+			modifier = template[i]
+			i++
+		}
+		n := -1
+		for ; i < len(template) && template[i] >= '0' && template[i] <= '9'; i++ {
+			if n < 0 {
+				n = 0
+			}
+			n = n*10 + int(template[i]-'0')
+		}
+		i--
+		if n < 0 {
+			return "", errors.New("Operand number missing after %-letter in asm template")
+		}
+		if n >= len(bindings) {
+			return "", fmt.Errorf("Operand number %d out of range in asm template", n)
+		}
+		sb.WriteString(bindings[n].Text(modifier))
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,170 @@
+package generator
+
+import (
+	"compiler/ast"
+	"compiler/types"
+	"errors"
+	"fmt"
+)
+
+// EvalConstant computes the value of a constant expression at compile time
+func (g *AssemblyGenerator) EvalConstant(e ast.Expression) (int64, error) {
+	switch e := e.(type) {
+	case *ast.IntegerLiteral:
+		v, _, err := types.IntegerConstant(e.Value)
+		return v, err
+	case *ast.Identifier:
+		v, err := g.Variables.GetVariable(e.Value)
+		if err != nil {
+			return 0, err
+		}
+		if v.Kind != EnumConstant {
+			return 0, fmt.Errorf("'%s' is not a constant", e.Value)
+		}
+		return v.Value, nil
+	case *ast.PrefixExpression:
+		v, err := g.EvalConstant(e.Expression)
+		if err != nil {
+			return 0, err
+		}
+		switch e.Operator {
+		case "-", "~":
+			t, err := g.TypeOf(e)
+			if err != nil {
+				return 0, err
+			}
+			if e.Operator == "-" {
+				return ConvertConstant(t, -v), nil
+			}
+			return ConvertConstant(t, ^v), nil
+		case "!":
+			return boolToConstant(v == 0), nil
+		}
+		return 0, fmt.Errorf("Operator '%s' is not supported in a constant expression", e.Operator)
+	case *ast.InfixExpression:
+		return g.EvalInfixConstant(e)
+	case *ast.CastExpression:
+		v, err := g.EvalConstant(e.Expression)
+		if err != nil {
+			return 0, err
+		}
+		t, err := g.ResolveType(e.Type)
+		if err != nil {
+			return 0, err
+		}
+		return ConvertConstant(t, v), nil
+	case *ast.SizeofExpression:
+		var t types.Type
+		var err error
+		if e.Expression != nil {
+			t, err = g.TypeOf(e.Expression)
+		} else {
+			t, err = g.ResolveType(e.Type)
+		}
+		if err != nil {
+			return 0, err
+		}
+		return int64(t.Size()), nil
+	default:
+		return 0, fmt.Errorf("%s is not a constant expression", e.TokenLiteral())
+	}
+}
+
+// EvalInfixConstant computes the value of a binary operation between two constant expressions
+func (g *AssemblyGenerator) EvalInfixConstant(e *ast.InfixExpression) (int64, error) {
+	l, err := g.EvalConstant(e.Left)
+	if err != nil {
+		return 0, err
+	}
+	// Short circuit the same way the generated code would
+	if e.Operator == "&&" && l == 0 {
+		return 0, nil
+	}
+	if e.Operator == "||" && l != 0 {
+		return 1, nil
+	}
+	r, err := g.EvalConstant(e.Right)
+	if err != nil {
+		return 0, err
+	}
+	if e.Operator == "&&" || e.Operator == "||" {
+		return boolToConstant(r != 0), nil
+	}
+	// The operands are converted to their common type like in the generated code
+	t, err := g.OperandType(*e)
+	if err != nil {
+		return 0, err
+	}
+	l, r = ConvertConstant(t, l), ConvertConstant(t, r)
+	unsigned := IsUnsignedOperation(t)
+	switch e.Operator {
+	case "+":
+		return ConvertConstant(t, l+r), nil
+	case "-":
+		return ConvertConstant(t, l-r), nil
+	case "*":
+		return ConvertConstant(t, l*r), nil
+	case "/", "%":
+		if r == 0 {
+			return 0, errors.New("Division by zero in constant expression")
+		}
+		switch {
+		case unsigned && e.Operator == "/":
+			return ConvertConstant(t, int64(uint64(l)/uint64(r))), nil
+		case unsigned:
+			return ConvertConstant(t, int64(uint64(l)%uint64(r))), nil
+		case e.Operator == "/":
+			return ConvertConstant(t, l/r), nil
+		}
+		return ConvertConstant(t, l%r), nil
+	case "==":
+		return boolToConstant(l == r), nil
+	case "!=":
+		return boolToConstant(l != r), nil
+	}
+	less, greater := l < r, l > r
+	if unsigned {
+		less, greater = uint64(l) < uint64(r), uint64(l) > uint64(r)
+	}
+	switch e.Operator {
+	case "<":
+		return boolToConstant(less), nil
+	case "<=":
+		return boolToConstant(!greater), nil
+	case ">":
+		return boolToConstant(greater), nil
+	case ">=":
+		return boolToConstant(!less), nil
+	}
+	return 0, fmt.Errorf("Operator '%s' is not supported in a constant expression", e.Operator)
+}
+
+// ConvertConstant truncates and extends a constant the same way GenerateConversion does at runtime
+func ConvertConstant(t types.Type, v int64) int64 {
+	b, ok := types.Underlying(t).(*types.Basic)
+	if !ok {
+		return v
+	}
+	switch b.Kind {
+	case types.CharKind:
+		return int64(int8(v))
+	case types.UnsignedCharKind:
+		return int64(uint8(v))
+	case types.ShortKind:
+		return int64(int16(v))
+	case types.UnsignedShortKind:
+		return int64(uint16(v))
+	case types.IntKind:
+		return int64(int32(v))
+	case types.UnsignedIntKind:
+		return int64(uint32(v))
+	}
+	return v
+}
+
+func boolToConstant(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -2,42 +2,416 @@ package generator
 
 import (
 	"compiler/ast"
+	"compiler/types"
+	"errors"
 	"fmt"
 )
 
-func (g *AssemblyGenerator) FromIntegerLiteral(e ast.IntegerLiteral) {
-	g.AddLine("mov", fmt.Sprintf("$%s, %%rax", e.Value), "/* Push the int constant to the RAX register */")
+func (g *AssemblyGenerator) FromIntegerLiteral(e ast.IntegerLiteral) error {
+	v, _, err := types.IntegerConstant(e.Value)
+	if err != nil {
+		return err
+	}
+	g.AddLine("mov", fmt.Sprintf("$%d, %%rax", v), "/* Push the int constant to the RAX register */")
+	return nil
 }
 
 func (g *AssemblyGenerator) FromPrefixExpression(e ast.PrefixExpression) error {
+	if e.Operator == "&" {
+		return g.GenerateAddressOf(e.Expression)
+	}
+	if e.Operator == "++" || e.Operator == "--" {
+		return g.GenerateIncDec(e.Expression, e.Operator, false)
+	}
 	err := g.FromExpression(e.Expression)
 	if err != nil {
 		return err
 	}
 	if e.Operator == "-" || e.Operator == "~" {
-		g.AddLine("eg", "%rax", "/* Negates the value in RAX */")
+		t, err := g.TypeOf(&e)
+		if err != nil {
+			return err
+		}
+		if e.Operator == "-" {
+			g.AddLine("neg", "%rax", "/* Negates the value in RAX */")
+		} else {
+			g.AddLine("not", "%rax", "/* Flips every bit of the value in RAX */")
+		}
+		// Only the bits of the promoted type are kept
+		g.GenerateConversion(t)
 		return nil
 	} else if e.Operator == "!" {
 		g.AddLine("cmp", "$0, %rax", "/* Set ZF to 0 if expression is equal to 0 */")
 		g.AddLine("mov", "$0, %rax", "/* Clear the EAX register */")
 		g.AddLine("sete", "%al", "/* Set the AL register to the value in ZF */")
 		return nil
+	} else if e.Operator == "*" {
+		t, err := g.TypeOf(e.Expression)
+		if err != nil {
+			return err
+		}
+		// Dereferencing a function gives back the function
+		if p, ok := types.Decay(t).(*types.Pointer); ok {
+			g.GenerateLoad(p.Elem)
+			return nil
+		}
+		return fmt.Errorf("Could not dereference a value of type '%s'", t)
 	}
 	return fmt.Errorf("Could not generate. Operator '%s' is not supported", e.Operator)
 }
 
+func (g *AssemblyGenerator) FromPostfixExpression(e ast.PostfixExpression) error {
+	return g.GenerateIncDec(e.Expression, e.Operator, true)
+}
+
+// GenerateIncDec adds or subtracts one to a variable, pointers move by the size of what they point to
+// RAX holds the value from before the operation for postfix operations and the new value otherwise
+func (g *AssemblyGenerator) GenerateIncDec(e ast.Expression, operator string, postfix bool) error {
+	id, ok := e.(*ast.Identifier)
+	if !ok {
+		return g.GenerateLocationIncDec(e, operator, postfix)
+	}
+	v, err := g.GetModifiableVariable(id.Value)
+	if err != nil {
+		return err
+	}
+	step, err := incDecStep(v.Type)
+	if err != nil {
+		return err
+	}
+	g.GenerateVariableLoad(v)
+	if postfix {
+		g.Push("%rax", "/* Keep the value from before the operation */")
+	}
+	if operator == "++" {
+		g.AddLine("add", fmt.Sprintf("$%d, %%rax", step), "/* Increment the variable */")
+	} else {
+		g.AddLine("sub", fmt.Sprintf("$%d, %%rax", step), "/* Decrement the variable */")
+	}
+	g.GenerateConversion(v.Type)
+	g.GenerateVariableStore(v)
+	if postfix {
+		g.Pop("%rax", "/* The expression gives the value from before the operation */")
+	}
+	return nil
+}
+
+// GenerateLocationIncDec adds or subtracts one to an object designated by an expression such as `a[i]`
+func (g *AssemblyGenerator) GenerateLocationIncDec(e ast.Expression, operator string, postfix bool) error {
+	t, err := g.TypeOf(e)
+	if err != nil {
+		return err
+	}
+	err = g.CheckModifiable(e, t)
+	if err != nil {
+		return err
+	}
+	step, err := incDecStep(t)
+	if err != nil {
+		return err
+	}
+	err = g.GenerateAddressOf(e)
+	if err != nil {
+		return err
+	}
+	g.AddLine("mov", "%rax, %rcx", "/* Keep the address of the object in RCX */")
+	g.GenerateLoadFrom(t, "(%rcx)")
+	g.AddLine("mov", "%rax, %rdx", "/* Keep the value from before the operation */")
+	if operator == "++" {
+		g.AddLine("add", fmt.Sprintf("$%d, %%rax", step), "/* Increment the object */")
+	} else {
+		g.AddLine("sub", fmt.Sprintf("$%d, %%rax", step), "/* Decrement the object */")
+	}
+	g.GenerateConversion(t)
+	g.GenerateStoreTo(t, "(%rcx)")
+	if postfix {
+		g.AddLine("mov", "%rdx, %rax", "/* The expression gives the value from before the operation */")
+	}
+	return nil
+}
+
+// incDecStep returns the amount added by an increment of a value of type t
+func incDecStep(t types.Type) (int, error) {
+	if p, ok := types.Unqualified(t).(*types.Pointer); ok {
+		if !types.IsComplete(p.Elem) {
+			return 0, fmt.Errorf("Arithmetic on a pointer to incomplete type '%s'", p.Elem)
+		}
+		return p.Elem.Size(), nil
+	}
+	return 1, nil
+}
+
+// GenerateAddressOf moves the address of an expression into RAX
+func (g *AssemblyGenerator) GenerateAddressOf(e ast.Expression) error {
+	switch e := e.(type) {
+	case *ast.Identifier:
+		v, err := g.Variables.GetVariable(e.Value)
+		if err != nil {
+			return err
+		}
+		switch v.Kind {
+		case LocalVariable:
+			g.AddLine("lea", fmt.Sprintf("%d(%%rbp), %%rax", v.StackIndex), "/* Move the address of the variable into RAX */")
+			return nil
+		case FunctionName:
+			g.AddLine("lea", fmt.Sprintf("%s(%%rip), %%rax", v.Label), "/* Move the address of the function into RAX */")
+			return nil
+		case StaticVariable:
+			g.AddLine("lea", fmt.Sprintf("%s(%%rip), %%rax", v.Label), "/* Move the address of the variable into RAX */")
+			return nil
+		}
+	case *ast.PrefixExpression:
+		// &*p is the value of p
+		if e.Operator == "*" {
+			return g.FromExpression(e.Expression)
+		}
+	case *ast.IndexExpression:
+		return g.GenerateIndexAddress(*e)
+	case *ast.MemberExpression:
+		return g.GenerateMemberAddress(*e)
+	}
+	return fmt.Errorf("Could not take the address of %s", e.TokenLiteral())
+}
+
+// GenerateIndexAddress moves the address of the element of an array or pointer into RAX
+// The index is scaled by the size of the elements, `i[a]` is the same as `a[i]`
+func (g *AssemblyGenerator) GenerateIndexAddress(e ast.IndexExpression) error {
+	base, index := e.Left, e.Index
+	t, err := g.TypeOf(base)
+	if err != nil {
+		return err
+	}
+	if types.IsArithmetic(t) {
+		base, index = index, base
+		t, err = g.TypeOf(base)
+		if err != nil {
+			return err
+		}
+	}
+	p, ok := types.Decay(t).(*types.Pointer)
+	if !ok {
+		return fmt.Errorf("Subscripted value of type '%s' is neither array nor pointer", t)
+	}
+	it, err := g.TypeOf(index)
+	if err != nil {
+		return err
+	}
+	if !types.IsArithmetic(it) {
+		return fmt.Errorf("Array subscript of type '%s' is not an integer", it)
+	}
+	if !types.IsComplete(p.Elem) {
+		return fmt.Errorf("Subscript of a pointer to incomplete type '%s'", p.Elem)
+	}
+	err = g.FromExpression(base)
+	if err != nil {
+		return err
+	}
+	g.Push("%rax", "/* Keep the address of the first element */")
+	err = g.FromExpression(index)
+	if err != nil {
+		return err
+	}
+	g.AddLine("imul", fmt.Sprintf("$%d, %%rax", p.Elem.Size()), "/* Scale the index by the size of the elements */")
+	g.Pop("%rcx", "/* Get back the address of the first element */")
+	g.AddLine("add", "%rcx, %rax", "/* Move to the element */")
+	return nil
+}
+
+// GenerateMemberAddress moves the address of a member of a struct into RAX
+func (g *AssemblyGenerator) GenerateMemberAddress(e ast.MemberExpression) error {
+	f, err := g.MemberOf(e)
+	if err != nil {
+		return err
+	}
+	if e.Operator == "->" {
+		err = g.FromExpression(e.Left)
+	} else {
+		err = g.GenerateAddressOf(e.Left)
+	}
+	if err != nil {
+		return err
+	}
+	if f.Offset != 0 {
+		g.AddLine("add", fmt.Sprintf("$%d, %%rax", f.Offset), fmt.Sprintf("/* Move to the member '%s' */", f.Name))
+	}
+	return nil
+}
+
+// FromIndexExpression moves the value of an element of an array or pointer into RAX
+func (g *AssemblyGenerator) FromIndexExpression(e ast.IndexExpression) error {
+	t, err := g.TypeOf(&e)
+	if err != nil {
+		return err
+	}
+	err = g.GenerateIndexAddress(e)
+	if err != nil {
+		return err
+	}
+	g.GenerateLoad(t)
+	return nil
+}
+
+// FromMemberExpression moves the value of a member of a struct into RAX
+func (g *AssemblyGenerator) FromMemberExpression(e ast.MemberExpression) error {
+	f, err := g.MemberOf(e)
+	if err != nil {
+		return err
+	}
+	err = g.GenerateMemberAddress(e)
+	if err != nil {
+		return err
+	}
+	g.GenerateLoad(f.Type)
+	return nil
+}
+
+// GenerateLoad replaces the address in RAX by the value of type t it points to
+// Functions, arrays, structs and va_lists are left as addresses since they are not loaded in a register
+func (g *AssemblyGenerator) GenerateLoad(t types.Type) {
+	switch types.Unqualified(t).(type) {
+	case *types.Func, *types.Array, *types.Struct, *types.VaList:
+		return
+	}
+	g.GenerateLoadFrom(t, "(%rax)")
+}
+
+// GenerateLoadFrom moves the value of type t stored at the memory operand src into RAX
+// The value is sign or zero extended to 64 bits according to its type
+func (g *AssemblyGenerator) GenerateLoadFrom(t types.Type, src string) {
+	if b, ok := types.Underlying(t).(*types.Basic); ok {
+		switch b.Kind {
+		case types.CharKind:
+			g.AddLine("movsbq", src+", %rax", "/* Load a char into RAX */")
+			return
+		case types.UnsignedCharKind:
+			g.AddLine("movzbq", src+", %rax", "/* Load an unsigned char into RAX */")
+			return
+		case types.ShortKind:
+			g.AddLine("movswq", src+", %rax", "/* Load a short into RAX */")
+			return
+		case types.UnsignedShortKind:
+			g.AddLine("movzwq", src+", %rax", "/* Load an unsigned short into RAX */")
+			return
+		case types.IntKind:
+			g.AddLine("movslq", src+", %rax", "/* Load an int into RAX */")
+			return
+		case types.UnsignedIntKind:
+			g.AddLine("movl", src+", %eax", "/* Load an unsigned int into RAX */")
+			return
+		}
+	}
+	g.AddLine("mov", src+", %rax", "/* Load the value into RAX */")
+}
+
+// GenerateStoreTo moves the value of type t held in RAX to the memory operand dst
+func (g *AssemblyGenerator) GenerateStoreTo(t types.Type, dst string) {
+	switch t.Size() {
+	case 1:
+		g.AddLine("movb", "%al, "+dst, "/* Store the lowest byte of RAX */")
+	case 2:
+		g.AddLine("movw", "%ax, "+dst, "/* Store the lowest 2 bytes of RAX */")
+	case 4:
+		g.AddLine("movl", "%eax, "+dst, "/* Store the lowest 4 bytes of RAX */")
+	default:
+		g.AddLine("mov", "%rax, "+dst, "/* Store RAX */")
+	}
+}
+
+// GenerateVariableLoad moves the value of a variable into RAX
+func (g *AssemblyGenerator) GenerateVariableLoad(v *Variable) {
+	if v.Kind == StaticVariable {
+		g.GenerateLoadFrom(v.Type, fmt.Sprintf("%s(%%rip)", v.Label))
+		return
+	}
+	g.AddLine("mov", fmt.Sprintf("%d(%%rbp), %%rax", v.StackIndex), "/* Move the variable into the rax register */")
+}
+
+// GenerateVariableStore moves RAX into a variable
+func (g *AssemblyGenerator) GenerateVariableStore(v *Variable) {
+	if v.Kind == StaticVariable {
+		g.GenerateStoreTo(v.Type, fmt.Sprintf("%s(%%rip)", v.Label))
+		return
+	}
+	g.AddLine("mov", fmt.Sprintf("%%rax, %d(%%rbp)", v.StackIndex), "/* Move the result into the variable */")
+}
+
+// argumentRegisters lists the registers used to pass the first arguments of a call
+var argumentRegisters = []string{"%rdi", "%rsi", "%rdx", "%rcx", "%r8", "%r9"}
+
+// FromCallExpression calls a function by name or through a function pointer following the System V ABI
+// Arguments are all evaluated and pushed first so nested calls cannot overwrite the argument registers
+func (g *AssemblyGenerator) FromCallExpression(e ast.CallExpression) error {
+	fn, err := g.CheckCall(e)
+	if err != nil {
+		return err
+	}
+	stackArgs := len(e.Arguments) - len(argumentRegisters)
+	if stackArgs < 0 {
+		stackArgs = 0
+	}
+	// The stack has to be 16 bytes aligned once the arguments passed on the stack are pushed
+	padding := 0
+	if (g.StackDepth+8*stackArgs)%16 != 0 {
+		padding = 8
+		g.AddLine("sub", "$8, %rsp", "/* Align the stack for the call */")
+		g.StackDepth += padding
+	}
+	for i := len(e.Arguments) - 1; i >= 0; i-- {
+		err := g.FromExpression(e.Arguments[i])
+		if err != nil {
+			return err
+		}
+		if i < len(fn.Params) {
+			g.GenerateConversion(fn.Params[i])
+		}
+		g.Push("%rax", fmt.Sprintf("/* Stack argument %d */", i))
+	}
+	callee := ""
+	if id, ok := e.Function.(*ast.Identifier); ok {
+		if v, err := g.Variables.GetVariable(id.Value); err == nil && v.Kind == FunctionName {
+			callee = id.Value
+		}
+	}
+	if callee == "" {
+		err := g.FromExpression(e.Function)
+		if err != nil {
+			return err
+		}
+		g.AddLine("mov", "%rax, %r10", "/* Keep the function address out of the argument registers */")
+	}
+	for i := 0; i < len(e.Arguments) && i < len(argumentRegisters); i++ {
+		g.Pop(argumentRegisters[i], fmt.Sprintf("/* Pass argument %d in its register */", i))
+	}
+	if fn.Variadic || fn.Unprototyped {
+		// The callee may be variadic when its parameters are not known
+		g.AddLine("mov", "$0, %eax", "/* No vector register holds an argument */")
+	}
+	if callee != "" {
+		g.AddLine("call", callee)
+	} else {
+		g.AddLine("call", "*%r10", "/* Indirect call through the function pointer */")
+	}
+	if cleanup := 8*stackArgs + padding; cleanup != 0 {
+		g.AddLine("add", fmt.Sprintf("$%d, %%rsp", cleanup), "/* Drop the stack arguments and the alignment */")
+		g.StackDepth -= cleanup
+	}
+	// Only the bytes of the return type are set by the callee
+	g.GenerateConversion(fn.Return)
+	return nil
+}
+
 // GenerateAddAssembly will output the string for an addition operation between two expressions
 func (g *AssemblyGenerator) GenerateAddAssembly(e1 ast.Expression, e2 ast.Expression) error {
 	err := g.FromExpression(e1)
 	if err != nil {
 		return err
 	}
-	g.AddLine("push", "%rax", "/* Push the previous expression (e1) result to the RAX register */")
+	g.Push("%rax", "/* Push the previous expression (e1) result to the RAX register */")
 	err = g.FromExpression(e2)
 	if err != nil {
 		return err
 	}
-	g.AddLine("pop", "%rcx", "/* Extract the second expression (e2) result from the RAX register */")
+	g.Pop("%rcx", "/* Extract the second expression (e2) result from the RAX register */")
 	g.AddLine("add", "%rcx, %rax", "/* Add e1 and e2 and push it to the RAX register */")
 	return nil
 }
@@ -48,52 +422,131 @@ func (g *AssemblyGenerator) GenerateSubAssembly(e1 ast.Expression, e2 ast.Expres
 	if err != nil {
 		return err
 	}
-	g.AddLine("push", "%rax", "/* Push the previous expression (e2) result to the stack */")
+	g.Push("%rax", "/* Push the previous expression (e2) result to the stack */")
 	err = g.FromExpression(e1)
 	if err != nil {
 		return err
 	}
-	g.AddLine("pop", "%rcx", "/* Extract the second expression (e2) result from the the stack onto RCX */")
+	g.Pop("%rcx", "/* Extract the second expression (e2) result from the the stack onto RCX */")
 	g.AddLine("sub", "%rcx, %rax", "/* Subtract e2 from e1 and push it to the RAX register */")
 	return nil
 }
 
+// IsPointerArithmetic returns whether or not an addition or a subtraction has a pointer operand
+func (g *AssemblyGenerator) IsPointerArithmetic(e ast.InfixExpression) (bool, error) {
+	for _, operand := range []ast.Expression{e.Left, e.Right} {
+		t, err := g.TypeOf(operand)
+		if err != nil {
+			return false, err
+		}
+		if _, ok := types.Decay(t).(*types.Pointer); ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// GeneratePointerArithmetic moves a pointer by an integer scaled by the size of the pointed type
+// The difference of two pointers is the number of elements between them
+func (g *AssemblyGenerator) GeneratePointerArithmetic(e ast.InfixExpression) error {
+	lt, err := g.TypeOf(e.Left)
+	if err != nil {
+		return err
+	}
+	rt, err := g.TypeOf(e.Right)
+	if err != nil {
+		return err
+	}
+	lp, lok := types.Decay(lt).(*types.Pointer)
+	rp, rok := types.Decay(rt).(*types.Pointer)
+	pointer, integer := e.Left, e.Right
+	p := lp
+	switch {
+	case lok && rok:
+		if e.Operator != "-" || !types.Identical(types.Unqualified(lp.Elem), types.Unqualified(rp.Elem)) {
+			return fmt.Errorf("Invalid operands to '%s' of types '%s' and '%s'", e.Operator, lt, rt)
+		}
+	case rok:
+		if e.Operator == "-" {
+			return fmt.Errorf("Invalid operands to '-' of types '%s' and '%s'", lt, rt)
+		}
+		pointer, integer, p = e.Right, e.Left, rp
+		fallthrough
+	default:
+		if it, _ := g.TypeOf(integer); !types.IsArithmetic(it) {
+			return fmt.Errorf("Invalid operands to '%s' of types '%s' and '%s'", e.Operator, lt, rt)
+		}
+	}
+	if !types.IsComplete(p.Elem) {
+		return fmt.Errorf("Arithmetic on a pointer to incomplete type '%s'", p.Elem)
+	}
+	size := p.Elem.Size()
+	if lok && rok {
+		err = g.GenerateSubAssembly(e.Left, e.Right)
+		if err != nil {
+			return err
+		}
+		g.AddLine("mov", fmt.Sprintf("$%d, %%rcx", size), "/* Size of the pointed type */")
+		g.AddLine("cqo", "/* Sign extend RAX into RDX */")
+		g.AddLine("idiv", "%rcx", "/* Count the elements between the pointers */")
+		return nil
+	}
+	err = g.FromExpression(integer)
+	if err != nil {
+		return err
+	}
+	g.AddLine("imul", fmt.Sprintf("$%d, %%rax", size), "/* Scale the integer by the size of the pointed type */")
+	g.Push("%rax", "/* Keep the scaled integer */")
+	err = g.FromExpression(pointer)
+	if err != nil {
+		return err
+	}
+	g.Pop("%rcx", "/* Get back the scaled integer */")
+	if e.Operator == "+" {
+		g.AddLine("add", "%rcx, %rax", "/* Move the pointer forward */")
+	} else {
+		g.AddLine("sub", "%rcx, %rax", "/* Move the pointer backward */")
+	}
+	return nil
+}
+
 // GenerateMultAssembly will output the assembly string multiplying two expressions
 func (g *AssemblyGenerator) GenerateMultAssembly(e1 ast.Expression, e2 ast.Expression) error {
 	err := g.FromExpression(e1)
 	if err != nil {
 		return err
 	}
-	g.AddLine("push", "%rax", "/* Push the previous expression (e1) result to the RAX register */")
+	g.Push("%rax", "/* Push the previous expression (e1) result to the RAX register */")
 	err = g.FromExpression(e2)
 	if err != nil {
 		return err
 	}
-	g.AddLine("pop", "%rcx", "/* Extract the second expression (e2) result from the RAX register */")
+	g.Pop("%rcx", "/* Extract the second expression (e2) result from the RAX register */")
 	g.AddLine("imul", "%rcx, %rax", "/* Multiply e1 and e2 and push it to the RAX register */")
 	return nil
 }
 
-// GenerateDivAssembly will output the assembly string dividing two expressions
-func (g *AssemblyGenerator) GenerateDivAssembly(e1 ast.Expression, e2 ast.Expression) error {
+// GenerateDivAssembly will output the assembly string dividing two expressions converted to t
+func (g *AssemblyGenerator) GenerateDivAssembly(t types.Type, e1 ast.Expression, e2 ast.Expression) error {
 	err := g.FromExpression(e2)
 	if err != nil {
 		return err
 	}
-	g.AddLine("push", "%rax", "/* Push (e2) to the stack */")
+	g.GenerateConversion(t)
+	g.Push("%rax", "/* Push (e2) to the stack */")
 	err = g.FromExpression(e1)
 	if err != nil {
 		return err
 	}
-	g.AddLine("cdq", "/* Expand rax (e1) into rdx to become a 64bit number */")
-	g.AddLine("pop", "%rcx", "/* Grab e2 form the stack to become the dividend */")
-	g.AddLine("div", "%rcx", "/* Divide (e1) by (e2), sending the quotient to rax */")
+	g.GenerateConversion(t)
+	g.Pop("%rcx", "/* Grab e2 form the stack to become the divisor */")
+	g.GenerateDivide(t)
 	return nil
 }
 
-// GenerateModuloAssembly will output the assembly string dividing two expressions
-func (g *AssemblyGenerator) GenerateModuloAssembly(e1 ast.Expression, e2 ast.Expression) error {
-	err := g.GenerateDivAssembly(e1, e2)
+// GenerateModuloAssembly will output the assembly string dividing two expressions converted to t
+func (g *AssemblyGenerator) GenerateModuloAssembly(t types.Type, e1 ast.Expression, e2 ast.Expression) error {
+	err := g.GenerateDivAssembly(t, e1, e2)
 	if err != nil {
 		return err
 	}
@@ -102,6 +555,18 @@ func (g *AssemblyGenerator) GenerateModuloAssembly(e1 ast.Expression, e2 ast.Exp
 	return nil
 }
 
+// GenerateDivide divides RAX by RCX, both holding values of type t
+// The quotient is left in RAX and the remainder in RDX
+func (g *AssemblyGenerator) GenerateDivide(t types.Type) {
+	if IsUnsignedOperation(t) {
+		g.AddLine("xor", "%rdx, %rdx", "/* The unsigned dividend is zero extended into RDX */")
+		g.AddLine("div", "%rcx", "/* Unsigned divide of RDX:RAX by RCX */")
+		return
+	}
+	g.AddLine("cqo", "/* Sign extend RAX into RDX */")
+	g.AddLine("idiv", "%rcx", "/* Signed divide of RDX:RAX by RCX */")
+}
+
 func (g *AssemblyGenerator) GenerateLogicalAndAssembly(e1 ast.Expression, e2 ast.Expression) error {
 	clauseName := g.LabelGenerator.GetNextLabel("clause")
 	endName := g.LabelGenerator.GetNextLabel("end")
@@ -155,100 +620,315 @@ func (g *AssemblyGenerator) GenerateLogicalOrAssembly(e1 ast.Expression, e2 ast.
 	return nil
 }
 
-func (g *AssemblyGenerator) GenerateComparatorAssembly(instr string, e1 ast.Expression, e2 ast.Expression) error {
+// setInstructions maps the comparison operators to the instructions setting AL from the flags,
+// for signed operands then for unsigned ones
+var setInstructions = map[string][2]string{
+	"==": {"sete", "sete"},
+	"!=": {"setne", "setne"},
+	">":  {"setg", "seta"},
+	">=": {"setge", "setae"},
+	"<":  {"setl", "setb"},
+	"<=": {"setle", "setbe"},
+}
+
+// GenerateComparatorAssembly compares two expressions converted to t, RAX is set to 1 when the comparison holds
+func (g *AssemblyGenerator) GenerateComparatorAssembly(operator string, t types.Type, e1 ast.Expression, e2 ast.Expression) error {
+	instr := setInstructions[operator][0]
+	if IsUnsignedOperation(t) {
+		instr = setInstructions[operator][1]
+	}
 	err := g.FromExpression(e1)
 	if err != nil {
 		return err
 	}
-	g.AddLine("push", "%rax")
+	g.GenerateConversion(t)
+	g.Push("%rax")
 	err = g.FromExpression(e2)
 	if err != nil {
 		return err
 	}
-	g.AddLine("pop", "%rcx")
+	g.GenerateConversion(t)
+	g.Pop("%rcx")
 	g.AddLine("cmp", "%rax, %rcx", "/* Set ZF on if e1 == e2 otherwise off */")
 	g.AddLine("mov", "$0, %rax", "/* Reset rax, does not affect ZF */")
 	g.AddLine(instr, "%al", "/* Set the lower half of rax to 1 based on ZF */")
 	return nil
 }
 
+// GetModifiableVariable returns the variable named name if it can be written to
+func (g *AssemblyGenerator) GetModifiableVariable(name string) (*Variable, error) {
+	v, err := g.Variables.GetVariable(name)
+	if err != nil {
+		return nil, err
+	}
+	switch v.Kind {
+	case EnumConstant:
+		return nil, fmt.Errorf("Could not assign to enumerator '%s'", name)
+	case TypedefName:
+		return nil, fmt.Errorf("Could not assign to type '%s'", name)
+	case FunctionName:
+		return nil, fmt.Errorf("Could not assign to function '%s'", name)
+	}
+	if types.IsConst(v.Type) {
+		return nil, fmt.Errorf("Could not assign to read-only variable '%s'", name)
+	}
+	switch types.Unqualified(v.Type).(type) {
+	case *types.VaList:
+		return nil, fmt.Errorf("Could not assign to va_list '%s'", name)
+	case *types.Array, *types.Struct:
+		return nil, fmt.Errorf("Could not assign to '%s' of type '%s'", name, v.Type)
+	}
+	return v, nil
+}
+
+// CheckModifiable makes sure the object designated by e, of type t, can be written to
+func (g *AssemblyGenerator) CheckModifiable(e ast.Expression, t types.Type) error {
+	switch e := e.(type) {
+	case *ast.IndexExpression, *ast.MemberExpression:
+	case *ast.PrefixExpression:
+		if e.Operator != "*" {
+			return fmt.Errorf("Could not assign to %s", e.TokenLiteral())
+		}
+	default:
+		return fmt.Errorf("Could not assign to %s", e.TokenLiteral())
+	}
+	if types.IsConst(t) {
+		return errors.New("Could not assign to a read-only location")
+	}
+	switch types.Unqualified(t).(type) {
+	case *types.Pointer:
+		return nil
+	case *types.Array, *types.Struct, *types.Func, *types.VaList:
+		return fmt.Errorf("Could not assign to a location of type '%s'", t)
+	}
+	if !types.IsArithmetic(t) {
+		return fmt.Errorf("Could not assign to a location of type '%s'", t)
+	}
+	return nil
+}
+
 func (g *AssemblyGenerator) FromAssignExpression(e ast.AssignExpression) error {
-	err := g.FromExpression(e.Right)
+	id, ok := e.Left.(*ast.Identifier)
+	if !ok {
+		return g.GenerateLocationAssign(e)
+	}
+	v, err := g.GetModifiableVariable(id.Value)
+	if err != nil {
+		return err
+	}
+	err = g.CheckAssignOperands(e, v.Type)
 	if err != nil {
 		return err
 	}
-	stackIndex, err := g.Variables.GetVariableStackIndex(e.Left.Value)
+	err = g.FromExpression(e.Right)
 	if err != nil {
 		return err
 	}
+	if e.Operator != "=" {
+		rt, err := g.TypeOf(e.Right)
+		if err != nil {
+			return err
+		}
+		g.Push("%rax", "/* Stack the right operand */")
+		g.GenerateVariableLoad(v)
+		g.Pop("%rcx", "/* Move the right operand into RCX */")
+		err = g.GenerateCompoundOperator(e.Operator, v.Type, rt)
+		if err != nil {
+			return err
+		}
+	}
+	g.GenerateConversion(v.Type)
+	// Always move the result into the variable
+	g.GenerateVariableStore(v)
+	return nil
+}
+
+// GenerateLocationAssign stores a value into an object designated by an expression such as `p->x`
+// The address is computed first and kept on the stack while the right side is evaluated
+func (g *AssemblyGenerator) GenerateLocationAssign(e ast.AssignExpression) error {
+	t, err := g.TypeOf(e.Left)
+	if err != nil {
+		return err
+	}
+	err = g.CheckModifiable(e.Left, t)
+	if err != nil {
+		return err
+	}
+	err = g.CheckAssignOperands(e, t)
+	if err != nil {
+		return err
+	}
+	err = g.GenerateAddressOf(e.Left)
+	if err != nil {
+		return err
+	}
+	g.Push("%rax", "/* Keep the address of the assigned object */")
+	err = g.FromExpression(e.Right)
+	if err != nil {
+		return err
+	}
+	if e.Operator != "=" {
+		rt, err := g.TypeOf(e.Right)
+		if err != nil {
+			return err
+		}
+		g.AddLine("mov", "%rax, %rcx", "/* Move the right operand into RCX */")
+		g.AddLine("mov", "(%rsp), %rax", "/* Get the address of the assigned object */")
+		g.GenerateLoad(t)
+		err = g.GenerateCompoundOperator(e.Operator, t, rt)
+		if err != nil {
+			return err
+		}
+	}
+	g.GenerateConversion(t)
+	g.Pop("%rcx", "/* Get back the address of the assigned object */")
+	g.GenerateStoreTo(t, "(%rcx)")
+	return nil
+}
+
+// CheckAssignOperands makes sure the right side of an assignment to an object of type t is valid
+// Pointers can only be moved by an integer with "+=" and "-="
+func (g *AssemblyGenerator) CheckAssignOperands(e ast.AssignExpression, t types.Type) error {
 	switch e.Operator {
-	case "":
-		break
+	case "=":
+		return g.CheckAssignable(t, e.Right)
+	case "+=", "-=", "*=", "/=":
+	default:
+		return fmt.Errorf("Expected a valid assignment operator, got '%s'", e.Operator)
+	}
+	rt, err := g.TypeOf(e.Right)
+	if err != nil {
+		return err
+	}
+	if !types.IsArithmetic(rt) {
+		return fmt.Errorf("Invalid operand of type '%s' for '%s'", rt, e.Operator)
+	}
+	if _, ok := types.Unqualified(t).(*types.Pointer); ok {
+		if e.Operator != "+=" && e.Operator != "-=" {
+			return fmt.Errorf("Invalid operand of type '%s' for '%s'", t, e.Operator)
+		}
+		_, err := incDecStep(t)
+		return err
+	}
+	return nil
+}
+
+// GenerateCompoundOperator applies the operator of a compound assignment to an object of type t
+// RAX holds the value of the object and RCX the right operand of type rt, the result is left in RAX
+func (g *AssemblyGenerator) GenerateCompoundOperator(operator string, t, rt types.Type) error {
+	if p, ok := types.Unqualified(t).(*types.Pointer); ok {
+		g.AddLine("imul", fmt.Sprintf("$%d, %%rcx", p.Elem.Size()), "/* Scale the operand by the size of the pointed type */")
+	}
+	switch operator {
 	case "+=":
-		g.AddLine("add", fmt.Sprintf("%d(%%rbp), %%rax", stackIndex), "/* Add the expression result and the variable. add puch to RAX */")
-		break
+		g.AddLine("add", "%rcx, %rax", "/* Add the expression result and the variable */")
 	case "-=":
-		g.AddLine("push", "%rax", "/* Stack the value to subtract */")
-		g.AddLine("mov", fmt.Sprintf("%d(%%rbp), %%rax", stackIndex), "/* Move the variable into RAX */")
-		g.AddLine("pop", "%rcx", "/* Move the value to subtract into RCX */")
 		g.AddLine("sub", "%rcx, %rax", "/* Subtract RCX from the variable */")
-		break
 	case "*=":
-		g.AddLine("push", "%rax", "/* Stack the multiplier */")
-		g.AddLine("mov", fmt.Sprintf("%d(%%rbp), %%rax", stackIndex), "/* Move the variable into RAX */")
-		g.AddLine("pop", "%rcx", "/* Move the multiplier into RCX */")
 		g.AddLine("imul", "%rcx, %rax", "/* Multiply the var by the multipler */")
-		break
 	case "/=":
-		g.AddLine("push", "%rax", "/* Stack the divisor */")
-		g.AddLine("mov", fmt.Sprintf("%d(%%rbp), %%rax", stackIndex), "/* Move the variable into RAX */")
-		g.AddLine("cdq", "/* Expand RAX into RDX */")
-		g.AddLine("pop", "%rcx", "/* Move the divisor into RCX */")
-		g.AddLine("div", "%rcx", "/* Divide the var by the divisor in RAX:RDX */")
-		break
+		// Both operands are converted to their common type before the division
+		ct := types.Common(t, rt)
+		g.GenerateConversion(ct)
+		g.GenerateRegisterConversion(ct, "%rcx")
+		g.GenerateDivide(ct)
 	default:
-		fmt.Errorf("Expected a valid assignment operator, got '%s'", e.Operator)
+		return fmt.Errorf("Expected a valid assignment operator, got '%s'", operator)
 	}
-	// Always move the result into the variable
-	g.AddLine("mov", fmt.Sprintf("%%rax, %d(%%rbp)", stackIndex), "/* Move the result into the variable */")
+	return nil
+}
+
+// FromCommaExpression evaluates both sides, only the right value stays in RAX
+func (g *AssemblyGenerator) FromCommaExpression(e ast.CommaExpression) error {
+	err := g.FromExpression(e.Left)
+	if err != nil {
+		return err
+	}
+	return g.FromExpression(e.Right)
+}
+
+func (g *AssemblyGenerator) FromCastExpression(e ast.CastExpression) error {
+	err := g.FromExpression(e.Expression)
+	if err != nil {
+		return err
+	}
+	t, err := g.ResolveType(e.Type)
+	if err != nil {
+		return err
+	}
+	g.GenerateConversion(t)
+	return nil
+}
+
+// FromSizeofExpression folds the size at compile time, the operand is never evaluated
+func (g *AssemblyGenerator) FromSizeofExpression(e ast.SizeofExpression) error {
+	var t types.Type
+	var err error
+	if e.Expression != nil {
+		t, err = g.TypeOf(e.Expression)
+	} else {
+		t, err = g.ResolveType(e.Type)
+	}
+	if err != nil {
+		return err
+	}
+	g.AddLine("mov", fmt.Sprintf("$%d, %%rax", t.Size()), "/* Push the size computed at compile time to RAX */")
 	return nil
 }
 
 func (g *AssemblyGenerator) FromIdentifier(i ast.Identifier) error {
-	stackIndex, err := g.Variables.GetVariableStackIndex(i.Value)
+	v, err := g.Variables.GetVariable(i.Value)
 	if err != nil {
 		return err
 	}
-	g.AddLine("mov", fmt.Sprintf("%d(%%rbp), %%rax", stackIndex), "/* Move the variable into the rax register */")
+	switch v.Kind {
+	case EnumConstant:
+		g.AddLine("mov", fmt.Sprintf("$%d, %%rax", v.Value), "/* Push the enumerator value to the RAX register */")
+		return nil
+	case TypedefName:
+		return fmt.Errorf("Unexpected type name '%s' in expression", i.Value)
+	case FunctionName:
+		g.AddLine("lea", fmt.Sprintf("%s(%%rip), %%rax", v.Label), "/* Move the address of the function into RAX */")
+		return nil
+	}
+	switch types.Unqualified(v.Type).(type) {
+	case *types.Array, *types.Struct, *types.VaList:
+		// Arrays decay to the address of their first element, a va_list is an array
+		return g.GenerateAddressOf(&i)
+	}
+	g.GenerateVariableLoad(v)
 	return nil
 }
 
 // GenerateFromInfixExpression outputs assembly for a InfixExpression node
 func (g *AssemblyGenerator) FromInfixExpression(e ast.InfixExpression) error {
 	l, r := e.Left, e.Right
+	if e.Operator == "+" || e.Operator == "-" {
+		isPointer, err := g.IsPointerArithmetic(e)
+		if err != nil {
+			return err
+		}
+		if isPointer {
+			return g.GeneratePointerArithmetic(e)
+		}
+	}
+	t, err := g.OperandType(e)
+	if err != nil {
+		return err
+	}
 	switch e.Operator {
 	case "+":
-		return g.GenerateAddAssembly(l, r)
+		err = g.GenerateAddAssembly(l, r)
 	case "*":
-		return g.GenerateMultAssembly(l, r)
+		err = g.GenerateMultAssembly(l, r)
 	case "-":
-		return g.GenerateSubAssembly(l, r)
+		err = g.GenerateSubAssembly(l, r)
 	case "/":
-		return g.GenerateDivAssembly(l, r)
+		err = g.GenerateDivAssembly(t, l, r)
 	case "%":
-		return g.GenerateModuloAssembly(l, r)
-	case "==":
-		return g.GenerateComparatorAssembly("sete", l, r)
-	case "!=":
-		return g.GenerateComparatorAssembly("setne", l, r)
-	case ">":
-		return g.GenerateComparatorAssembly("setg", l, r)
-	case ">=":
-		return g.GenerateComparatorAssembly("setge", l, r)
-	case "<":
-		return g.GenerateComparatorAssembly("setl", l, r)
-	case "<=":
-		return g.GenerateComparatorAssembly("setle", l, r)
+		err = g.GenerateModuloAssembly(t, l, r)
+	case "==", "!=", ">", ">=", "<", "<=":
+		return g.GenerateComparatorAssembly(e.Operator, t, l, r)
 	case "&&":
 		return g.GenerateLogicalAndAssembly(l, r)
 	case "||":
@@ -256,13 +936,18 @@ func (g *AssemblyGenerator) FromInfixExpression(e ast.InfixExpression) error {
 	default:
 		return fmt.Errorf("Unsupported infix operation with operator '%s'", e.Operator)
 	}
+	if err != nil {
+		return err
+	}
+	// The result is a value of the common type of the operands
+	g.GenerateConversion(t)
+	return nil
 }
 
 func (g *AssemblyGenerator) FromExpression(e ast.Expression) error {
 	switch e := e.(type) {
 	case *ast.IntegerLiteral:
-		g.FromIntegerLiteral(*e)
-		return nil
+		return g.FromIntegerLiteral(*e)
 	case *ast.PrefixExpression:
 		return g.FromPrefixExpression(*e)
 	case *ast.InfixExpression:
@@ -271,6 +956,22 @@ func (g *AssemblyGenerator) FromExpression(e ast.Expression) error {
 		return g.FromAssignExpression(*e)
 	case *ast.Identifier:
 		return g.FromIdentifier(*e)
+	case *ast.CommaExpression:
+		return g.FromCommaExpression(*e)
+	case *ast.CastExpression:
+		return g.FromCastExpression(*e)
+	case *ast.SizeofExpression:
+		return g.FromSizeofExpression(*e)
+	case *ast.CallExpression:
+		return g.FromCallExpression(*e)
+	case *ast.PostfixExpression:
+		return g.FromPostfixExpression(*e)
+	case *ast.BuiltinExpression:
+		return g.FromBuiltinExpression(*e)
+	case *ast.IndexExpression:
+		return g.FromIndexExpression(*e)
+	case *ast.MemberExpression:
+		return g.FromMemberExpression(*e)
 	default:
 		return fmt.Errorf("Failed with %s", e.TokenLiteral())
 	}
@@ -2,7 +2,9 @@ package generator
 
 import (
 	"compiler/ast"
+	"compiler/types"
 	"fmt"
+	"strings"
 )
 
 type AssemblyGenerator struct {
@@ -10,6 +12,18 @@ type AssemblyGenerator struct {
 	Variables      *VariableManager
 	Lines          [][]string
 	Depth          int
+	// ReturnType is the return type of the function being generated
+	ReturnType types.Type
+	// VarArgs locates the variadic arguments, it is nil unless the function being generated is variadic
+	VarArgs *VarArgs
+	// Statics lists the variables with static storage duration to emit once the functions are generated
+	Statics []*StaticData
+	// StackDepth is the number of bytes pushed on top of the current frame
+	StackDepth int
+	// Warnings lists the diagnostics that do not stop the compilation
+	Warnings []string
+	// groupStructs holds the anonymous structs of the declaration group being generated
+	groupStructs map[string]*types.Struct
 }
 
 func NewAssemblyGenerator() *AssemblyGenerator {
@@ -25,6 +39,23 @@ func (g *AssemblyGenerator) AddLine(els ...string) {
 	g.Lines = append(g.Lines, lines)
 }
 
+// Push adds a push instruction and keeps track of the stack depth
+func (g *AssemblyGenerator) Push(els ...string) {
+	g.AddLine(append([]string{"push"}, els...)...)
+	g.StackDepth += 8
+}
+
+// Pop adds a pop instruction and keeps track of the stack depth
+func (g *AssemblyGenerator) Pop(els ...string) {
+	g.AddLine(append([]string{"pop"}, els...)...)
+	g.StackDepth -= 8
+}
+
+// Warn records a diagnostic that does not stop the compilation
+func (g *AssemblyGenerator) Warn(format string, a ...interface{}) {
+	g.Warnings = append(g.Warnings, fmt.Sprintf(format, a...))
+}
+
 func (g *AssemblyGenerator) EnterContext() {
 	g.Depth++
 }
@@ -65,34 +96,194 @@ func (g *AssemblyGenerator) GetString() string {
 }
 
 func (g *AssemblyGenerator) FromProgram(p *ast.Program) (string, error) {
+	// File scope declarations come first so every function can see them
+	for _, stmt := range p.Statements {
+		err := g.FromStatement(stmt)
+		if err != nil {
+			return "", err
+		}
+	}
+	// Functions can be called before their definition
+	for _, fn := range p.Functions {
+		f, ok := fn.(*ast.FunctionStatement)
+		if !ok {
+			continue
+		}
+		err := g.DeclareFunction(*f)
+		if err != nil {
+			return "", err
+		}
+	}
 	for _, fn := range p.Functions {
 		err := g.FromStatement(fn)
 		if err != nil {
 			return "", err
 		}
 	}
+	err := g.GenerateStaticData()
+	if err != nil {
+		return "", err
+	}
 	return g.GetString(), nil
 }
 
+// FunctionType returns the type of a function from its declaration
+func (g *AssemblyGenerator) FunctionType(f ast.FunctionStatement) (*types.Func, error) {
+	returnType, err := g.ResolveType(f.Return)
+	if err != nil {
+		return nil, err
+	}
+	// A definition written with "()" gives the function no parameters
+	fn := &types.Func{Return: returnType, Variadic: f.Variadic, Unprototyped: f.Unprototyped && f.Body == nil}
+	for _, arg := range f.Parameters {
+		t, err := g.ResolveType(arg.Type)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := types.Unqualified(t).(*types.Struct); ok {
+			return nil, fmt.Errorf("Parameter '%s' of '%s': passing a struct by value is not supported", arg.Arg, f.Name)
+		}
+		// Qualifiers of the parameters only matter inside the function
+		fn.Params = append(fn.Params, types.Decay(t))
+	}
+	switch types.Unqualified(returnType).(type) {
+	case *types.Struct:
+		return nil, fmt.Errorf("Function '%s' returns a struct by value, which is not supported", f.Name)
+	case *types.Array:
+		return nil, fmt.Errorf("Function '%s' cannot return an array", f.Name)
+	}
+	return fn, nil
+}
+
+// DeclareFunction records the function in the current scope
+func (g *AssemblyGenerator) DeclareFunction(f ast.FunctionStatement) error {
+	fn, err := g.FunctionType(f)
+	if err != nil {
+		return err
+	}
+	_, err = g.DeclareFunctionName(f.Name, fn, f.Storage)
+	return err
+}
+
+// DeclareFunctionName records a function declared with the given storage class
+// Functions declared static have internal linkage, others get the linkage of a previous declaration
+func (g *AssemblyGenerator) DeclareFunctionName(name string, fn *types.Func, storage string) (*Variable, error) {
+	linkage := g.Variables.GetLinkage(name)
+	switch storage {
+	case "static":
+		if !g.Variables.IsFileScope() {
+			return nil, fmt.Errorf("Invalid storage class for function '%s'", name)
+		}
+		linkage = InternalLinkage
+	case "", "extern":
+	default:
+		return nil, fmt.Errorf("Invalid storage class '%s' for function '%s'", storage, name)
+	}
+	if !g.Variables.IsFileScope() {
+		err := g.CheckFileScopeDeclaration(name, FunctionName, fn)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return g.Variables.CreateFunction(name, fn, linkage)
+}
+
 func (g *AssemblyGenerator) FromFunction(f ast.FunctionStatement) error {
-	g.AddLine(fmt.Sprintf(".globl %s", f.Name))
-	g.AddLine(fmt.Sprintf("%s:", f.Name))
+	if f.Body == nil {
+		// Prototypes were declared before generating the functions
+		return nil
+	}
+	fn, err := g.FunctionType(f)
+	if err != nil {
+		return err
+	}
+	v, err := g.Variables.GetVariable(f.Name)
+	if err != nil {
+		return err
+	}
+	if v.Defined {
+		return fmt.Errorf("Redefinition of '%s'", f.Name)
+	}
+	v.Defined = true
+	g.ReturnType = fn.Return
+	g.Variables.StackIndex = -8
+	g.StackDepth = 0
+	// Functions with internal linkage stay out of the global symbol table
+	if v.Linkage == ExternalLinkage {
+		g.AddLine(fmt.Sprintf(".globl %s", v.Label))
+	}
+	g.AddLine(fmt.Sprintf("%s:", v.Label))
 	g.EnterContext()
 	g.AddLine("pushq", "%rbp", "/* Save value of the bottom of the current frame */")
 	g.AddLine("movq", "%rsp, %rbp", "/* Top of stack is now bottom of new frame */")
-	err := g.FromStatement(f.Body)
+	// The size of the frame is only known once the body was generated
+	frameLine := len(g.Lines)
+	g.AddLine("subq", "$0, %rsp", "/* Reserve the stack space for the local variables */")
+	g.VarArgs = nil
+	if f.Variadic {
+		g.GenerateRegisterSaveArea(f)
+	}
+	g.Variables.EnterScope()
+	defer g.Variables.LeaveScope()
+	for i, arg := range f.Parameters {
+		if arg.Arg == "" {
+			g.LeaveContext()
+			return fmt.Errorf("Parameter %d of '%s' has no name", i+1, f.Name)
+		}
+		// The parameter keeps its qualifiers inside the function, arrays are adjusted to pointers
+		t, err := g.ResolveType(arg.Type)
+		if err != nil {
+			g.LeaveContext()
+			return err
+		}
+		switch types.Unqualified(t).(type) {
+		case *types.Array, *types.Func, *types.VaList:
+			t = types.Decay(t)
+		}
+		if i >= len(argumentRegisters) {
+			// Stack arguments are above the return address and the saved RBP
+			err = g.Variables.CreateParameter(arg.Arg, t, 16+8*(i-len(argumentRegisters)))
+		} else {
+			err = g.Variables.CreateVariable(arg.Arg, t)
+		}
+		if err != nil {
+			g.LeaveContext()
+			return err
+		}
+		// The bits of an argument beyond the size of its type are not defined by the ABI,
+		// locals are read as 64 bits values so the parameter is extended first
+		if i < len(argumentRegisters) {
+			stackIndex, _ := g.Variables.GetVariableStackIndex(arg.Arg)
+			g.GenerateRegisterConversion(t, argumentRegisters[i])
+			g.AddLine("mov", fmt.Sprintf("%s, %d(%%rbp)", argumentRegisters[i], stackIndex), "/* Save the parameter to its stack slot */")
+		} else if types.IsArithmetic(t) && t.Size() < 8 {
+			slot := fmt.Sprintf("%d(%%rbp)", 16+8*(i-len(argumentRegisters)))
+			g.GenerateLoadFrom(t, slot)
+			g.AddLine("mov", "%rax, "+slot, "/* Extend the stack parameter in its slot */")
+		}
+	}
+	err = g.FromStatement(f.Body)
 	g.LeaveContext()
 	if err != nil {
 		return err
 	}
+	// Keep the stack 16 bytes aligned
+	frameSize := (-(g.Variables.StackIndex + 8) + 15) &^ 15
+	line := g.Lines[frameLine]
+	line[len(line)-2] = fmt.Sprintf("$%d, %%rsp", frameSize)
 	return nil
 }
 
 func (g *AssemblyGenerator) FromReturnStatement(r ast.ReturnStatement) error {
-	err := g.FromExpression(r.ReturnValue)
+	err := g.CheckAssignable(g.ReturnType, r.ReturnValue)
+	if err != nil {
+		return err
+	}
+	err = g.FromExpression(r.ReturnValue)
 	if err != nil {
 		return err
 	}
+	g.GenerateConversion(g.ReturnType)
 	g.AddLine("movq", "%rbp, %rsp", "/* restore esp now it points to the old ebp */")
 	g.AddLine("popq", "%rbp", "/* restore old ebp, esp is now where it was before */")
 	g.AddLine("ret")
@@ -100,6 +291,8 @@ func (g *AssemblyGenerator) FromReturnStatement(r ast.ReturnStatement) error {
 }
 
 func (g *AssemblyGenerator) FromBlockStatement(block ast.BlockStatement) error {
+	g.Variables.EnterScope()
+	defer g.Variables.LeaveScope()
 	for _, stmt := range block.Statements {
 		err := g.FromStatement(stmt)
 		if err != nil {
@@ -110,16 +303,187 @@ func (g *AssemblyGenerator) FromBlockStatement(block ast.BlockStatement) error {
 }
 
 func (g *AssemblyGenerator) FromDeclStatement(s ast.DeclStatement) error {
-	if s.Right != nil {
-		err := g.FromExpression(s.Right)
+	t, err := g.ResolveType(s.Type)
+	if err != nil {
+		return err
+	}
+	if fn, ok := t.(*types.Func); ok {
+		if s.Right != nil {
+			return fmt.Errorf("Function '%s' is initialized like a variable", s.Left.Value)
+		}
+		_, err := g.DeclareFunctionName(s.Left.Value, fn, s.Storage)
+		return err
+	}
+	if a, ok := t.(*types.Array); ok && a.Len < 0 && s.Right != nil {
+		// The length of the array is given by its initializer
+		_, t, err = g.FlattenInitializer(t, s.Right)
 		if err != nil {
 			return err
 		}
+	}
+	if g.Variables.IsFileScope() || s.Storage != "" {
+		return g.DeclareStatic(s, t)
+	}
+	if types.IsAggregate(t) {
+		return g.DeclareLocalAggregate(s.Left.Value, t, s.Right)
+	}
+	right := s.Right
+	if _, ok := right.(*ast.InitializerList); ok {
+		// A scalar can be initialized by a single expression in braces
+		elements, _, err := g.FlattenInitializer(t, right)
+		if err != nil {
+			return err
+		}
+		right = nil
+		if len(elements) != 0 {
+			right = elements[0].Value
+		}
+	}
+	if right != nil {
+		err := g.CheckAssignable(t, right)
+		if err != nil {
+			return err
+		}
+		err = g.FromExpression(right)
+		if err != nil {
+			return err
+		}
+		g.GenerateConversion(t)
 	} else {
 		g.AddLine("mov", "$0, %rax", "/* default variable value */")
 	}
-	g.AddLine("push", "%rax", "/* Save variable value to stack */")
-	return g.Variables.CreateVariable(s.Left.Value)
+	err = g.Variables.CreateVariable(s.Left.Value, t)
+	if err != nil {
+		return err
+	}
+	stackIndex, err := g.Variables.GetVariableStackIndex(s.Left.Value)
+	if err != nil {
+		return err
+	}
+	g.AddLine("mov", fmt.Sprintf("%%rax, %d(%%rbp)", stackIndex), "/* Save variable value to its stack slot */")
+	return nil
+}
+
+// ResolveType returns the type for a type of the AST, looking up tags and typedef names in the current scope
+// and computing the lengths of arrays
+// A struct tag used before its definition declares an incomplete struct in the current scope
+func (g *AssemblyGenerator) ResolveType(t types.Type) (types.Type, error) {
+	return types.Resolve(t, &types.Scope{Lookup: g.lookupType, Eval: g.evalLength, Structs: g.groupStructs})
+}
+
+// lookupType returns the type named by a typedef name, "enum Tag" or "struct Tag" in the current scope
+func (g *AssemblyGenerator) lookupType(name string) (types.Type, error) {
+	if strings.HasPrefix(name, "enum ") {
+		tag := strings.TrimPrefix(name, "enum ")
+		t := g.Variables.GetTag(tag)
+		if t == nil {
+			return nil, fmt.Errorf("Undeclared '%s'", name)
+		}
+		if _, ok := t.(*types.Enum); !ok {
+			return nil, fmt.Errorf("'%s' defined as wrong kind of tag", tag)
+		}
+		return t, nil
+	}
+	if strings.HasPrefix(name, "struct ") {
+		tag := strings.TrimPrefix(name, "struct ")
+		t := g.Variables.GetTag(tag)
+		if t == nil {
+			t = &types.Struct{Tag: tag}
+			return t, g.Variables.CreateTag(tag, t)
+		}
+		if _, ok := t.(*types.Struct); !ok {
+			return nil, fmt.Errorf("'%s' defined as wrong kind of tag", tag)
+		}
+		return t, nil
+	}
+	return g.Variables.GetTypedef(name)
+}
+
+// evalLength computes the length of an array written as an expression
+func (g *AssemblyGenerator) evalLength(e types.Expr) (int64, error) {
+	x, ok := e.(ast.Expression)
+	if !ok {
+		return 0, fmt.Errorf("'%s' is not an expression", e)
+	}
+	return g.EvalConstant(x)
+}
+
+// FromStructStatement lays out the members of a struct, no code is generated
+// The tag is declared before the members are resolved so they can point to the struct itself
+func (g *AssemblyGenerator) FromStructStatement(s ast.StructStatement) error {
+	var st *types.Struct
+	if t := g.Variables.GetScopeTag(s.Name); t != nil {
+		var ok bool
+		if st, ok = t.(*types.Struct); !ok {
+			return fmt.Errorf("'%s' defined as wrong kind of tag", s.Name)
+		}
+		if st.Defined {
+			return fmt.Errorf("Redefinition of '%s'", st)
+		}
+	} else {
+		st = &types.Struct{Tag: s.Name}
+		err := g.Variables.CreateTag(s.Name, st)
+		if err != nil {
+			return err
+		}
+	}
+	fields := make([]types.Field, len(s.Fields))
+	for i, f := range s.Fields {
+		t, err := g.ResolveType(f.Type)
+		if err != nil {
+			return err
+		}
+		fields[i] = types.Field{Name: f.Name, Type: t}
+	}
+	return st.Define(fields)
+}
+
+func (g *AssemblyGenerator) FromTypedefStatement(s ast.TypedefStatement) error {
+	t, err := g.ResolveType(s.Type)
+	if err != nil {
+		return err
+	}
+	return g.Variables.CreateTypedef(s.Name, t)
+}
+
+// FromEnumStatement records the enumerators as constants, no code is generated
+func (g *AssemblyGenerator) FromEnumStatement(s ast.EnumStatement) error {
+	if s.Name != "" {
+		err := g.Variables.CreateTag(s.Name, &types.Enum{Tag: s.Name})
+		if err != nil {
+			return err
+		}
+	}
+	value := int64(0)
+	for _, e := range s.Enumerators {
+		if e.Value != nil {
+			v, err := g.EvalConstant(e.Value)
+			if err != nil {
+				return err
+			}
+			value = v
+		}
+		err := g.Variables.CreateConstant(e.Name, types.Int, value)
+		if err != nil {
+			return err
+		}
+		value++
+	}
+	return nil
+}
+
+// FromDeclGroup declares the declarators of a declaration in order
+// The declarators share their anonymous structs, so `struct { int x; } s, *p = &s;` is valid
+func (g *AssemblyGenerator) FromDeclGroup(s ast.DeclGroup) error {
+	g.groupStructs = map[string]*types.Struct{}
+	defer func() { g.groupStructs = nil }()
+	for _, decl := range s.Decls {
+		err := g.FromStatement(decl)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (g *AssemblyGenerator) FromExpStatement(s ast.ExpStatement) error {
@@ -176,10 +540,20 @@ func (g *AssemblyGenerator) FromStatement(s ast.Statement) error {
 		return g.FromFunction(*s)
 	case *ast.DeclStatement:
 		return g.FromDeclStatement(*s)
+	case *ast.DeclGroup:
+		return g.FromDeclGroup(*s)
+	case *ast.EnumStatement:
+		return g.FromEnumStatement(*s)
+	case *ast.StructStatement:
+		return g.FromStructStatement(*s)
+	case *ast.TypedefStatement:
+		return g.FromTypedefStatement(*s)
 	case *ast.ExpStatement:
 		return g.FromExpStatement(*s)
 	case *ast.IfStatement:
 		return g.FromIfStatement(*s)
+	case *ast.AsmStatement:
+		return g.FromAsmStatement(*s)
 	default:
 		return fmt.Errorf("Failed with %s", s.TokenLiteral())
 	}
@@ -0,0 +1,42 @@
+package generator_test
+
+import (
+	"compiler/generator"
+	"compiler/lexer"
+	"compiler/parser"
+	"compiler/source"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestCorpus generates the assembly of the sources of parser/testdata and files
+func TestCorpus(t *testing.T) {
+	paths := make([]string, 0)
+	for _, pattern := range []string{"../parser/testdata/*.c", "../files/*.c"} {
+		matches, err := filepath.Glob(filepath.FromSlash(pattern))
+		if err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, matches...)
+	}
+	if len(paths) == 0 {
+		t.Fatal("No source in the corpus")
+	}
+	for _, path := range paths {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			f, err := os.Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			program, err := parser.NewParser(lexer.NewFileLexer(f, source.NewFileSet().AddFile(path, -1))).ParseProgram()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := generator.NewAssemblyGenerator().FromProgram(program); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
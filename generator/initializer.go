@@ -0,0 +1,228 @@
+package generator
+
+import (
+	"compiler/ast"
+	"compiler/types"
+	"errors"
+	"fmt"
+)
+
+// InitElement is a scalar member of an object and the expression it is initialized with
+// Offset is the position of the member in bytes from the start of the object
+type InitElement struct {
+	Offset int
+	Type   types.Type
+	Value  ast.Expression
+}
+
+// initCursor walks the elements of a brace enclosed initializer, the elements are copied
+// so the designators can be consumed while the members they select are reached
+type initCursor struct {
+	elements []ast.Initializer
+	pos      int
+}
+
+func newInitCursor(list *ast.InitializerList) *initCursor {
+	return &initCursor{elements: append([]ast.Initializer{}, list.Elements...)}
+}
+
+// FlattenInitializer lists the scalar members of an object of type t set by its initializer,
+// the members left out are zero initialized
+// The type is returned with the length of an array declared without one set from the initializer
+func (g *AssemblyGenerator) FlattenInitializer(t types.Type, init ast.Expression) ([]InitElement, types.Type, error) {
+	elements := make([]InitElement, 0)
+	if a, ok := t.(*types.Array); ok && a.Len < 0 {
+		list, ok := init.(*ast.InitializerList)
+		if !ok {
+			return nil, nil, fmt.Errorf("Invalid initializer for a value of type '%s', expected '{'", t)
+		}
+		length, err := g.initAggregate(t, newInitCursor(list), 0, &elements, true)
+		if err != nil {
+			return nil, nil, err
+		}
+		if length == 0 {
+			return nil, nil, errors.New("Zero size array initialized with '{}'")
+		}
+		return elements, &types.Array{Elem: a.Elem, Len: length}, nil
+	}
+	err := g.initObject(t, init, 0, &elements)
+	if err != nil {
+		return nil, nil, err
+	}
+	return elements, t, nil
+}
+
+// initObject initializes an object of type t from a single initializer
+func (g *AssemblyGenerator) initObject(t types.Type, init ast.Expression, offset int, out *[]InitElement) error {
+	list, isList := init.(*ast.InitializerList)
+	if types.IsAggregate(t) {
+		if !isList {
+			return fmt.Errorf("Invalid initializer for a value of type '%s', expected '{'", t)
+		}
+		_, err := g.initAggregate(t, newInitCursor(list), offset, out, true)
+		return err
+	}
+	if !isList {
+		*out = append(*out, InitElement{Offset: offset, Type: t, Value: init})
+		return nil
+	}
+	switch {
+	case len(list.Elements) == 0:
+		return nil
+	case len(list.Elements) > 1:
+		return fmt.Errorf("Excess elements in initializer of '%s'", t)
+	case len(list.Elements[0].Designators) != 0:
+		return fmt.Errorf("Designator in initializer of '%s'", t)
+	}
+	return g.initObject(t, list.Elements[0].Value, offset, out)
+}
+
+// initAggregate initializes the members of an array or struct in order from the elements of c
+// and returns the number of array elements initialized
+// braced is false when the braces of the aggregate were left out, it then stops once it is full
+// or at the next designator since designators always refer to the innermost braces
+func (g *AssemblyGenerator) initAggregate(t types.Type, c *initCursor, offset int, out *[]InitElement, braced bool) (int, error) {
+	count := memberCount(t)
+	next, length := 0, 0
+	for first := true; c.pos < len(c.elements); first = false {
+		element := &c.elements[c.pos]
+		if len(element.Designators) != 0 {
+			if !braced && !first {
+				break
+			}
+			var err error
+			next, err = g.designatedMember(t, element.Designators[0])
+			if err != nil {
+				return 0, err
+			}
+			element.Designators = element.Designators[1:]
+		} else if count >= 0 && next >= count {
+			if !braced {
+				break
+			}
+			return 0, fmt.Errorf("Excess elements in initializer of '%s'", t)
+		}
+		mt, moffset := memberAt(t, next)
+		err := g.initMember(mt, c, offset+moffset, out)
+		if err != nil {
+			return 0, err
+		}
+		next++
+		if next > length {
+			length = next
+		}
+	}
+	return length, nil
+}
+
+// initMember initializes a member of type t from the current element of c
+// An aggregate member without braces takes as many elements as it has members
+func (g *AssemblyGenerator) initMember(t types.Type, c *initCursor, offset int, out *[]InitElement) error {
+	element := c.elements[c.pos]
+	_, isList := element.Value.(*ast.InitializerList)
+	if types.IsAggregate(t) && (!isList || len(element.Designators) != 0) {
+		_, err := g.initAggregate(t, c, offset, out, false)
+		return err
+	}
+	if len(element.Designators) != 0 {
+		return fmt.Errorf("Designator in initializer of '%s'", t)
+	}
+	c.pos++
+	return g.initObject(t, element.Value, offset, out)
+}
+
+// designatedMember returns the position of the member of t selected by a designator
+func (g *AssemblyGenerator) designatedMember(t types.Type, d ast.Designator) (int, error) {
+	switch t := types.Unqualified(t).(type) {
+	case *types.Array:
+		if d.Index == nil {
+			return 0, fmt.Errorf("Member designator '.%s' in initializer of '%s'", d.Field, t)
+		}
+		i, err := g.EvalConstant(d.Index)
+		if err != nil {
+			return 0, fmt.Errorf("Array index in initializer is not an integer constant: %s", err)
+		}
+		if i < 0 || (t.Len >= 0 && i >= int64(t.Len)) {
+			return 0, fmt.Errorf("Array index %d in initializer exceeds the bounds of '%s'", i, t)
+		}
+		return int(i), nil
+	case *types.Struct:
+		if d.Index != nil {
+			return 0, fmt.Errorf("Array index in initializer of '%s'", t)
+		}
+		for i, f := range t.Fields {
+			if f.Name == d.Field {
+				return i, nil
+			}
+		}
+		return 0, fmt.Errorf("'%s' has no member named '%s'", t, d.Field)
+	}
+	return 0, fmt.Errorf("Designator in initializer of '%s'", t)
+}
+
+// memberCount returns the number of members of an aggregate, -1 for an array of unknown length
+func memberCount(t types.Type) int {
+	switch t := types.Unqualified(t).(type) {
+	case *types.Array:
+		return t.Len
+	case *types.Struct:
+		return len(t.Fields)
+	}
+	return 0
+}
+
+// memberAt returns the type and the offset of the i-th member of an aggregate
+// The members of a qualified aggregate have the same qualifiers
+func memberAt(t types.Type, i int) (types.Type, int) {
+	isConst, isVolatile := types.IsConst(t), types.IsVolatile(t)
+	switch u := types.Unqualified(t).(type) {
+	case *types.Array:
+		return types.Qualify(u.Elem, isConst, isVolatile), i * u.Elem.Size()
+	case *types.Struct:
+		f := u.Fields[i]
+		return types.Qualify(f.Type, isConst, isVolatile), f.Offset
+	}
+	return t, 0
+}
+
+// DeclareLocalAggregate reserves the stack space of a local array or struct and runs its initializer
+// The whole object is zeroed first then every initialized member is stored on its own
+func (g *AssemblyGenerator) DeclareLocalAggregate(name string, t types.Type, init ast.Expression) error {
+	var elements []InitElement
+	if init != nil {
+		var err error
+		elements, _, err = g.FlattenInitializer(t, init)
+		if err != nil {
+			return err
+		}
+	}
+	// The variable is visible in its own initializer
+	err := g.Variables.CreateVariable(name, t)
+	if err != nil {
+		return err
+	}
+	if init == nil {
+		return nil
+	}
+	stackIndex, err := g.Variables.GetVariableStackIndex(name)
+	if err != nil {
+		return err
+	}
+	g.AddLine("lea", fmt.Sprintf("%d(%%rbp), %%rdi", stackIndex), "/* Address of the variable to zero */")
+	g.AddLine("mov", fmt.Sprintf("$%d, %%rcx", (t.Size()+7)/8), "/* Number of quad words to zero */")
+	g.AddLine("mov", "$0, %rax", "/* Value to store */")
+	g.AddLine("rep stosq", "", "/* Zero the whole variable */")
+	for _, e := range elements {
+		err := g.CheckAssignable(e.Type, e.Value)
+		if err != nil {
+			return err
+		}
+		err = g.FromExpression(e.Value)
+		if err != nil {
+			return err
+		}
+		g.GenerateConversion(e.Type)
+		g.GenerateStoreTo(e.Type, fmt.Sprintf("%d(%%rbp)", stackIndex+e.Offset))
+	}
+	return nil
+}
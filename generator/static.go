@@ -0,0 +1,262 @@
+package generator
+
+import (
+	"compiler/ast"
+	"compiler/types"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// StaticData is the storage of a variable with static storage duration
+// File scope initializers are only evaluated once every function was declared
+type StaticData struct {
+	Variable *Variable
+	Init     ast.Expression
+	// Values holds the evaluated initializer, it stays nil until Init is evaluated
+	Values []StaticValue
+}
+
+// StaticValue is the value of a scalar member of a static variable
+type StaticValue struct {
+	Offset int
+	Size   int
+	Value  string
+}
+
+// DeclareStatic records a file scope variable, a static local or an extern declaration
+// Static locals get a unique label so they do not clash with other functions
+func (g *AssemblyGenerator) DeclareStatic(s ast.DeclStatement, t types.Type) error {
+	name := s.Left.Value
+	fileScope := g.Variables.IsFileScope()
+	label := name
+	var linkage Linkage
+	switch s.Storage {
+	case "extern":
+		if s.Right != nil && !fileScope {
+			return fmt.Errorf("'%s' has both 'extern' and an initializer", name)
+		}
+		linkage = g.Variables.GetLinkage(name)
+		if !fileScope {
+			err := g.CheckFileScopeDeclaration(name, StaticVariable, t)
+			if err != nil {
+				return err
+			}
+		}
+	case "static":
+		linkage = InternalLinkage
+		if !fileScope {
+			linkage = NoLinkage
+			label = g.LabelGenerator.GetNextLabel(name + ".")
+		}
+	case "":
+		linkage = ExternalLinkage
+	default:
+		return fmt.Errorf("Invalid storage class '%s' for '%s'", s.Storage, name)
+	}
+	if s.Storage != "extern" && !types.IsComplete(t) {
+		return fmt.Errorf("Storage size of '%s' isn't known", name)
+	}
+	v, err := g.Variables.CreateStatic(name, t, linkage, label)
+	if err != nil {
+		return err
+	}
+	// An extern declaration without initializer is resolved at link time
+	if s.Storage == "extern" && s.Right == nil {
+		return nil
+	}
+	var data *StaticData
+	for _, d := range g.Statics {
+		if d.Variable == v {
+			data = d
+		}
+	}
+	if data == nil {
+		data = &StaticData{Variable: v}
+		g.Statics = append(g.Statics, data)
+	}
+	if s.Right == nil {
+		return nil
+	}
+	if data.Init != nil {
+		return fmt.Errorf("Redefinition of '%s'", name)
+	}
+	data.Init = s.Right
+	if !fileScope {
+		// The block scope is gone by the time the data is generated
+		data.Values, err = g.StaticValues(t, s.Right)
+		return err
+	}
+	return nil
+}
+
+// CheckFileScopeDeclaration makes sure a block scope declaration with linkage
+// agrees with the file scope declaration of the same name, if any
+func (g *AssemblyGenerator) CheckFileScopeDeclaration(name string, kind VariableKind, t types.Type) error {
+	v, ok := g.Variables.Scopes[0].Variables[name]
+	if !ok {
+		return nil
+	}
+	if v.Kind != kind || !types.Compatible(v.Type, t) {
+		return fmt.Errorf("Conflicting types for '%s'", name)
+	}
+	return nil
+}
+
+// StaticValues evaluates the initializer of a variable with static storage duration
+// The values are sorted by offset, a member initialized twice keeps its last value
+func (g *AssemblyGenerator) StaticValues(t types.Type, init ast.Expression) ([]StaticValue, error) {
+	elements, _, err := g.FlattenInitializer(t, init)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]StaticValue, 0, len(elements))
+	for _, e := range elements {
+		value, err := g.StaticInitializer(e.Type, e.Value)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, StaticValue{Offset: e.Offset, Size: e.Type.Size(), Value: value})
+	}
+	sort.SliceStable(values, func(i, j int) bool { return values[i].Offset < values[j].Offset })
+	unique := make([]StaticValue, 0, len(values))
+	for i, v := range values {
+		if i+1 < len(values) && values[i+1].Offset == v.Offset {
+			continue
+		}
+		unique = append(unique, v)
+	}
+	return unique, nil
+}
+
+// StaticInitializer returns the value of the initializer of a variable with static storage duration
+// It has to be a constant expression, or the address of a function or of a static variable
+func (g *AssemblyGenerator) StaticInitializer(t types.Type, e ast.Expression) (string, error) {
+	err := g.CheckAssignable(t, e)
+	if err != nil {
+		return "", err
+	}
+	if v, err := g.EvalConstant(e); err == nil {
+		return strconv.FormatInt(ConvertConstant(t, v), 10), nil
+	}
+	addressOf := false
+	if p, ok := e.(*ast.PrefixExpression); ok && p.Operator == "&" {
+		e, addressOf = p.Expression, true
+	}
+	if id, ok := e.(*ast.Identifier); ok {
+		v, err := g.Variables.GetVariable(id.Value)
+		if err == nil && v.Kind == FunctionName {
+			return v.Label, nil
+		}
+	}
+	// Arrays decay to the address of their first element
+	et, _ := g.TypeOf(e)
+	if _, isArray := types.Unqualified(et).(*types.Array); addressOf || isArray {
+		label, offset, ok := g.StaticAddress(e)
+		if ok && offset != 0 {
+			return fmt.Sprintf("%s+%d", label, offset), nil
+		}
+		if ok {
+			return label, nil
+		}
+	}
+	return "", errors.New("Initializer element of a static variable is not constant")
+}
+
+// StaticAddress returns the label and the offset of an object with static storage duration
+// designated by a static variable and constant subscripts or member accesses
+func (g *AssemblyGenerator) StaticAddress(e ast.Expression) (string, int, bool) {
+	switch e := e.(type) {
+	case *ast.Identifier:
+		v, err := g.Variables.GetVariable(e.Value)
+		if err != nil || v.Kind != StaticVariable {
+			return "", 0, false
+		}
+		return v.Label, 0, true
+	case *ast.IndexExpression:
+		t, _ := g.TypeOf(e.Left)
+		a, ok := types.Unqualified(t).(*types.Array)
+		if !ok {
+			return "", 0, false
+		}
+		i, err := g.EvalConstant(e.Index)
+		if err != nil {
+			return "", 0, false
+		}
+		label, offset, ok := g.StaticAddress(e.Left)
+		return label, offset + int(i)*a.Elem.Size(), ok
+	case *ast.MemberExpression:
+		if e.Operator != "." {
+			return "", 0, false
+		}
+		f, err := g.MemberOf(*e)
+		if err != nil {
+			return "", 0, false
+		}
+		label, offset, ok := g.StaticAddress(e.Left)
+		return label, offset + f.Offset, ok
+	}
+	return "", 0, false
+}
+
+// GenerateStaticData emits the storage of the static variables, initialized ones
+// go in .data and the others in .bss
+func (g *AssemblyGenerator) GenerateStaticData() error {
+	for _, d := range g.Statics {
+		if d.Init != nil && d.Values == nil {
+			values, err := g.StaticValues(d.Variable.Type, d.Init)
+			if err != nil {
+				return err
+			}
+			d.Values = values
+		}
+	}
+	for _, section := range []string{".data", ".bss"} {
+		first := true
+		for _, d := range g.Statics {
+			if (d.Init != nil) != (section == ".data") {
+				continue
+			}
+			if first {
+				g.AddLine(section)
+				first = false
+			}
+			v := d.Variable
+			size := v.Type.Size()
+			if v.Linkage == ExternalLinkage {
+				g.AddLine(fmt.Sprintf(".globl %s", v.Label))
+			}
+			g.AddLine(fmt.Sprintf(".align %d", types.Alignof(v.Type)))
+			g.AddLine(fmt.Sprintf("%s:", v.Label))
+			g.EnterContext()
+			// The bytes between the initialized members are zero
+			offset := 0
+			for _, value := range d.Values {
+				if value.Offset > offset {
+					g.AddLine(".zero", strconv.Itoa(value.Offset-offset))
+				}
+				g.AddLine(dataDirective(value.Size), value.Value)
+				offset = value.Offset + value.Size
+			}
+			if offset < size {
+				g.AddLine(".zero", strconv.Itoa(size-offset))
+			}
+			g.LeaveContext()
+		}
+	}
+	return nil
+}
+
+// dataDirective returns the assembler directive emitting a value of the given size
+func dataDirective(size int) string {
+	switch size {
+	case 1:
+		return ".byte"
+	case 2:
+		return ".short"
+	case 4:
+		return ".long"
+	}
+	return ".quad"
+}
@@ -0,0 +1,152 @@
+package generator
+
+import (
+	"compiler/ast"
+	"compiler/types"
+	"fmt"
+)
+
+// The va_list of the System V ABI is laid out as
+//
+//	unsigned int gp_offset;       offset of the next register argument in reg_save_area
+//	unsigned int fp_offset;       offset of the next vector register argument in reg_save_area
+//	void *overflow_arg_area;      address of the next argument passed on the stack
+//	void *reg_save_area;          copy of the argument registers made by the prologue
+//
+// Vector registers are never saved since there are no floating point types,
+// fp_offset is set past the vector registers as if they were all used
+const (
+	registerSaveAreaSize = 48
+	vectorSaveAreaEnd    = registerSaveAreaSize + 8*16
+)
+
+// VarArgs locates the arguments of the variadic function being generated
+type VarArgs struct {
+	// SaveArea is the stack index of the copy of the argument registers
+	SaveArea int
+	// Named is the number of named parameters
+	Named int
+	// Last is the name of the last named parameter, as given to va_start
+	Last string
+}
+
+// GenerateRegisterSaveArea copies every argument register to the stack so va_arg can read
+// the variadic arguments passed in registers
+func (g *AssemblyGenerator) GenerateRegisterSaveArea(f ast.FunctionStatement) {
+	g.VarArgs = &VarArgs{SaveArea: g.Variables.Reserve(registerSaveAreaSize), Named: len(f.Parameters)}
+	if len(f.Parameters) != 0 {
+		g.VarArgs.Last = f.Parameters[len(f.Parameters)-1].Arg
+	}
+	for i, reg := range argumentRegisters {
+		g.AddLine("mov", fmt.Sprintf("%s, %d(%%rbp)", reg, g.VarArgs.SaveArea+8*i), "/* Save the argument register for va_arg */")
+	}
+}
+
+// FromBuiltinExpression generates va_start, va_arg and va_end
+func (g *AssemblyGenerator) FromBuiltinExpression(e ast.BuiltinExpression) error {
+	switch e.Name {
+	case "va_start":
+		return g.GenerateVaStart(e)
+	case "va_arg":
+		return g.GenerateVaArg(e)
+	case "va_end":
+		if len(e.Arguments) != 1 {
+			return fmt.Errorf("'va_end' expects 1 argument, got %d", len(e.Arguments))
+		}
+		// Nothing to release, the va_list only has to be valid
+		return g.CheckVaList(e.Arguments[0])
+	}
+	return fmt.Errorf("Unknown builtin '%s'", e.Name)
+}
+
+// CheckVaList makes sure e is a va_list, either an object of type va_list or the pointer it decays to
+// such as a va_list parameter
+func (g *AssemblyGenerator) CheckVaList(e ast.Expression) error {
+	t, err := g.TypeOf(e)
+	if err != nil {
+		return err
+	}
+	if p, ok := types.Decay(t).(*types.Pointer); ok {
+		if _, ok := p.Elem.(*types.VaListTag); ok {
+			return nil
+		}
+	}
+	return fmt.Errorf("Expected a va_list, got '%s' of type '%s'", e, t)
+}
+
+// GenerateVaListAddress moves the address of the va_list designated by e into RAX
+// A va_list object gives its address and a parameter holds the address of the va_list of the caller
+func (g *AssemblyGenerator) GenerateVaListAddress(e ast.Expression) error {
+	if err := g.CheckVaList(e); err != nil {
+		return err
+	}
+	return g.FromExpression(e)
+}
+
+// GenerateVaStart points the va_list to the first variadic argument
+func (g *AssemblyGenerator) GenerateVaStart(e ast.BuiltinExpression) error {
+	if g.VarArgs == nil {
+		return fmt.Errorf("'va_start' used in a function with fixed arguments")
+	}
+	if len(e.Arguments) != 2 {
+		return fmt.Errorf("'va_start' expects 2 arguments, got %d", len(e.Arguments))
+	}
+	if last, ok := e.Arguments[1].(*ast.Identifier); !ok || last.Value != g.VarArgs.Last {
+		return fmt.Errorf("Second argument of 'va_start' is not the last named parameter")
+	}
+	err := g.GenerateVaListAddress(e.Arguments[0])
+	if err != nil {
+		return err
+	}
+	inRegisters, onStack := g.VarArgs.Named, 0
+	if inRegisters > len(argumentRegisters) {
+		inRegisters, onStack = len(argumentRegisters), inRegisters-len(argumentRegisters)
+	}
+	g.AddLine("movl", fmt.Sprintf("$%d, (%%rax)", 8*inRegisters), "/* gp_offset skips the named register arguments */")
+	g.AddLine("movl", fmt.Sprintf("$%d, 4(%%rax)", vectorSaveAreaEnd), "/* fp_offset, no vector register is saved */")
+	g.AddLine("lea", fmt.Sprintf("%d(%%rbp), %%rcx", 16+8*onStack), "/* The stack arguments follow the named ones */")
+	g.AddLine("mov", "%rcx, 8(%rax)", "/* Set overflow_arg_area */")
+	g.AddLine("lea", fmt.Sprintf("%d(%%rbp), %%rcx", g.VarArgs.SaveArea), "/* Address of the register save area */")
+	g.AddLine("mov", "%rcx, 16(%rax)", "/* Set reg_save_area */")
+	return nil
+}
+
+// GenerateVaArg moves the next variadic argument into RAX and advances the va_list
+// Arguments are taken from the register save area until it is exhausted, then from the stack
+func (g *AssemblyGenerator) GenerateVaArg(e ast.BuiltinExpression) error {
+	if len(e.Arguments) != 1 || e.Type == nil {
+		return fmt.Errorf("'va_arg' expects a va_list and a type name")
+	}
+	t, err := g.ResolveType(e.Type)
+	if err != nil {
+		return err
+	}
+	if _, ok := types.Unqualified(t).(*types.Pointer); !ok && !types.IsArithmetic(t) {
+		return fmt.Errorf("Could not read a variadic argument of type '%s'", t)
+	}
+	err = g.GenerateVaListAddress(e.Arguments[0])
+	if err != nil {
+		return err
+	}
+	stackName := g.LabelGenerator.GetNextLabel("va_stack")
+	doneName := g.LabelGenerator.GetNextLabel("va_done")
+	g.AddLine("mov", "%rax, %rcx", "/* Keep the address of the va_list in RCX */")
+	g.AddLine("movl", "(%rcx), %eax", "/* Load gp_offset */")
+	g.AddLine("cmp", fmt.Sprintf("$%d, %%eax", registerSaveAreaSize), "/* Check if the register arguments are exhausted */")
+	g.AddLine("jae", stackName, "/* Read the argument from the stack */")
+	g.AddLine("lea", "8(%rax), %rdx", "/* Offset of the next register argument */")
+	g.AddLine("movl", "%edx, (%rcx)", "/* Update gp_offset */")
+	g.AddLine("add", "16(%rcx), %rax", "/* Address of the argument in the register save area */")
+	g.AddLine("jmp", doneName)
+	g.LeaveContext()
+	g.AddLine(fmt.Sprintf("%s:", stackName))
+	g.EnterContext()
+	g.AddLine("mov", "8(%rcx), %rax", "/* Address of the argument on the stack */")
+	g.AddLine("lea", "8(%rax), %rdx", "/* Address of the next stack argument */")
+	g.AddLine("mov", "%rdx, 8(%rcx)", "/* Update overflow_arg_area */")
+	g.LeaveContext()
+	g.AddLine(fmt.Sprintf("%s:", doneName))
+	g.EnterContext()
+	g.GenerateLoad(t)
+	return nil
+}
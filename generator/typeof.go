@@ -0,0 +1,305 @@
+package generator
+
+import (
+	"compiler/ast"
+	"compiler/types"
+	"fmt"
+)
+
+// TypeOf returns the type of an expression without generating any code for it
+func (g *AssemblyGenerator) TypeOf(e ast.Expression) (types.Type, error) {
+	switch e := e.(type) {
+	case *ast.IntegerLiteral:
+		_, t, err := types.IntegerConstant(e.Value)
+		return t, err
+	case *ast.Identifier:
+		v, err := g.Variables.GetVariable(e.Value)
+		if err != nil {
+			return nil, err
+		}
+		if v.Kind == TypedefName {
+			return nil, fmt.Errorf("Unexpected type name '%s' in expression", e.Value)
+		}
+		return v.Type, nil
+	case *ast.PrefixExpression:
+		if e.Operator == "!" {
+			return types.Int, nil
+		}
+		t, err := g.TypeOf(e.Expression)
+		if err != nil {
+			return nil, err
+		}
+		switch e.Operator {
+		case "&":
+			return &types.Pointer{Elem: t}, nil
+		case "*":
+			if p, ok := types.Decay(t).(*types.Pointer); ok {
+				return p.Elem, nil
+			}
+			return nil, fmt.Errorf("Could not dereference a value of type '%s'", t)
+		case "++", "--":
+			return types.Unqualified(t), nil
+		}
+		return types.Promote(t), nil
+	case *ast.BuiltinExpression:
+		if e.Name != "va_arg" {
+			return nil, fmt.Errorf("'%s' does not return a value", e.Name)
+		}
+		return g.ResolveType(e.Type)
+	case *ast.PostfixExpression:
+		t, err := g.TypeOf(e.Expression)
+		if err != nil {
+			return nil, err
+		}
+		return types.Unqualified(t), nil
+	case *ast.InfixExpression:
+		switch e.Operator {
+		case "==", "!=", "<", "<=", ">", ">=", "&&", "||":
+			return types.Int, nil
+		}
+		l, err := g.TypeOf(e.Left)
+		if err != nil {
+			return nil, err
+		}
+		r, err := g.TypeOf(e.Right)
+		if err != nil {
+			return nil, err
+		}
+		if e.Operator == "+" || e.Operator == "-" {
+			lp, lok := types.Decay(l).(*types.Pointer)
+			rp, rok := types.Decay(r).(*types.Pointer)
+			switch {
+			case lok && rok:
+				// The difference of two pointers
+				return types.Long, nil
+			case lok:
+				return lp, nil
+			case rok:
+				return rp, nil
+			}
+		}
+		return types.Common(l, r), nil
+	case *ast.IndexExpression:
+		l, err := g.TypeOf(e.Left)
+		if err != nil {
+			return nil, err
+		}
+		if types.IsArithmetic(l) {
+			// i[a] is the same as a[i]
+			l, err = g.TypeOf(e.Index)
+			if err != nil {
+				return nil, err
+			}
+		}
+		p, ok := types.Decay(l).(*types.Pointer)
+		if !ok {
+			return nil, fmt.Errorf("Subscripted value of type '%s' is neither array nor pointer", l)
+		}
+		return p.Elem, nil
+	case *ast.MemberExpression:
+		f, err := g.MemberOf(*e)
+		if err != nil {
+			return nil, err
+		}
+		return f.Type, nil
+	case *ast.AssignExpression:
+		return g.TypeOf(e.Left)
+	case *ast.CommaExpression:
+		return g.TypeOf(e.Right)
+	case *ast.CastExpression:
+		return g.ResolveType(e.Type)
+	case *ast.SizeofExpression:
+		return types.UnsignedLong, nil
+	case *ast.CallExpression:
+		fn, err := g.CalleeType(*e)
+		if err != nil {
+			return nil, err
+		}
+		return fn.Return, nil
+	default:
+		return nil, fmt.Errorf("Could not find the type of %s", e.TokenLiteral())
+	}
+}
+
+// OperandType returns the type both operands of a binary operator are converted to before the operation
+// following the usual arithmetic conversions, a pointer operand makes it a comparison of addresses
+func (g *AssemblyGenerator) OperandType(e ast.InfixExpression) (types.Type, error) {
+	l, err := g.TypeOf(e.Left)
+	if err != nil {
+		return nil, err
+	}
+	r, err := g.TypeOf(e.Right)
+	if err != nil {
+		return nil, err
+	}
+	if !types.IsArithmetic(l) {
+		return types.Decay(l), nil
+	}
+	if !types.IsArithmetic(r) {
+		return types.Decay(r), nil
+	}
+	return types.Common(l, r), nil
+}
+
+// IsUnsignedOperation returns whether or not values of type t are compared and divided as unsigned numbers
+// Addresses are unsigned
+func IsUnsignedOperation(t types.Type) bool {
+	if _, ok := types.Decay(t).(*types.Pointer); ok {
+		return true
+	}
+	b, ok := types.Underlying(t).(*types.Basic)
+	return ok && b.Unsigned
+}
+
+// GenerateConversion truncates the value in RAX to the size of t
+// then sign or zero extends it back to 64 bits so RAX always holds a valid value of type t
+func (g *AssemblyGenerator) GenerateConversion(t types.Type) {
+	g.GenerateRegisterConversion(t, "%rax")
+}
+
+// GenerateRegisterConversion does what GenerateConversion does for another register such as "%rdi"
+func (g *AssemblyGenerator) GenerateRegisterConversion(t types.Type, register string) {
+	b, ok := types.Underlying(t).(*types.Basic)
+	if !ok {
+		return
+	}
+	r, _ := asmRegisterByName(register)
+	src, dst := asmRegisterName(r, b.Size()), asmRegisterName(r, 8)
+	switch b.Kind {
+	case types.CharKind:
+		g.AddLine("movsbq", src+", "+dst, "/* Truncate to a char and sign extend */")
+	case types.UnsignedCharKind:
+		g.AddLine("movzbq", src+", "+dst, "/* Truncate to an unsigned char and zero extend */")
+	case types.ShortKind:
+		g.AddLine("movswq", src+", "+dst, "/* Truncate to a short and sign extend */")
+	case types.UnsignedShortKind:
+		g.AddLine("movzwq", src+", "+dst, "/* Truncate to an unsigned short and zero extend */")
+	case types.IntKind:
+		g.AddLine("movslq", src+", "+dst, "/* Truncate to an int and sign extend */")
+	case types.UnsignedIntKind:
+		g.AddLine("movl", src+", "+src, "/* Truncate to an unsigned int, upper half is zeroed */")
+	}
+}
+
+// MemberOf returns the member of a struct selected by a member access, the left side has to be
+// a struct for "." and a pointer to a struct for "->"
+// The member gets the qualifiers of the struct it belongs to
+func (g *AssemblyGenerator) MemberOf(e ast.MemberExpression) (types.Field, error) {
+	t, err := g.TypeOf(e.Left)
+	if err != nil {
+		return types.Field{}, err
+	}
+	if e.Operator == "->" {
+		p, ok := types.Decay(t).(*types.Pointer)
+		if !ok {
+			return types.Field{}, fmt.Errorf("Invalid type argument of '->' (have '%s')", t)
+		}
+		t = p.Elem
+	}
+	s, ok := types.Unqualified(t).(*types.Struct)
+	if !ok {
+		return types.Field{}, fmt.Errorf("Request for member '%s' in something not a structure (type '%s')", e.Member, t)
+	}
+	if !s.Defined {
+		return types.Field{}, fmt.Errorf("Invalid use of incomplete type '%s'", s)
+	}
+	f, ok := s.Field(e.Member)
+	if !ok {
+		return types.Field{}, fmt.Errorf("'%s' has no member named '%s'", s, e.Member)
+	}
+	f.Type = types.Qualify(f.Type, types.IsConst(t), types.IsVolatile(t))
+	return f, nil
+}
+
+// CalleeType returns the type of the called function, the callee has to be a function or a function pointer
+func (g *AssemblyGenerator) CalleeType(e ast.CallExpression) (*types.Func, error) {
+	t, err := g.TypeOf(e.Function)
+	if err != nil {
+		return nil, err
+	}
+	if p, ok := types.Unqualified(t).(*types.Pointer); ok {
+		t = p.Elem
+	}
+	fn, ok := t.(*types.Func)
+	if !ok {
+		return nil, fmt.Errorf("Called object of type '%s' is not a function or function pointer", t)
+	}
+	return fn, nil
+}
+
+// CheckCall makes sure the callee is a function or a function pointer and that the arguments
+// match its parameters, it returns the type of the called function
+func (g *AssemblyGenerator) CheckCall(e ast.CallExpression) (*types.Func, error) {
+	fn, err := g.CalleeType(e)
+	if err != nil {
+		return nil, err
+	}
+	name := "function"
+	if id, ok := e.Function.(*ast.Identifier); ok {
+		name = fmt.Sprintf("'%s'", id.Value)
+	}
+	if len(e.Arguments) < len(fn.Params) {
+		return nil, fmt.Errorf("Too few arguments to %s of type '%s'", name, fn)
+	}
+	if len(e.Arguments) > len(fn.Params) && !fn.Variadic && !fn.Unprototyped {
+		return nil, fmt.Errorf("Too many arguments to %s of type '%s'", name, fn)
+	}
+	for i, arg := range e.Arguments {
+		if i >= len(fn.Params) {
+			// Variadic arguments and the arguments of a function declared with "()" are only promoted
+			t, err := g.TypeOf(arg)
+			if err != nil {
+				return nil, err
+			}
+			switch types.Decay(t).(type) {
+			case *types.Pointer:
+			default:
+				if !types.IsArithmetic(t) {
+					return nil, fmt.Errorf("Argument %d of %s: could not pass a value of type '%s' through '...'", i+1, name, t)
+				}
+			}
+			continue
+		}
+		err := g.CheckAssignable(fn.Params[i], arg)
+		if err != nil {
+			return nil, fmt.Errorf("Argument %d of %s: %s", i+1, name, err)
+		}
+	}
+	return fn, nil
+}
+
+// CheckAssignable makes sure the value of e can be implicitly converted to t
+// Integers convert to each other, pointers have to point to the same type
+// except for the null pointer constant, and arrays and functions decay to pointers
+// The qualifiers of the pointed type can be added but a warning is issued when they are discarded
+// Structs are not copied so they are never assignable
+func (g *AssemblyGenerator) CheckAssignable(t types.Type, e ast.Expression) error {
+	et, err := g.TypeOf(e)
+	if err != nil {
+		return err
+	}
+	if types.IsArithmetic(t) && types.IsArithmetic(et) {
+		return nil
+	}
+	if _, ok := types.Unqualified(t).(*types.Struct); ok {
+		return fmt.Errorf("Copying a value of type '%s' is not supported", t)
+	}
+	t, et = types.Unqualified(t), types.Unqualified(types.Decay(et))
+	if p, ok := t.(*types.Pointer); ok {
+		if ep, ok := et.(*types.Pointer); ok && types.Compatible(types.Unqualified(p.Elem), types.Unqualified(ep.Elem)) {
+			if types.IsConst(ep.Elem) && !types.IsConst(p.Elem) {
+				g.Warn("Conversion from '%s' to '%s' discards the 'const' qualifier", et, t)
+			}
+			if types.IsVolatile(ep.Elem) && !types.IsVolatile(p.Elem) {
+				g.Warn("Conversion from '%s' to '%s' discards the 'volatile' qualifier", et, t)
+			}
+			return nil
+		}
+		if types.IsArithmetic(et) {
+			if v, err := g.EvalConstant(e); err == nil && v == 0 {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("Incompatible types, expected '%s' got '%s'", t, et)
+}
@@ -1,36 +1,234 @@
 package generator
 
 import (
+	"compiler/types"
 	"fmt"
 )
 
+// VariableKind tells what an identifier of the symbol table refers to
+type VariableKind uint32
+
+// All the kinds of identifiers
+const (
+	LocalVariable VariableKind = iota
+	EnumConstant
+	TypedefName
+	FunctionName
+	StaticVariable
+)
+
+// Linkage tells whether a name declared in different scopes or files refers to the same entity
+type Linkage uint32
+
+// All the kinds of linkage
+const (
+	NoLinkage Linkage = iota
+	InternalLinkage
+	ExternalLinkage
+)
+
+// Variable is an entry of the symbol table
+// Only local variables get a slot in the current stack frame, enumerators hold their Value
+// typedef names hold the Type they alias and function names hold their *types.Func
+// Functions and variables with static storage duration are referred to by their Label
+type Variable struct {
+	Kind       VariableKind
+	StackIndex int
+	Type       types.Type
+	Value      int64
+	Label      string
+	Linkage    Linkage
+	// Defined is set once the storage of a static variable or the body of a function was generated
+	Defined bool
+}
+
+// Scope holds the identifiers and the enum and struct tags declared in a block
+type Scope struct {
+	Variables map[string]*Variable
+	Tags      map[string]types.Type
+}
+
+func NewScope() *Scope {
+	return &Scope{Variables: make(map[string]*Variable), Tags: make(map[string]types.Type)}
+}
+
 type VariableManager struct {
-	Variables  map[string]int
+	Scopes     []*Scope
 	StackIndex int
 }
 
 func NewVariableManager() *VariableManager {
-	return &VariableManager{Variables: make(map[string]int), StackIndex: -8}
+	v := &VariableManager{Scopes: []*Scope{NewScope()}, StackIndex: -8}
+	// va_list is built in since there is no stdarg.h to include
+	v.Scopes[0].Variables["va_list"] = &Variable{Kind: TypedefName, Type: &types.VaList{}}
+	return v
+}
+
+// Reserve allocates size bytes in the current stack frame and returns the stack index of the first one
+// Every allocation is a multiple of 8 bytes
+func (v *VariableManager) Reserve(size int) int {
+	size = (size + 7) &^ 7
+	if size > 8 {
+		v.StackIndex -= size - 8
+	}
+	index := v.StackIndex
+	v.StackIndex -= 8
+	return index
+}
+
+// EnterScope opens a new block scope, identifiers of the outer scopes stay visible
+func (v *VariableManager) EnterScope() {
+	v.Scopes = append(v.Scopes, NewScope())
+}
+
+// LeaveScope drops the innermost scope, its stack slots are not reused
+func (v *VariableManager) LeaveScope() {
+	v.Scopes = v.Scopes[:len(v.Scopes)-1]
+}
+
+// IsFileScope returns whether or not no block is currently open
+func (v *VariableManager) IsFileScope() bool {
+	return len(v.Scopes) == 1
 }
 
+func (v *VariableManager) current() *Scope {
+	return v.Scopes[len(v.Scopes)-1]
+}
+
+// VariableExists only looks at the current scope since inner declarations can hide outer ones
 func (v *VariableManager) VariableExists(name string) bool {
-	_, ok := v.Variables[name]
+	_, ok := v.current().Variables[name]
 	return ok
 }
 
+func (v *VariableManager) GetVariable(name string) (*Variable, error) {
+	for i := len(v.Scopes) - 1; i >= 0; i-- {
+		if value, ok := v.Scopes[i].Variables[name]; ok {
+			return value, nil
+		}
+	}
+	return nil, fmt.Errorf("Undecraled variable '%s'", name)
+}
+
 func (v *VariableManager) GetVariableStackIndex(name string) (int, error) {
-	value, ok := v.Variables[name]
-	if !ok {
-		return 0, fmt.Errorf("Undecraled variable '%s'", name)
+	value, err := v.GetVariable(name)
+	if err != nil {
+		return 0, err
 	}
-	return value, nil
+	return value.StackIndex, nil
 }
 
-func (v *VariableManager) CreateVariable(name string) error {
+func (v *VariableManager) CreateVariable(name string, t types.Type) error {
 	if v.VariableExists(name) {
 		return fmt.Errorf("Could not re-declare variable '%s'", name)
 	}
-	v.Variables[name] = v.StackIndex
-	v.StackIndex = v.StackIndex - 8
+	if !types.IsComplete(t) {
+		return fmt.Errorf("Storage size of '%s' isn't known", name)
+	}
+	v.current().Variables[name] = &Variable{Kind: LocalVariable, StackIndex: v.Reserve(t.Size()), Type: t}
+	return nil
+}
+
+func (v *VariableManager) CreateConstant(name string, t types.Type, value int64) error {
+	if v.VariableExists(name) {
+		return fmt.Errorf("Could not re-declare '%s'", name)
+	}
+	v.current().Variables[name] = &Variable{Kind: EnumConstant, Type: t, Value: value}
 	return nil
 }
+
+func (v *VariableManager) CreateTypedef(name string, t types.Type) error {
+	if v.VariableExists(name) {
+		return fmt.Errorf("Could not re-declare '%s'", name)
+	}
+	v.current().Variables[name] = &Variable{Kind: TypedefName, Type: t}
+	return nil
+}
+
+// GetTypedef returns the type aliased by a typedef name
+func (v *VariableManager) GetTypedef(name string) (types.Type, error) {
+	value, err := v.GetVariable(name)
+	if err != nil || value.Kind != TypedefName {
+		return nil, fmt.Errorf("Unknown type '%s'", name)
+	}
+	return value.Type, nil
+}
+
+// CreateTag records the type named by an enum or struct tag
+func (v *VariableManager) CreateTag(name string, t types.Type) error {
+	if _, ok := v.current().Tags[name]; ok {
+		return fmt.Errorf("Could not re-declare '%s'", t)
+	}
+	v.current().Tags[name] = t
+	return nil
+}
+
+// GetTag returns the type named by a tag visible from the current scope, nil if there is none
+func (v *VariableManager) GetTag(name string) types.Type {
+	for i := len(v.Scopes) - 1; i >= 0; i-- {
+		if t, ok := v.Scopes[i].Tags[name]; ok {
+			return t
+		}
+	}
+	return nil
+}
+
+// GetScopeTag returns the type named by a tag declared in the current scope, nil if there is none
+func (v *VariableManager) GetScopeTag(name string) types.Type {
+	return v.current().Tags[name]
+}
+
+// CreateParameter records a parameter passed on the stack, its slot is above the saved RBP
+func (v *VariableManager) CreateParameter(name string, t types.Type, stackIndex int) error {
+	if v.VariableExists(name) {
+		return fmt.Errorf("Could not re-declare parameter '%s'", name)
+	}
+	v.current().Variables[name] = &Variable{Kind: LocalVariable, StackIndex: stackIndex, Type: t}
+	return nil
+}
+
+// CreateFunction records a function, declaring it again is fine as long as the types and linkage match
+func (v *VariableManager) CreateFunction(name string, t *types.Func, linkage Linkage) (*Variable, error) {
+	return v.createLinked(name, FunctionName, t, linkage, name)
+}
+
+// CreateStatic records a variable with static storage duration stored at label
+// File scope variables can be declared several times as long as the types and linkage match
+func (v *VariableManager) CreateStatic(name string, t types.Type, linkage Linkage, label string) (*Variable, error) {
+	return v.createLinked(name, StaticVariable, t, linkage, label)
+}
+
+func (v *VariableManager) createLinked(name string, kind VariableKind, t types.Type, linkage Linkage, label string) (*Variable, error) {
+	if value, ok := v.current().Variables[name]; ok {
+		if value.Kind != kind || !types.Compatible(value.Type, t) {
+			return nil, fmt.Errorf("Conflicting types for '%s'", name)
+		}
+		if linkage == NoLinkage || value.Linkage == NoLinkage {
+			return nil, fmt.Errorf("Could not re-declare '%s'", name)
+		}
+		if value.Linkage != linkage {
+			if linkage == InternalLinkage {
+				return nil, fmt.Errorf("Static declaration of '%s' follows non-static declaration", name)
+			}
+			return nil, fmt.Errorf("Non-static declaration of '%s' follows static declaration", name)
+		}
+		// A prototype gives the parameters of a function declared with "()" before
+		if fn, ok := value.Type.(*types.Func); ok && fn.Unprototyped {
+			value.Type = t
+		}
+		return value, nil
+	}
+	value := &Variable{Kind: kind, Type: t, Linkage: linkage, Label: label}
+	v.current().Variables[name] = value
+	return value, nil
+}
+
+// GetLinkage returns the linkage of a previous declaration of name visible from the current scope
+// Names without a previous declaration, or hidden by one without linkage, get external linkage
+func (v *VariableManager) GetLinkage(name string) Linkage {
+	value, err := v.GetVariable(name)
+	if err != nil || value.Linkage == NoLinkage {
+		return ExternalLinkage
+	}
+	return value.Linkage
+}
@@ -0,0 +1,40 @@
+package lexer
+
+import (
+	"compiler/source"
+	"strings"
+)
+
+// Error is a lexical error, the lexer reports it and goes on with the next token
+type Error struct {
+	Pos      source.Pos
+	Position source.Position
+	Msg      string
+}
+
+func (e *Error) Error() string {
+	return e.Position.String() + ": " + e.Msg
+}
+
+// ErrorHandler is called by the lexer for every lexical error it finds
+type ErrorHandler func(err *Error)
+
+// ErrorList holds the lexical errors in the order they were found
+type ErrorList []*Error
+
+// Error returns every error of the list, one per line
+func (list ErrorList) Error() string {
+	msgs := make([]string, len(list))
+	for i, e := range list {
+		msgs[i] = e.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Err returns the list as an error, nil when it is empty
+func (list ErrorList) Err() error {
+	if len(list) == 0 {
+		return nil
+	}
+	return list
+}
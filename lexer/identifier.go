@@ -0,0 +1,55 @@
+package lexer
+
+import "unicode"
+
+// notXIDStart lists the characters that are ID_Start but not XID_Start, they are not
+// closed under NFKC normalization so UAX #31 leaves them out of identifiers
+var notXIDStart = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x037A, Hi: 0x037A, Stride: 1},
+		{Lo: 0x0E33, Hi: 0x0EB3, Stride: 0x80},
+		{Lo: 0x309B, Hi: 0x309C, Stride: 1},
+		{Lo: 0xFC5E, Hi: 0xFC63, Stride: 1},
+		{Lo: 0xFDFA, Hi: 0xFDFB, Stride: 1},
+		{Lo: 0xFE70, Hi: 0xFE7E, Stride: 2},
+		{Lo: 0xFF9E, Hi: 0xFF9F, Stride: 1},
+	},
+}
+
+// notXIDContinue lists the characters that are ID_Continue but not XID_Continue
+var notXIDContinue = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x037A, Hi: 0x037A, Stride: 1},
+		{Lo: 0x309B, Hi: 0x309C, Stride: 1},
+		{Lo: 0xFC5E, Hi: 0xFC63, Stride: 1},
+		{Lo: 0xFDFA, Hi: 0xFDFB, Stride: 1},
+		{Lo: 0xFE70, Hi: 0xFE7E, Stride: 2},
+	},
+}
+
+// idStart holds the categories and properties making up ID_Start
+var idStart = []*unicode.RangeTable{unicode.L, unicode.Nl, unicode.Other_ID_Start}
+
+// idContinue holds the categories and properties ID_Continue adds to ID_Start
+var idContinue = []*unicode.RangeTable{unicode.Mn, unicode.Mc, unicode.Nd, unicode.Pc, unicode.Other_ID_Continue}
+
+// IsIdentifierStart returns whether or not a rune can start an identifier
+// Besides "_" these are the XID_Start characters of UAX #31
+func IsIdentifierStart(r rune) bool {
+	if r < 0x80 {
+		return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || r == '_'
+	}
+	return unicode.IsOneOf(idStart, r) && !unicode.In(r, unicode.Pattern_Syntax, unicode.Pattern_White_Space, notXIDStart)
+}
+
+// IsIdentifierContinue returns whether or not a rune can follow the first one of an identifier
+// Besides "_" and digits these are the XID_Continue characters of UAX #31
+func IsIdentifierContinue(r rune) bool {
+	if r < 0x80 {
+		return IsIdentifierStart(r) || (r >= '0' && r <= '9')
+	}
+	if !unicode.IsOneOf(idStart, r) && !unicode.IsOneOf(idContinue, r) {
+		return false
+	}
+	return !unicode.In(r, unicode.Pattern_Syntax, unicode.Pattern_White_Space, notXIDContinue)
+}
@@ -1,169 +1,181 @@
 package lexer
 
 import (
+	"bytes"
 	"compiler/buffer"
+	"compiler/source"
+	"fmt"
 	"io"
+	"strings"
 	"unicode"
+	"unicode/utf8"
 )
 
 // Lexer reads tokens from a source one at a time
+// C tokens do not depend on what comes before them so the lexer keeps no parsing state
 type Lexer struct {
-	r         *buffer.Lexer
-	stack     []ParsingContext
-	state     TokenState
-	emptyLine bool
+	r *buffer.Lexer
+	// file records the line starts and gives the positions of the tokens
+	file *source.File
+	// errors lists the lexical errors found so far, handler is also called for each of them if set
+	errors  ErrorList
+	handler ErrorHandler
 }
 
 // NewLexer creates a new Lexer from a io.Reader
+// The positions of its tokens belong to a file of its own, use NewFileLexer to share a FileSet
 func NewLexer(r io.Reader) *Lexer {
+	return NewFileLexer(r, source.NewFileSet().AddFile("", -1))
+}
+
+// NewFileLexer creates a new Lexer reading the content of file from r
+// The tokens get positions in the FileSet of the file and its line table is filled while lexing
+func NewFileLexer(r io.Reader, file *source.File) *Lexer {
 	return &Lexer{
-		r:         buffer.NewLexer(r),
-		stack:     make([]ParsingContext, 0, 16),
-		state:     ExprState,
-		emptyLine: true,
+		r:    buffer.NewLexer(r),
+		file: file,
 	}
 }
 
 // Err returns the current error from the buffer reader
+// Lexical errors do not stop the lexer, they are returned by Errors
 func (l *Lexer) Err() error {
 	return l.r.Err()
 }
 
-func (l *Lexer) enterContext(context ParsingContext) {
-	l.stack = append(l.stack, context)
+// Errors returns the lexical errors found so far
+func (l *Lexer) Errors() ErrorList {
+	return l.errors
 }
 
-func (l *Lexer) leaveContext() ParsingContext {
-	ctx := GlobalContext
-	if last := len(l.stack) - 1; last >= 0 {
-		ctx, l.stack = l.stack[last], l.stack[:last]
-	}
-	return ctx
+// Position decodes the position of a token read by the lexer
+func (l *Lexer) Position(p source.Pos) source.Position {
+	return l.file.Position(p)
+}
+
+// SetErrorHandler sets a function to call for every lexical error as soon as it is found
+func (l *Lexer) SetErrorHandler(handler ErrorHandler) {
+	l.handler = handler
 }
 
 // Next reads from the buffer and returns the next available token
+// An invalid token is reported as a lexical error and returned as an ErrToken, an EOFToken is returned
+// once the input is exhausted
 func (l *Lexer) Next() *Token {
-	tt := UnknownToken
+	offset := l.r.Offset()
+	t := l.next()
+	t.Pos = l.file.Pos(offset)
+	return t
+}
+
+// errorf reports a lexical error at the given offset
+func (l *Lexer) errorf(offset int, format string, args ...interface{}) {
+	pos := l.file.Pos(offset)
+	err := &Error{Pos: pos, Position: l.file.Position(pos), Msg: fmt.Sprintf(format, args...)}
+	l.errors = append(l.errors, err)
+	if l.handler != nil {
+		l.handler(err)
+	}
+}
+
+func (l *Lexer) next() *Token {
 	c := l.r.Peek(0)
-	switch c {
-	case '(':
-		if l.state == StmtParensState {
-			l.enterContext(StmtParensContext)
-		} else {
-			l.enterContext(ExprParensContext)
-		}
-		l.state = ExprState
-		l.r.Move(1)
-		tt = PunctuatorToken
-	case ')':
-		if l.leaveContext() == StmtParensContext {
-			l.state = ExprState
-		} else {
-			l.state = SubscriptState
+	switch {
+	case c >= '0' && c <= '9':
+		if !l.consumeNumericToken() {
+			return &Token{Type: ErrToken, Value: l.r.Shift()}
 		}
-		l.r.Move(1)
-		tt = PunctuatorToken
-	case '{':
-		l.enterContext(BracesContext)
-		l.state = ExprState
-		l.r.Move(1)
-		tt = PunctuatorToken
-	case '}':
-		if l.leaveContext() == TemplateContext && l.consumeTemplateToken() {
-			tt = TemplateToken
-		} else {
-			l.state = ExprState
-			l.r.Move(1)
-			tt = PunctuatorToken
-		}
-	case ']':
-		l.state = SubscriptState
-		l.r.Move(1)
-		tt = PunctuatorToken
-	case '[', ';', ',', '~', '?', ':':
-		l.state = ExprState
-		l.r.Move(1)
-		tt = PunctuatorToken
-	case '-', '!', '+', '*', '%', '&', '|', '=', '<', '>':
-		if l.consumePunctuatorToken() {
-			l.state = ExprState
-			tt = PunctuatorToken
+		return &Token{Type: NumericToken, Value: l.r.Shift()}
+	case c == '"':
+		if !l.consumeStringToken() {
+			return &Token{Type: ErrToken, Value: l.r.Shift()}
 		}
-	case '/':
-		if l.consumePunctuatorToken() {
-			l.state = ExprState
-			tt = PunctuatorToken
-		}
-	case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9', '.':
-		if l.consumeNumericToken() {
-			tt = NumericToken
-			l.state = SubscriptState
-		} else if c == '.' {
-			l.state = PropNameState
-			l.r.Move(1)
-			tt = PunctuatorToken
+		return &Token{Type: StringToken, Value: l.r.Shift()}
+	case c == '/' && l.consumeCommentToken():
+		return &Token{Type: CommentToken, Value: l.r.Shift()}
+	case l.consumePunctuatorToken():
+		raw := l.r.Shift()
+		if p, ok := digraphs[string(raw)]; ok {
+			return &Token{Type: PunctuatorToken, Value: []byte(p), Raw: raw}
 		}
-	case ' ', '\t', '\v', '\f':
-		l.r.Move(1)
+		return &Token{Type: PunctuatorToken, Value: raw}
+	case l.consumeWhitespace():
 		for l.consumeWhitespace() {
 		}
 		return &Token{Type: WhitespaceToken, Value: l.r.Shift()}
-	case '\n', '\r':
-		l.r.Move(1)
+	case l.consumeLineTerminator():
+		l.file.AddLine(l.r.Offset())
 		for l.consumeLineTerminator() {
+			l.file.AddLine(l.r.Offset())
 		}
-		tt = LineTerminatorToken
-	default:
-		if l.consumeIdentifierToken() {
-			tt = IdentifierToken
-		} else if c >= 0xC0 {
-			if l.consumeWhitespace() {
-				for l.consumeWhitespace() {
-				}
-				return &Token{Type: WhitespaceToken, Value: l.r.Shift()}
-			}
+		return &Token{Type: LineTerminatorToken, Value: l.r.Shift()}
+	case l.consumeIdentifierToken():
+		raw := l.r.Shift()
+		if id := decodeUniversalCharacterNames(raw); len(id) != len(raw) {
+			return &Token{Type: IdentifierToken, Value: id, Raw: raw}
 		}
+		return &Token{Type: IdentifierToken, Value: raw}
+	case c == '\\' && l.consumeIncompleteUniversalCharacterName():
+		return &Token{Type: ErrToken, Value: l.r.Shift()}
+	case c == 0 && l.r.Err() != nil:
+		// Nothing is left to read, Err tells whether the end was reached or the reader failed
+		return &Token{Type: EOFToken}
 	}
+	l.reportStray()
+	return &Token{Type: ErrToken, Value: l.r.Shift()}
+}
 
-	if tt == UnknownToken {
-		_, n := l.r.PeekRune(0)
-		l.r.Move(n)
+// reportStray consumes a character that cannot start any token and reports it
+// An invalid UTF-8 sequence is reported one byte at a time
+func (l *Lexer) reportStray() {
+	r, n := l.r.PeekRune(0)
+	if r == utf8.RuneError && n == 1 || r < ' ' || r == 0x7F {
+		l.errorf(l.r.Offset(), "stray '\\%o' in program", l.r.Peek(0))
+	} else {
+		l.errorf(l.r.Offset(), "stray '%c' in program", r)
 	}
-
-	return &Token{Type: tt, Value: l.r.Shift()}
+	l.r.Move(n)
 }
 
-func (l *Lexer) consumePunctuatorToken() bool {
-	c := l.r.Peek(0)
-	if c == '!' || c == '=' || c == '+' || c == '-' || c == '*' || c == '/' || c == '%' || c == '&' || c == '|' || c == '^' {
-		l.r.Move(1)
-		if l.r.Peek(0) == '=' {
-			l.r.Move(1)
-		} else if (c == '+' || c == '-' || c == '&' || c == '|') && l.r.Peek(0) == c {
-			l.r.Move(1)
-		} else if c == '=' && l.r.Peek(0) == '>' {
-			l.r.Move(1)
-		}
-	} else { // c == '<' || c == '>'
-		l.r.Move(1)
-		if l.r.Peek(0) == c { // >> or <<
+// consumeCommentToken reads a "//" comment up to the end of the line or a "/* */" comment
+// The lines of a block comment are added to the line table
+func (l *Lexer) consumeCommentToken() bool {
+	switch l.r.Peek(1) {
+	case '/':
+		l.r.Move(2)
+		for c := l.r.Peek(0); c != '\n' && c != '\r' && (c != 0 || l.r.Err() == nil); c = l.r.Peek(0) {
 			l.r.Move(1)
 		}
-		if l.r.Peek(0) == '=' { // >= or <=
-			l.r.Move(1)
+		return true
+	case '*':
+		offset := l.r.Offset()
+		l.r.Move(2)
+		for {
+			c := l.r.Peek(0)
+			switch {
+			case c == '*' && l.r.Peek(1) == '/':
+				l.r.Move(2)
+				return true
+			case c == 0 && l.r.Err() != nil:
+				l.errorf(offset, "unterminated comment")
+				return true
+			case l.consumeLineTerminator():
+				l.file.AddLine(l.r.Offset())
+			default:
+				l.r.Move(1)
+			}
 		}
 	}
-	return true
+	return false
 }
 
 func (l *Lexer) consumeWhitespace() bool {
 	c := l.r.Peek(0)
-	l.r.Peek(0)
 	if c == ' ' || c == '\t' || c == '\v' || c == '\f' {
 		l.r.Move(1)
 		return true
-	} else if c > 0xC0 {
-		if r, n := l.r.PeekRune(0); r == '\u00A0' || r == '\uFFEF' || unicode.Is(unicode.Zs, r) {
+	} else if c >= utf8.RuneSelf {
+		if r, n := l.r.PeekRune(0); r == '\u00A0' || r == '\uFEFF' || unicode.Is(unicode.Zs, r) {
 			l.r.Move(n)
 			return true
 		}
@@ -183,7 +195,7 @@ func (l *Lexer) consumeLineTerminator() bool {
 			l.r.Move(1)
 		}
 		return true
-	} else if c > 0xC0 {
+	} else if c >= utf8.RuneSelf {
 		if r, n := l.r.PeekRune(0); r == '\u2028' || r == '\u2029' {
 			l.r.Move(n)
 			return true
@@ -192,14 +204,80 @@ func (l *Lexer) consumeLineTerminator() bool {
 	return false
 }
 
-func (l *Lexer) consumeTemplateToken() bool {
-	return false
+// consumeStringToken reads a string literal with its quotes, escape sequences are kept as written
+// A string literal cannot span several lines, an unterminated one is reported and consumed up to the end of the line
+func (l *Lexer) consumeStringToken() bool {
+	offset := l.r.Offset()
+	l.r.Move(1)
+	for {
+		switch c := l.r.Peek(0); {
+		case c == '"':
+			l.r.Move(1)
+			return true
+		case c == '\\' && l.r.Peek(1) != '\n' && l.r.Peek(1) != '\r' && (l.r.Peek(1) != 0 || l.r.PeekErr(1) == nil):
+			l.r.Move(2)
+		case c == '\n' || c == '\r' || (c == 0 && l.r.Err() != nil):
+			l.errorf(offset, "missing terminating '\"' character")
+			return false
+		default:
+			l.r.Move(1)
+		}
+	}
 }
 
+// consumeNumericToken reads a decimal, octal or hexadecimal integer constant with its suffix
+// An invalid digit or suffix is reported and the whole constant is consumed
 func (l *Lexer) consumeNumericToken() bool {
-	if l.consumeDigit() {
-		for l.consumeDigit() {
+	offset := l.r.Offset()
+	if !l.consumeDigit() {
+		return false
+	}
+	hex := false
+	if c := l.r.Peek(0); l.r.Pos() == 1 && l.r.Lexeme()[0] == '0' && (c == 'x' || c == 'X') {
+		if _, ok := hexDigit(l.r.Peek(1)); ok {
+			l.r.Move(2)
+			hex = true
 		}
+	}
+	for {
+		if hex {
+			if _, ok := hexDigit(l.r.Peek(0)); !ok {
+				break
+			}
+			l.r.Move(1)
+		} else if !l.consumeDigit() {
+			break
+		}
+	}
+	digits := string(l.r.Lexeme())
+	for l.consumeIdentifierRune(false) {
+	}
+	suffix := string(l.r.Lexeme()[len(digits):])
+
+	valid := true
+	if !hex && len(digits) > 1 && digits[0] == '0' {
+		if i := strings.IndexAny(digits, "89"); i >= 0 {
+			l.errorf(offset+i, "invalid digit \"%c\" in octal constant", digits[i])
+			valid = false
+		}
+	}
+	if !isIntegerSuffix(suffix) {
+		l.errorf(offset, "invalid suffix \"%s\" on integer constant", suffix)
+		valid = false
+	}
+	return valid
+}
+
+// isIntegerSuffix returns whether or not s is a valid integer suffix, made of an optional "u"
+// and an optional "l" or "ll" in any order, "ll" cannot mix cases
+func isIntegerSuffix(s string) bool {
+	if len(s) > 0 && (s[0] == 'u' || s[0] == 'U') {
+		s = s[1:]
+	} else if n := len(s); n > 0 && (s[n-1] == 'u' || s[n-1] == 'U') {
+		s = s[:n-1]
+	}
+	switch s {
+	case "", "l", "L", "ll", "LL":
 		return true
 	}
 	return false
@@ -214,22 +292,134 @@ func (l *Lexer) consumeDigit() bool {
 }
 
 func (l *Lexer) consumeIdentifierToken() bool {
-	c := l.r.Peek(0)
-	if (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '$' || c == '_' {
-		l.r.Move(1)
-	} else if c < 0xC0 {
+	if !l.consumeIdentifierRune(true) {
 		return false
-	} else {
+	}
+	for l.consumeIdentifierRune(false) {
+	}
+	return true
+}
+
+// consumeIdentifierRune consumes a character of an identifier, written as is or as a universal character name
+// Digits and combining marks are only allowed after the first character
+func (l *Lexer) consumeIdentifierRune(first bool) bool {
+	c := l.r.Peek(0)
+	r, n := rune(c), 1
+	if c == '\\' {
+		r, n = l.peekUniversalCharacterName()
+		if n == 0 {
+			return false
+		}
+	} else if c >= utf8.RuneSelf {
+		r, n = l.r.PeekRune(0)
+		if r == utf8.RuneError && n == 1 {
+			return false
+		}
+	}
+	if c == '\\' {
+		// A universal character name is kept in the identifier even when it is not valid there
+		// so lexing goes on after the error
+		if !isValidUniversalCharacter(r) || !IsIdentifierContinue(r) {
+			l.errorf(l.r.Offset(), "universal character %s is not valid in an identifier", l.peekString(n))
+		} else if first && !IsIdentifierStart(r) {
+			l.errorf(l.r.Offset(), "universal character %s is not valid at the start of an identifier", l.peekString(n))
+		}
+	} else if (first && !IsIdentifierStart(r)) || (!first && !IsIdentifierContinue(r)) {
 		return false
 	}
-	// Deal with unicode
-	for {
-		c := l.r.Peek(0)
-		if (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '$' || c == '_' {
-			l.r.Move(1)
-		} else {
-			break
+	l.r.Move(n)
+	return true
+}
+
+// peekString returns the n bytes at the cursor
+func (l *Lexer) peekString(n int) string {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = l.r.Peek(i)
+	}
+	return string(b)
+}
+
+// peekUniversalCharacterName decodes the \uXXXX or \UXXXXXXXX universal character name at the cursor
+// and returns its length, the length is 0 when the cursor is not on a complete universal character name
+func (l *Lexer) peekUniversalCharacterName() (rune, int) {
+	digits := 0
+	switch l.r.Peek(1) {
+	case 'u':
+		digits = 4
+	case 'U':
+		digits = 8
+	default:
+		return 0, 0
+	}
+	var r rune
+	for i := 0; i < digits; i++ {
+		d, ok := hexDigit(l.r.Peek(2 + i))
+		if !ok {
+			return 0, 0
 		}
+		r = r*16 + d
+	}
+	return r, 2 + digits
+}
+
+// consumeIncompleteUniversalCharacterName consumes a "\u" or "\U" followed by too few hexadecimal digits and reports it
+func (l *Lexer) consumeIncompleteUniversalCharacterName() bool {
+	if c := l.r.Peek(1); c != 'u' && c != 'U' {
+		return false
 	}
+	n := 2
+	for _, ok := hexDigit(l.r.Peek(n)); ok; _, ok = hexDigit(l.r.Peek(n)) {
+		n++
+	}
+	l.errorf(l.r.Offset(), "incomplete universal character name %s", l.peekString(n))
+	l.r.Move(n)
 	return true
 }
+
+// isValidUniversalCharacter returns whether or not a universal character name may spell r
+// They cannot spell the basic character set nor surrogates
+func isValidUniversalCharacter(r rune) bool {
+	return (r >= 0xA0 || r == '$' || r == '@' || r == '`') && (r < 0xD800 || r > 0xDFFF) && r <= unicode.MaxRune
+}
+
+// hexDigit returns the value of a hexadecimal digit
+func hexDigit(c byte) (rune, bool) {
+	switch {
+	case c >= '0' && c <= '9':
+		return rune(c - '0'), true
+	case c >= 'a' && c <= 'f':
+		return rune(c - 'a' + 10), true
+	case c >= 'A' && c <= 'F':
+		return rune(c - 'A' + 10), true
+	}
+	return 0, false
+}
+
+// decodeUniversalCharacterNames returns an identifier with its universal character names encoded in UTF-8
+// so both spellings of a character name the same identifier
+func decodeUniversalCharacterNames(id []byte) []byte {
+	if bytes.IndexByte(id, '\\') < 0 {
+		return id
+	}
+	decoded := make([]byte, 0, len(id))
+	for i := 0; i < len(id); i++ {
+		if id[i] != '\\' {
+			decoded = append(decoded, id[i])
+			continue
+		}
+		digits := 4
+		if id[i+1] == 'U' {
+			digits = 8
+		}
+		var r rune
+		for _, c := range id[i+2 : i+2+digits] {
+			d, _ := hexDigit(c)
+			r = r*16 + d
+		}
+		var buf [utf8.UTFMax]byte
+		decoded = append(decoded, buf[:utf8.EncodeRune(buf[:], r)]...)
+		i += 1 + digits
+	}
+	return decoded
+}
@@ -0,0 +1,53 @@
+package lexer
+
+import "sort"
+
+// punctuators lists the punctuators of C11 6.4.6
+var punctuators = []string{
+	"[", "]", "(", ")", "{", "}", ".", "->",
+	"++", "--", "&", "*", "+", "-", "~", "!",
+	"/", "%", "<<", ">>", "<", ">", "<=", ">=", "==", "!=", "^", "|", "&&", "||",
+	"?", ":", ";", "...",
+	"=", "*=", "/=", "%=", "+=", "-=", "<<=", ">>=", "&=", "^=", "|=",
+	",", "#", "##",
+	"<:", ":>", "<%", "%>", "%:", "%:%:",
+}
+
+// digraphs gives the punctuator each digraph stands for, they behave the same except for their spelling
+var digraphs = map[string]string{
+	"<:": "[", ":>": "]", "<%": "{", "%>": "}", "%:": "#", "%:%:": "##",
+}
+
+// punctuatorTable holds the punctuators by their first byte, the longest ones first
+var punctuatorTable [256][]string
+
+func init() {
+	for _, p := range punctuators {
+		punctuatorTable[p[0]] = append(punctuatorTable[p[0]], p)
+	}
+	for _, list := range punctuatorTable {
+		sort.SliceStable(list, func(i, j int) bool { return len(list[i]) > len(list[j]) })
+	}
+}
+
+// consumePunctuatorToken reads the longest punctuator at the cursor
+// e.g. "a-->b" gives "--" then ">" and "..." is only read when the three dots are there
+func (l *Lexer) consumePunctuatorToken() bool {
+	for _, p := range punctuatorTable[l.r.Peek(0)] {
+		if l.hasPrefix(p) {
+			l.r.Move(len(p))
+			return true
+		}
+	}
+	return false
+}
+
+// hasPrefix returns whether or not the bytes at the cursor start with s
+func (l *Lexer) hasPrefix(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if l.r.Peek(i) != s[i] {
+			return false
+		}
+	}
+	return true
+}
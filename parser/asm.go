@@ -0,0 +1,188 @@
+package parser
+
+import (
+	"compiler/ast"
+	"compiler/lexer"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ParseAsmStatement will return an AsmStatement from the next tokens
+// It follows this grammar
+// <asm_statement> ::= "asm" [ "volatile" ] "(" <string> [ ":" <operands> [ ":" <operands> [ ":" <clobbers> ] ] ] ")" ";"
+// <operands> ::= [ <string> "(" <exp> ")" { "," <string> "(" <exp> ")" } ]
+// <clobbers> ::= [ <string> { "," <string> } ]
+func (p *Parser) ParseAsmStatement() (ast.Statement, error) {
+	token := p.Next()
+	volatile := false
+	switch string(p.Peek(0).Value) {
+	case "volatile", "__volatile", "__volatile__":
+		volatile = true
+		p.Next()
+	}
+	if _, err := p.Expect("("); err != nil {
+		return nil, err
+	}
+	template, err := p.ParseStringLiteral()
+	if err != nil {
+		return nil, err
+	}
+	if p.Accept(")") {
+		if _, err := p.Expect(";"); err != nil {
+			return nil, err
+		}
+		return ast.NewAsmStatement(token, volatile, template, nil, nil, nil)
+	}
+	operands := make([][]ast.AsmOperand, 0, 2)
+	for len(operands) < 2 && p.Accept(":") {
+		list, err := p.ParseAsmOperands()
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, list)
+	}
+	for len(operands) < 2 {
+		operands = append(operands, []ast.AsmOperand{})
+	}
+	clobbers := make([]string, 0)
+	if p.Accept(":") {
+		for p.Peek(0).Type == lexer.StringToken {
+			clobber, err := p.ParseStringLiteral()
+			if err != nil {
+				return nil, err
+			}
+			clobbers = append(clobbers, clobber)
+			if !p.Accept(",") {
+				break
+			}
+		}
+	}
+	if !p.Accept(")") {
+		return nil, p.errorf(p.Peek(0), "Expected ':' or ')' in asm statement got %s", describe(p.Peek(0)))
+	}
+	if !p.Accept(";") {
+		return nil, p.errorf(p.Peek(0), "Expected ';' after asm statement got %s", describe(p.Peek(0)))
+	}
+	return ast.NewAsmStatement(token, volatile, template, operands[0], operands[1], clobbers)
+}
+
+// ParseAsmOperands will return the operands of an asm statement up to the next ":" or ")"
+func (p *Parser) ParseAsmOperands() ([]ast.AsmOperand, error) {
+	list, err := ast.NewAsmOperandList()
+	if err != nil {
+		return nil, err
+	}
+	for !p.Is(":") && !p.Is(")") {
+		if len(list) != 0 && !p.Accept(",") {
+			return nil, p.errorf(p.Peek(0), "Expected ',' or ':' in asm operands got %s", describe(p.Peek(0)))
+		}
+		constraint, err := p.ParseStringLiteral()
+		if err != nil {
+			return nil, err
+		}
+		if !p.Accept("(") {
+			return nil, p.errorf(p.Peek(0), "Expected '(' after asm operand constraint \"%s\"", constraint)
+		}
+		exp, err := p.ParseExpression()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.Expect(")"); err != nil {
+			return nil, err
+		}
+		list, err = ast.AppendAsmOperand(list, constraint, exp)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return list, nil
+}
+
+// ParseStringLiteral will return the value of the next string literals, adjacent literals are concatenated
+func (p *Parser) ParseStringLiteral() (string, error) {
+	if p.Peek(0).Type != lexer.StringToken {
+		return "", p.errorf(p.Peek(0), "Expected string literal got %s", describe(p.Peek(0)))
+	}
+	var sb strings.Builder
+	for p.Peek(0).Type == lexer.StringToken {
+		t := p.Next()
+		s, err := unescapeString(t.Value)
+		if err != nil {
+			return "", p.errorf(t, "%s", err)
+		}
+		sb.WriteString(s)
+	}
+	return sb.String(), nil
+}
+
+// unescapeString decodes the escape sequences of a string literal and removes its quotes
+func unescapeString(literal []byte) (string, error) {
+	s := literal[1 : len(literal)-1]
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			sb.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch c := s[i]; c {
+		case 'n':
+			sb.WriteByte('\n')
+		case 't':
+			sb.WriteByte('\t')
+		case 'r':
+			sb.WriteByte('\r')
+		case 'a':
+			sb.WriteByte('\a')
+		case 'b':
+			sb.WriteByte('\b')
+		case 'f':
+			sb.WriteByte('\f')
+		case 'v':
+			sb.WriteByte('\v')
+		case '\\', '\'', '"', '?':
+			sb.WriteByte(c)
+		case 'x':
+			value, n := 0, 0
+			for ; i+1 < len(s) && isHexDigit(s[i+1]); i++ {
+				value = value*16 + hexValue(s[i+1])
+				n++
+			}
+			if n == 0 {
+				return "", errors.New("\\x used with no following hex digits")
+			}
+			if value > 0xFF {
+				return "", errors.New("Hex escape sequence out of range")
+			}
+			sb.WriteByte(byte(value))
+		case '0', '1', '2', '3', '4', '5', '6', '7':
+			value := int(c - '0')
+			for n := 1; n < 3 && i+1 < len(s) && s[i+1] >= '0' && s[i+1] <= '7'; n++ {
+				i++
+				value = value*8 + int(s[i]-'0')
+			}
+			if value > 0xFF {
+				return "", errors.New("Octal escape sequence out of range")
+			}
+			sb.WriteByte(byte(value))
+		default:
+			return "", fmt.Errorf("Unknown escape sequence '\\%c'", c)
+		}
+	}
+	return sb.String(), nil
+}
+
+func isHexDigit(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+func hexValue(c byte) int {
+	switch {
+	case c >= 'a':
+		return int(c-'a') + 10
+	case c >= 'A':
+		return int(c-'A') + 10
+	}
+	return int(c - '0')
+}
@@ -3,257 +3,271 @@ package parser
 import (
 	"compiler/ast"
 	"compiler/lexer"
-	"fmt"
 )
 
-// ExpressionParser parses an expression
-type ExpressionParser interface {
-	parseExpression(p *Parser, tokens []*lexer.Token) (ast.Expression, []*lexer.Token, error)
-	isValidToken(token *lexer.Token) bool
+// Operators returns the operator table of the parser, registering operators in it extends the dialect
+func (p *Parser) Operators() *OperatorTable {
+	return p.operators
 }
 
-// AdditiveExpressionParser handles
-// <additive_exp> ::= <term> { ("+" | "-") <term> }
-type AdditiveExpressionParser struct{}
-
-func (lep AdditiveExpressionParser) parseExpression(p *Parser, tokens []*lexer.Token) (ast.Expression, []*lexer.Token, error) {
-	return p.ParseTerm(tokens)
+// ParseExpressionWithPrecedence parses an expression whose operators bind at least as tight as minPrec
+// It is a Pratt parser: the first token is parsed by its prefix handler or as a primary expression,
+// then the infix and postfix operators following it are applied while they bind tight enough
+func (p *Parser) ParseExpressionWithPrecedence(minPrec int) (ast.Expression, error) {
+	t := p.Peek(0)
+	var left ast.Expression
+	var err error
+	if op := p.operators.lookupToken(t); op != nil && op.Prefix != nil {
+		left, err = op.Prefix(p, p.Next())
+	} else {
+		left, err = p.ParsePrimaryExpression()
+	}
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op := p.operators.lookupToken(p.Peek(0))
+		if op == nil || op.Precedence < minPrec || (op.Infix == nil && op.Postfix == nil) {
+			return left, nil
+		}
+		if op.Infix != nil {
+			left, err = op.Infix(p, left, p.Next())
+		} else {
+			left, err = op.Postfix(p, left, p.Next())
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
 }
 
-func (lep AdditiveExpressionParser) isValidToken(token *lexer.Token) bool {
-	s := string(token.Value)
-	return s == "+" || s == "-"
+// ParseRightOperand parses the right operand of the infix operator op, already consumed
+// A left associative operator only takes the operators binding tighter than itself
+func (p *Parser) ParseRightOperand(op *lexer.Token) (ast.Expression, error) {
+	entry := p.operators.lookupToken(op)
+	if entry == nil || entry.Infix == nil {
+		return nil, p.errorf(op, "'%s' is not an infix operator", op.Value)
+	}
+	if entry.Associativity == LeftAssoc {
+		return p.ParseExpressionWithPrecedence(entry.Precedence + 1)
+	}
+	return p.ParseExpressionWithPrecedence(entry.Precedence)
 }
 
-// RelationalExpressionParser handles
-type RelationalExpressionParser struct{}
-
-func (rep RelationalExpressionParser) parseExpression(p *Parser, tokens []*lexer.Token) (ast.Expression, []*lexer.Token, error) {
-	return p.ParseAdditiveExpression(tokens)
+// ParseExpression parses the grammar as follow
+// <exp> ::= <assignment_exp> { "," <assignment_exp> }
+func (p *Parser) ParseExpression() (ast.Expression, error) {
+	return p.ParseExpressionWithPrecedence(PrecComma)
 }
 
-func (rep RelationalExpressionParser) isValidToken(token *lexer.Token) bool {
-	s := string(token.Value)
-	return s == "<" || s == ">" || s == "<=" || s == ">="
+// ParseAssignmentExpression parses the grammar as follow
+// <assignment_exp> ::= <logical_or_exp> [ <assign_op> <assignment_exp> ]
+// The generator makes sure the left side designates an object
+func (p *Parser) ParseAssignmentExpression() (ast.Expression, error) {
+	return p.ParseExpressionWithPrecedence(PrecAssign)
 }
 
-// EqualityExpressionParser handles
-type EqualityExpressionParser struct{}
-
-func (eep EqualityExpressionParser) parseExpression(p *Parser, tokens []*lexer.Token) (ast.Expression, []*lexer.Token, error) {
-	return p.ParseRelationalExpression(tokens)
+// ParseLogicalOrExpression parses an expression without assignments nor commas, as used by constant expressions
+// <logical_or_exp> ::= <logical_and_exp> { "||" <logical_and_exp> }
+// <logical_and_exp> ::= <equality_exp> { "&&" <equality_exp> }
+// <equality_exp> ::= <relational_exp> { ("!=" | "==") <relational_exp> }
+// <relational_exp> ::= <additive_exp> { ("<" | ">" | "<=" | ">=") <additive_exp> }
+// <additive_exp> ::= <term> { ("+" | "-") <term> }
+// <term> ::= <unary_exp> { ("*" | "/" | "%") <unary_exp> }
+func (p *Parser) ParseLogicalOrExpression() (ast.Expression, error) {
+	return p.ParseExpressionWithPrecedence(PrecLogicalOr)
 }
 
-func (eep EqualityExpressionParser) isValidToken(token *lexer.Token) bool {
-	s := string(token.Value)
-	return s == "!=" || s == "=="
+// ParseUnaryExpression parses the operand of a unary operator or a cast
+// <unary_exp> ::= <postfix_exp> | "(" <type_name> ")" <unary_exp> | <sizeof_exp> | <unary_op> <unary_exp>
+func (p *Parser) ParseUnaryExpression() (ast.Expression, error) {
+	return p.ParseExpressionWithPrecedence(PrecUnary)
 }
 
-// LogicalAndExpressionParser handles
-type LogicalAndExpressionParser struct{}
-
-func (laep LogicalAndExpressionParser) parseExpression(p *Parser, tokens []*lexer.Token) (ast.Expression, []*lexer.Token, error) {
-	return p.ParseEqualityExpression(tokens)
+// ParsePrimaryExpression parses an expression that does not start with an operator
+// <primary> ::= <const> | <id> | <builtin_exp>
+func (p *Parser) ParsePrimaryExpression() (ast.Expression, error) {
+	t := p.Peek(0)
+	switch {
+	case IsConstant(t):
+		return ast.NewIntegerLiteral(p.Next())
+	case IsBuiltin(t) && string(p.Peek(1).Value) == "(":
+		return p.ParseBuiltinExpression()
+	case t.Type == lexer.IdentifierToken:
+		return ast.NewIdentifier(p.Next()), nil
+	case t.Type == lexer.EOFToken:
+		return nil, p.errorf(t, "Failed to parse factor. Expected expression got end of input")
+	}
+	return nil, p.errorf(t, "Failed to parse factor. Unexpected token %s '%s'", t.Type, t.Value)
 }
 
-func (laep LogicalAndExpressionParser) isValidToken(token *lexer.Token) bool {
-	s := string(token.Value)
-	return s == "&&"
+// parseParenthesized parses a cast or an expression in parenthesis, the "(" is already consumed
+// "(" <type_name> ")" <unary_exp> | "(" <exp> ")"
+func parseParenthesized(p *Parser, open *lexer.Token) (ast.Expression, error) {
+	if p.StartsTypeName(p.Peek(0)) {
+		t, err := p.ParseTypeName()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.Expect(")"); err != nil {
+			return nil, err
+		}
+		operand, err := p.ParseUnaryExpression()
+		if err != nil {
+			return nil, err
+		}
+		return ast.NewCastExpression(open, t, operand)
+	}
+	exp, err := p.ParseExpression()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.Expect(")"); err != nil {
+		return nil, err
+	}
+	return exp, nil
 }
 
-// LogicalOrExpressionParser handles
-type LogicalOrExpressionParser struct{}
-
-func (loep LogicalOrExpressionParser) parseExpression(p *Parser, tokens []*lexer.Token) (ast.Expression, []*lexer.Token, error) {
-	return p.ParseLogicalAndExpression(tokens)
+// parseComma returns a CommaExpression
+func parseComma(p *Parser, left ast.Expression, op *lexer.Token) (ast.Expression, error) {
+	right, err := p.ParseRightOperand(op)
+	if err != nil {
+		return nil, err
+	}
+	return ast.NewCommaExpression(op, left, right)
 }
 
-func (loep LogicalOrExpressionParser) isValidToken(token *lexer.Token) bool {
-	s := string(token.Value)
-	return s == "||"
+// parseAssign returns an AssignExpression
+func parseAssign(p *Parser, left ast.Expression, op *lexer.Token) (ast.Expression, error) {
+	right, err := p.ParseRightOperand(op)
+	if err != nil {
+		return nil, err
+	}
+	return ast.NewAssignExpression(op, left, right)
 }
 
-// TermExpressionParser handles
-type TermExpressionParser struct{}
-
-func (tep TermExpressionParser) parseExpression(p *Parser, tokens []*lexer.Token) (ast.Expression, []*lexer.Token, error) {
-	return p.ParseFactor(tokens)
+// parseIndex parses a subscript, the "[" is already consumed
+// <postfix_exp> "[" <exp> "]"
+func parseIndex(p *Parser, left ast.Expression, op *lexer.Token) (ast.Expression, error) {
+	index, err := p.ParseExpression()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.Expect("]"); err != nil {
+		return nil, err
+	}
+	return ast.NewIndexExpression(left, op, index)
 }
 
-func (tep TermExpressionParser) isValidToken(token *lexer.Token) bool {
-	s := string(token.Value)
-	return s == "*" || s == "/" || s == "%"
+// parseMember parses a member access, the "." or "->" is already consumed
+// <postfix_exp> ( "." | "->" ) <id>
+func parseMember(p *Parser, left ast.Expression, op *lexer.Token) (ast.Expression, error) {
+	if p.Peek(0).Type != lexer.IdentifierToken {
+		return nil, p.errorf(p.Peek(0), "Expected member name after '%s'", op.Value)
+	}
+	return ast.NewMemberExpression(left, op, p.Next())
 }
 
-// GetExpressionFromParser will return the expression for a given set of tokens and a parser
-func (p *Parser) GetExpressionFromParser(parser ExpressionParser, tokens []*lexer.Token) (ast.Expression, []*lexer.Token, error) {
-	// First tokens have to be a term
-	exp, tokens, err := parser.parseExpression(p, tokens)
+// parseCall parses the arguments of a call, the "(" is already consumed
+// <postfix_exp> "(" [ <assignment_exp> { "," <assignment_exp> } ] ")"
+func parseCall(p *Parser, left ast.Expression, op *lexer.Token) (ast.Expression, error) {
+	args, err := ast.NewExpressionList()
 	if err != nil {
-		return nil, tokens, err
+		return nil, err
 	}
-	// There will be more terms if the following tokens are + or -
-	for {
-		// Ignore if we reached the end
-		if len(tokens) == 0 {
-			return exp, tokens, nil
+	for !p.Accept(")") {
+		if len(args) != 0 && !p.Accept(",") {
+			return nil, p.errorf(p.Peek(0), "Expected ',' or ')' got %s", describe(p.Peek(0)))
 		}
-		// Get the first token
-		t := tokens[0]
-		isValid := parser.isValidToken(t)
-		if !isValid {
-			break
-		}
-		// Remove the accepted token
-		tokens = tokens[1:]
-		// Prepare a variable to host the expression
-		// This is to make sure we can use the = operator and not :=
-		// The second one would create a copy of the tokens array in the scope
-		var nextTerm ast.Expression
-		nextTerm, tokens, err = parser.parseExpression(p, tokens)
+		arg, err := p.ParseAssignmentExpression()
 		if err != nil {
-			return nil, tokens, err
+			return nil, err
 		}
-		// Got all the valid bits, build the infox expression
-		exp, err = ast.NewInfixExpression(t, exp, nextTerm)
+		args, err = ast.AppendExpression(args, arg)
 		if err != nil {
-			return nil, tokens, err
+			return nil, err
 		}
 	}
-	return exp, tokens, nil
+	return ast.NewCallExpression(left, op, args)
 }
 
-func IsValidAssignOperator(op string) bool {
-	return op == "=" || op == "+=" || op == "-=" || op == "*=" || op == "/="
+// IsBuiltin will return a boolean indicating whether or not a given token
+// names a builtin of the compiler
+func IsBuiltin(t *lexer.Token) bool {
+	switch string(t.Value) {
+	case "va_start", "va_arg", "va_end":
+		return t.Type == lexer.IdentifierToken
+	}
+	return false
 }
 
-// ParseExpression parses the grammar as follow
-// <exp> ::= <id> "=" <exp> | <logical_or_exp>
-func (p *Parser) ParseExpression(tokens []*lexer.Token) (ast.Expression, []*lexer.Token, error) {
-	tName := tokens[0]
-	if tName.Type == lexer.IdentifierToken {
-		if len(tokens) == 1 {
-			// No more token, it's a lonely identifier
-			return p.ParseLogicalOrExpression(tokens)
-		}
-		tOp := tokens[1]
-		if !IsValidAssignOperator(string(tOp.Value)) {
-			// Not a valid assigment, must be an expression
-			return p.ParseLogicalOrExpression(tokens)
+// ParseBuiltinExpression will return a BuiltinExpression from the next tokens
+// va_arg takes a type name as its last argument
+// <builtin_exp> ::= <builtin> "(" <assignment_exp> { "," <assignment_exp> } ")" | "va_arg" "(" <assignment_exp> "," <type_name> ")"
+func (p *Parser) ParseBuiltinExpression() (ast.Expression, error) {
+	name := p.Next()
+	if _, err := p.Expect("("); err != nil {
+		return nil, err
+	}
+	args, err := ast.NewExpressionList()
+	if err != nil {
+		return nil, err
+	}
+	var typeName ast.Attrib
+	for !p.Accept(")") {
+		if len(args) != 0 {
+			if !p.Accept(",") {
+				return nil, p.errorf(p.Peek(0), "Expected ',' or ')' got %s", describe(p.Peek(0)))
+			}
+			if string(name.Value) == "va_arg" {
+				t, err := p.ParseTypeName()
+				if err != nil {
+					return nil, err
+				}
+				if _, err := p.Expect(")"); err != nil {
+					return nil, err
+				}
+				typeName = t
+				break
+			}
 		}
-		// consume the identifier and operator
-		tokens = tokens[2:]
-		exp, tokens, err := p.ParseExpression(tokens)
+		arg, err := p.ParseAssignmentExpression()
 		if err != nil {
-			return nil, tokens, err
+			return nil, err
 		}
-		nextExp, err := ast.NewAssignExpression(tOp, tName, exp)
+		args, err = ast.AppendExpression(args, arg)
 		if err != nil {
-			return nil, tokens, err
+			return nil, err
 		}
-		return nextExp, tokens, nil
 	}
-	return p.ParseLogicalOrExpression(tokens)
-}
-
-// ParseLogicalOrExpression parses the grammar as follow
-// <logical_or_exp> ::= <logical_and_exp> { "||" <logical_and_exp> }
-func (p *Parser) ParseLogicalOrExpression(tokens []*lexer.Token) (ast.Expression, []*lexer.Token, error) {
-	return p.GetExpressionFromParser(&LogicalOrExpressionParser{}, tokens)
+	return ast.NewBuiltinExpression(name, args, typeName)
 }
 
-// ParseLogicalAndExpression parses the grammar as follow
-// <logical_and_exp> ::= <equality_exp> { "&&" <equality_exp> }
-func (p *Parser) ParseLogicalAndExpression(tokens []*lexer.Token) (ast.Expression, []*lexer.Token, error) {
-	return p.GetExpressionFromParser(&LogicalAndExpressionParser{}, tokens)
-}
-
-// ParseEqualityExpression parses the grammar as follow
-// <equality_exp> ::= <relational_exp> { ("!=" | "==") <relational_exp> }
-func (p *Parser) ParseEqualityExpression(tokens []*lexer.Token) (ast.Expression, []*lexer.Token, error) {
-	return p.GetExpressionFromParser(&EqualityExpressionParser{}, tokens)
-}
-
-// ParseRelationalExpression parses the grammar as follow
-// <relational_exp> ::= <additive_exp> { ("<" | ">" | "<=" | ">=") <additive_exp> }
-func (p *Parser) ParseRelationalExpression(tokens []*lexer.Token) (ast.Expression, []*lexer.Token, error) {
-	return p.GetExpressionFromParser(&RelationalExpressionParser{}, tokens)
-}
-
-// ParseAdditiveExpression returns an expression tree based on provided tokens
-// Returns an Expression, a new array of left over tokens and an optional error
-// Handles the following scenario
-// <additive_exp> ::= <term> { ("+" | "-") <term> }
-func (p *Parser) ParseAdditiveExpression(tokens []*lexer.Token) (ast.Expression, []*lexer.Token, error) {
-	return p.GetExpressionFromParser(&AdditiveExpressionParser{}, tokens)
-}
-
-// ParseTerm will build and expression matching the following grammar for given tokens
-// <term> ::= <factor> { ("*" | "/") <factor> }
-// It will return an Expression, the remaining tokens and an optional error
-func (p *Parser) ParseTerm(tokens []*lexer.Token) (ast.Expression, []*lexer.Token, error) {
-	return p.GetExpressionFromParser(&TermExpressionParser{}, tokens)
-}
-
-// ParseFactor will return an Expression and the remaining tokens
-// for a given array of tokens following this grammar
-// <factor> ::= "(" <exp> ")" | <unary_op> <factor> | <const>
-func (p *Parser) ParseFactor(tokens []*lexer.Token) (ast.Expression, []*lexer.Token, error) {
-	// Extract the first token to try to match one of the 3 options
-	t := tokens[0]
-	var exp ast.Expression
-	var err error
-	if string(t.Value) == "(" {
-		// Matches the expression in parenthesis
-		// "(" <exp> ")"
-		tokens = tokens[1:]
-		exp, tokens, err = p.ParseExpression(tokens)
+// parseSizeof parses the operand of sizeof, "sizeof" is already consumed
+// <sizeof_exp> ::= "sizeof" "(" <type_name> ")" | "sizeof" <unary_exp>
+func parseSizeof(p *Parser, op *lexer.Token) (ast.Expression, error) {
+	if p.Is("(") && p.StartsTypeName(p.Peek(1)) {
+		p.Next()
+		t, err := p.ParseTypeName()
 		if err != nil {
-			return nil, tokens, err
+			return nil, err
 		}
-		t = tokens[0]
-		if string(t.Value) != ")" {
-			return nil, tokens, fmt.Errorf("Expected ')' got '%s'", t.Value)
+		if _, err := p.Expect(")"); err != nil {
+			return nil, err
 		}
-		tokens = tokens[1:]
-		return exp, tokens, nil
-	} else if IsUnaryOp(t) {
-		// Matches an unary operation
-		// <unary_op> <factor>
-		tokens = tokens[1:]
-		var fact ast.Expression
-		fact, tokens, err = p.ParseFactor(tokens)
-		if err != nil {
-			return nil, tokens, err
-		}
-		exp, err = ast.NewPrefixExpression(t, fact)
-		if err != nil {
-			return nil, tokens, err
-		}
-		return exp, tokens, nil
-	} else if IsConstant(t) {
-		// Matches a constant
-		// <const>
-		tokens = tokens[1:]
-		intLit, err := ast.NewIntegerLiteral(t)
-		if err != nil {
-			return nil, tokens, err
-		}
-		return intLit, tokens, nil
-	} else if t.Type == lexer.IdentifierToken {
-		tokens = tokens[1:]
-		id := ast.NewIdentifier(t)
-		return id, tokens, nil
-	} else {
-		return nil, tokens, fmt.Errorf("Failed to parse factor. Unexpected token %s '%s'", t, t.Value)
+		return ast.NewSizeofExpression(op, t)
+	}
+	operand, err := p.ParseUnaryExpression()
+	if err != nil {
+		return nil, err
 	}
+	return ast.NewSizeofExpression(op, operand)
 }
 
-// IsUnaryOp will return a boolean indicating whether or not a given token
-// starts an unary operation
-func IsUnaryOp(t *lexer.Token) bool {
-	s := string(t.Value)
-	return s == "-" || s == "!" || s == "~"
+// StartsTypeName will return a boolean indicating whether or not a token starts a type name,
+// telling a cast apart from a parenthesized expression
+func (p *Parser) StartsTypeName(t *lexer.Token) bool {
+	return IsTypeSpecifier(t) || IsTypeQualifier(t) || p.IsTypedefName(t)
 }
 
 // IsConstant will returna boolean indicating whether or not a given token
@@ -0,0 +1,233 @@
+package parser
+
+//go:generate go run ../tools/lrgen -o lr_tables.go -imports compiler/ast,compiler/lexer,compiler/types c.bnf
+
+import (
+	"compiler/ast"
+	"compiler/lexer"
+	"io"
+	"strings"
+)
+
+// LRParser builds the AST with the LALR(1) parser generated from the grammar of c.bnf
+// It reads the same language as Parser and gives the same AST, which makes both parsers check each other
+type LRParser struct {
+	l *lexer.Lexer
+	// err is set when the lexer could not read its source
+	err error
+	*Scopes
+	// comments holds the comments skipped so far
+	comments []*lexer.Token
+	// tokens holds every token read when keepTokens is set, whitespace and comments included
+	tokens     []*lexer.Token
+	keepTokens bool
+}
+
+// lrProduction is a production of the grammar, reducing it replaces the values of its size symbols
+// by the one given by reduce, or by the value of its first symbol without reduce
+type lrProduction struct {
+	lhs    int
+	size   int
+	reduce func(p *LRParser, X []ast.Attrib) (ast.Attrib, error)
+}
+
+// lrActionTable and lrGotoTable are the dense forms of lrActions and lrGotos, indexed by state then symbol
+var (
+	lrActionTable   [][]int
+	lrGotoTable     [][]int
+	lrTerminalIndex = map[string]int{}
+)
+
+func init() {
+	for i, t := range lrTerminals {
+		lrTerminalIndex[t] = i
+	}
+	rules := 0
+	for _, prod := range lrProductions {
+		if prod.lhs >= rules {
+			rules = prod.lhs + 1
+		}
+	}
+	lrActionTable = make([][]int, len(lrActions))
+	lrGotoTable = make([][]int, len(lrGotos))
+	for s := range lrActions {
+		lrActionTable[s] = make([]int, len(lrTerminals))
+		for i := 0; i < len(lrActions[s]); i += 2 {
+			lrActionTable[s][lrActions[s][i]] = lrActions[s][i+1]
+		}
+		lrGotoTable[s] = make([]int, rules)
+		for i := 0; i < len(lrGotos[s]); i += 2 {
+			lrGotoTable[s][lrGotos[s][i]] = lrGotos[s][i+1]
+		}
+	}
+}
+
+// NewLRParser creates a new LR parser
+func NewLRParser(l *lexer.Lexer) *LRParser {
+	return &LRParser{l: l, Scopes: NewScopes()}
+}
+
+// ParseProgram will parse the entire source by consuming all tokens from the lexer
+// and building an AST with a Program as the root
+// The lexical errors come first like with Parser
+func (p *LRParser) ParseProgram() (*ast.Program, error) {
+	program, err := p.parse()
+	if err != nil {
+		// Read the rest of the source to report all of its lexical errors
+		for p.l.Err() == nil {
+			p.l.Next()
+		}
+	}
+	if lexErr := p.l.Errors().Err(); lexErr != nil {
+		return nil, lexErr
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	return program, err
+}
+
+// parse runs the automaton of the tables, the value of each symbol on the stack is kept along its state
+// The lookahead is only read when the state needs it, so a rule reduced without it can declare names
+// that change how it is read
+func (p *LRParser) parse() (*ast.Program, error) {
+	states := []int{0}
+	values := []ast.Attrib{nil}
+	var t *lexer.Token
+	terminal := 0
+	for {
+		state := states[len(states)-1]
+		prod := lrDefaults[state]
+		if prod == 0 {
+			if t == nil {
+				t = p.read()
+				terminal = p.terminal(t)
+			}
+			action := 0
+			if terminal >= 0 {
+				action = lrActionTable[state][terminal]
+			}
+			if action == 0 {
+				return nil, p.syntaxError(state, t)
+			}
+			if action > 0 {
+				states = append(states, action-1)
+				values = append(values, t)
+				t = nil
+				continue
+			}
+			prod = -action - 1
+			if prod == 0 {
+				return values[len(values)-1].(*ast.Program), nil
+			}
+		}
+		r := lrProductions[prod]
+		n := len(states) - r.size
+		var value ast.Attrib
+		if r.reduce != nil {
+			var err error
+			value, err = r.reduce(p, values[n:])
+			if err != nil {
+				return nil, err
+			}
+		} else if r.size > 0 {
+			value = values[n]
+		}
+		states, values = states[:n], values[:n]
+		states = append(states, lrGotoTable[states[n-1]][r.lhs])
+		values = append(values, value)
+	}
+}
+
+// read returns the next token from the lexer that is neither whitespace, a line return nor a comment
+// The comments are kept for Comments
+func (p *LRParser) read() *lexer.Token {
+	for {
+		t := p.l.Next()
+		if p.keepTokens && (len(p.tokens) == 0 || p.tokens[len(p.tokens)-1].Type != lexer.EOFToken) {
+			p.tokens = append(p.tokens, t)
+		}
+		switch t.Type {
+		case lexer.EOFToken:
+			if err := p.l.Err(); err != io.EOF && p.err == nil {
+				p.err = err
+			}
+			return t
+		case lexer.CommentToken:
+			p.comments = append(p.comments, t)
+		case lexer.WhitespaceToken, lexer.LineTerminatorToken, lexer.ErrToken:
+			// Invalid tokens were reported by the lexer, skipping them lets parsing go on
+		default:
+			return t
+		}
+	}
+}
+
+// Comments returns the comments read so far in the order they are written, like Parser.Comments
+func (p *LRParser) Comments() []*lexer.Token {
+	return p.comments
+}
+
+// KeepTokens makes the parser keep every token read from the lexer, like Parser.KeepTokens
+func (p *LRParser) KeepTokens() {
+	p.keepTokens = true
+}
+
+// Tokens returns the tokens read so far when KeepTokens was called, like Parser.Tokens
+func (p *LRParser) Tokens() []*lexer.Token {
+	return p.tokens
+}
+
+// terminal returns the terminal of the grammar a token is read as, -1 if the grammar does not use it
+// Identifiers are keywords, typedef names or plain identifiers depending on the names declared so far
+func (p *LRParser) terminal(t *lexer.Token) int {
+	switch t.Type {
+	case lexer.EOFToken:
+		return 0
+	case lexer.NumericToken:
+		return lrTerminalIndex["int_lit"]
+	case lexer.StringToken:
+		return lrTerminalIndex["string_lit"]
+	case lexer.IdentifierToken:
+		if i, ok := lrTerminalIndex["'"+string(t.Value)+"'"]; ok {
+			return i
+		}
+		if p.IsTypedefName(t) {
+			return lrTerminalIndex["typedef_name"]
+		}
+		return lrTerminalIndex["id"]
+	case lexer.PunctuatorToken:
+		if i, ok := lrTerminalIndex["'"+string(t.Value)+"'"]; ok {
+			return i
+		}
+	}
+	return -1
+}
+
+// terminalNames holds how the token classes of the grammar are written in error messages
+var terminalNames = map[string]string{
+	"$":            "end of input",
+	"id":           "identifier",
+	"typedef_name": "type name",
+	"int_lit":      "integer constant",
+	"string_lit":   "string literal",
+}
+
+// syntaxError returns the error for the token t read in state, the expected terminals are listed when there are few
+func (p *LRParser) syntaxError(state int, t *lexer.Token) error {
+	expected := make([]string, 0)
+	for terminal, action := range lrActionTable[state] {
+		if action == 0 {
+			continue
+		}
+		name := lrTerminals[terminal]
+		if n, ok := terminalNames[name]; ok {
+			name = n
+		}
+		expected = append(expected, name)
+	}
+	if len(expected) == 0 || len(expected) > 4 {
+		return errorAt(p.l, t, "Unexpected %s", describe(t))
+	}
+	return errorAt(p.l, t, "Expected %s got %s", strings.Join(expected, " or "), describe(t))
+}
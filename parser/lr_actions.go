@@ -0,0 +1,660 @@
+package parser
+
+import (
+	"compiler/ast"
+	"compiler/lexer"
+	"compiler/types"
+	"errors"
+	"fmt"
+)
+
+// The functions below are called by the actions of c.bnf, they build the AST like the methods of Parser
+// Lists start from a nil value so a single rule can both create and extend them
+
+// program splits the external declarations between the functions and the other statements
+func (p *LRParser) program(decls ast.Attrib) (*ast.Program, error) {
+	fns, err := ast.NewStatementList()
+	if err != nil {
+		return nil, err
+	}
+	stmts, err := ast.NewStatementList()
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range decls.([]ast.Statement) {
+		if _, ok := s.(*ast.FunctionStatement); ok {
+			fns, err = ast.AppendStatement(fns, s)
+		} else {
+			stmts, err = ast.AppendStatement(stmts, s)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ast.NewProgram(fns, stmts)
+}
+
+// initDeclarator is a declarator of a declaration along its initializer, init is nil without one
+type initDeclarator struct {
+	d    *declarator
+	init ast.Expression
+}
+
+func newInitDeclarator(d, init ast.Attrib) (*initDeclarator, error) {
+	decl := &initDeclarator{d: d.(*declarator)}
+	if init != nil {
+		decl.init = init.(ast.Expression)
+	}
+	return decl, nil
+}
+
+func appendInitDeclarator(list, decl ast.Attrib) ([]*initDeclarator, error) {
+	l, _ := list.([]*initDeclarator)
+	return append(l, decl.(*initDeclarator)), nil
+}
+
+// declaration returns the statement of a declaration, decls is nil when there is no declarator
+// It follows Parser.parseInitDeclarators
+func (p *LRParser) declaration(specs, decls ast.Attrib) (ast.Statement, error) {
+	s := specs.(*declSpecifiers)
+	list, _ := decls.([]*initDeclarator)
+	if len(list) == 0 {
+		if s.isDefinition && s.def == nil {
+			return nil, errors.New("Anonymous struct declares nothing")
+		}
+		// A definition alone is not grouped
+		if s.def != nil && s.storage != "typedef" {
+			return s.def, nil
+		}
+		return nil, errors.New("Expected identifier, got ';'")
+	}
+	stmts, err := ast.NewDeclList()
+	if err != nil {
+		return nil, err
+	}
+	if s.def != nil {
+		stmts, err = ast.AppendDecl(stmts, s.def)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, decl := range list {
+		stmt, err := p.initDeclaration(s, decl)
+		if err != nil {
+			return nil, err
+		}
+		stmts, err = ast.AppendDecl(stmts, stmt)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ast.NewDeclGroup(s.token, stmts)
+}
+
+// initDeclaration returns the declaration of a single declarator and declares its name
+// It follows Parser.parseInitDeclarator
+func (p *LRParser) initDeclaration(s *declSpecifiers, decl *initDeclarator) (ast.Statement, error) {
+	declType := decl.d.wrap(s.base)
+	p.DeclareName(string(decl.d.name.Value), s.storage == "typedef")
+	if s.storage == "typedef" {
+		if decl.init != nil {
+			return nil, fmt.Errorf("Typedef '%s' cannot be initialized", decl.d.name.Value)
+		}
+		return ast.NewTypedefStatement(declType, decl.d.name)
+	}
+	return ast.NewDeclStatement(s.storage, declType, decl.d.name, decl.init)
+}
+
+// externalDeclaration returns a function declaration when the declaration holds a single function declarator
+// without initializer, the statement of the declaration otherwise
+func (p *LRParser) externalDeclaration(specs, decls ast.Attrib) (ast.Statement, error) {
+	s := specs.(*declSpecifiers)
+	list := decls.([]*initDeclarator)
+	if s.storage == "typedef" || s.isDefinition || len(list) != 1 || list[0].init != nil || list[0].d.params == nil {
+		return p.declaration(specs, decls)
+	}
+	d := list[0].d
+	fn := d.wrap(s.base).(*types.Func)
+	p.DeclareName(string(d.name.Value), false)
+	return ast.NewFunctionStatement(s.storage, d.name, d.params, fn.Variadic, fn.Unprototyped, fn.Return, nil)
+}
+
+// functionHead holds a function definition until its body is read
+type functionHead struct {
+	storage string
+	name    *lexer.Token
+	params  []ast.FormalArg
+	fn      *types.Func
+	brace   *lexer.Token
+}
+
+// functionHead declares the function and opens the scope of its body where its parameters are declared
+func (p *LRParser) functionHead(specs, d, brace ast.Attrib) (*functionHead, error) {
+	s := specs.(*declSpecifiers)
+	decl := d.(*declarator)
+	if s.storage == "typedef" || s.isDefinition || decl.params == nil {
+		return nil, errorAt(p.l, brace.(*lexer.Token), "Expected ',' or ';' got '{'")
+	}
+	p.DeclareName(string(decl.name.Value), false)
+	p.EnterScope()
+	for _, arg := range decl.params {
+		p.DeclareName(arg.Arg, false)
+	}
+	return &functionHead{
+		storage: s.storage,
+		name:    decl.name,
+		params:  decl.params,
+		fn:      decl.wrap(s.base).(*types.Func),
+		brace:   brace.(*lexer.Token),
+	}, nil
+}
+
+// functionDefinition closes the scope of the body of a function and returns the function
+func (p *LRParser) functionDefinition(head, stmts, rbrace ast.Attrib) (ast.Statement, error) {
+	p.LeaveScope()
+	h := head.(*functionHead)
+	body, err := ast.NewBlockStatement(h.brace, stmts, rbrace)
+	if err != nil {
+		return nil, err
+	}
+	return ast.NewFunctionStatement(h.storage, h.name, h.params, h.fn.Variadic, h.fn.Unprototyped, h.fn.Return, body)
+}
+
+// declKeywordSpecifiers returns the specifiers of a declaration whose type is named by type keywords,
+// mixed with storage classes and qualifiers
+// It follows Parser.ParseDeclSpecifiers
+func declKeywordSpecifiers(list ast.Attrib) (*declSpecifiers, error) {
+	tokens := list.([]*lexer.Token)
+	keywords := make([]string, 0)
+	modifiers := make([]*lexer.Token, 0)
+	for _, t := range tokens {
+		if IsTypeSpecifier(t) {
+			keywords = append(keywords, string(t.Value))
+		} else {
+			modifiers = append(modifiers, t)
+		}
+	}
+	t, err := types.FromSpecifiers(keywords)
+	if err != nil {
+		return nil, err
+	}
+	s := &declSpecifiers{token: tokens[0], base: t}
+	return s, applyModifiers(s, modifiers)
+}
+
+// modifiedSpecifiers returns the specifiers of a declaration whose type is given by specs,
+// before and after are the storage classes and qualifiers around it
+func modifiedSpecifiers(before, specs, after ast.Attrib) (*declSpecifiers, error) {
+	s := specs.(*declSpecifiers)
+	s.token = firstToken(before, s.token)
+	if err := applyModifiers(s, before, after); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// applyModifiers sets the storage class and qualifies the type of s with the modifiers found in the lists of tokens
+func applyModifiers(s *declSpecifiers, lists ...ast.Attrib) error {
+	isConst, isVolatile := false, false
+	for _, list := range lists {
+		l, _ := list.([]*lexer.Token)
+		for _, t := range l {
+			switch {
+			case IsStorageClass(t):
+				if s.storage != "" {
+					return errors.New("Multiple storage classes in declaration")
+				}
+				s.storage = string(t.Value)
+			case string(t.Value) == "const":
+				isConst = true
+			default:
+				isVolatile = true
+			}
+		}
+	}
+	s.base = types.Qualify(s.base, isConst, isVolatile)
+	return nil
+}
+
+func appendToken(list, t ast.Attrib) ([]*lexer.Token, error) {
+	l, _ := list.([]*lexer.Token)
+	return append(l, t.(*lexer.Token)), nil
+}
+
+// qualifiers returns which qualifiers are found in the lists of qualifier tokens
+func qualifiers(lists ...ast.Attrib) (bool, bool) {
+	isConst, isVolatile := false, false
+	for _, list := range lists {
+		l, _ := list.([]*lexer.Token)
+		for _, t := range l {
+			if string(t.Value) == "const" {
+				isConst = true
+			} else {
+				isVolatile = true
+			}
+		}
+	}
+	return isConst, isVolatile
+}
+
+// keywordSpecifiers returns the type named by type keywords mixed with qualifiers
+func keywordSpecifiers(list ast.Attrib) (*declSpecifiers, error) {
+	tokens := list.([]*lexer.Token)
+	specs := make([]string, 0)
+	quals := make([]*lexer.Token, 0)
+	for _, t := range tokens {
+		if IsTypeQualifier(t) {
+			quals = append(quals, t)
+		} else {
+			specs = append(specs, string(t.Value))
+		}
+	}
+	t, err := types.FromSpecifiers(specs)
+	if err != nil {
+		return nil, err
+	}
+	isConst, isVolatile := qualifiers(quals)
+	return &declSpecifiers{token: tokens[0], base: types.Qualify(t, isConst, isVolatile)}, nil
+}
+
+// typedefSpecifiers returns the type named by a typedef name between qualifiers, the generator resolves it
+func typedefSpecifiers(before, name, after ast.Attrib) (*declSpecifiers, error) {
+	n := name.(*lexer.Token)
+	isConst, isVolatile := qualifiers(before, after)
+	return &declSpecifiers{
+		token: firstToken(before, n),
+		base:  types.Qualify(&types.Named{Name: string(n.Value)}, isConst, isVolatile),
+	}, nil
+}
+
+// tagSpecifiers returns the type named by an enum or struct tag between qualifiers, the generator resolves it
+func tagSpecifiers(before, keyword, name, after ast.Attrib) (*declSpecifiers, error) {
+	k := keyword.(*lexer.Token)
+	isConst, isVolatile := qualifiers(before, after)
+	t := &types.Named{Name: string(k.Value) + " " + string(name.(*lexer.Token).Value)}
+	return &declSpecifiers{token: firstToken(before, k), base: types.Qualify(t, isConst, isVolatile)}, nil
+}
+
+// firstToken returns the first token of the list, t when the list is empty
+func firstToken(list ast.Attrib, t *lexer.Token) *lexer.Token {
+	if l, _ := list.([]*lexer.Token); len(l) != 0 {
+		return l[0]
+	}
+	return t
+}
+
+// enumerator declares an enumerator and appends it to the list, a nil list starts a new enum
+// It follows Parser.ParseEnumDefinition
+func (p *LRParser) enumerator(list, name, value ast.Attrib) ([]ast.Enumerator, error) {
+	l, _ := list.([]ast.Enumerator)
+	if l == nil {
+		var err error
+		l, err = ast.NewEnumeratorList()
+		if err != nil {
+			return nil, err
+		}
+	}
+	p.DeclareName(string(name.(*lexer.Token).Value), false)
+	return ast.AppendEnumerator(l, name, value)
+}
+
+// enumBody holds the enumerators of an enum and the "}" closing them
+type enumBody struct {
+	enumerators []ast.Enumerator
+	rbrace      *lexer.Token
+}
+
+// newEnumBody returns the body of an enum, enumerators is nil for an enum without any
+func newEnumBody(enumerators, rbrace ast.Attrib) (*enumBody, error) {
+	l, _ := enumerators.([]ast.Enumerator)
+	if l == nil {
+		var err error
+		l, err = ast.NewEnumeratorList()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &enumBody{enumerators: l, rbrace: rbrace.(*lexer.Token)}, nil
+}
+
+// enumDefinition returns the specifiers defining an enum, name is nil for anonymous enums
+func enumDefinition(keyword, name, body ast.Attrib) (*declSpecifiers, error) {
+	b := body.(*enumBody)
+	stmt, err := ast.NewEnumStatement(keyword, name, b.enumerators, b.rbrace)
+	if err != nil {
+		return nil, err
+	}
+	specs := &declSpecifiers{token: keyword.(*lexer.Token), def: stmt, isDefinition: true, base: types.Int}
+	// Anonymous enums can only be referred to as ints
+	if name != nil {
+		specs.base = &types.Named{Name: "enum " + stmt.(*ast.EnumStatement).Name}
+	}
+	return specs, nil
+}
+
+// structBody holds the members of a struct as they go in its StructStatement and in its type
+// and the "}" closing them
+type structBody struct {
+	fields  []ast.StructField
+	members []types.Field
+	rbrace  *lexer.Token
+}
+
+func newStructBody() (*structBody, error) {
+	fields, err := ast.NewStructFieldList()
+	if err != nil {
+		return nil, err
+	}
+	return &structBody{fields: fields, members: make([]types.Field, 0)}, nil
+}
+
+// appendMembers appends the members declared by a member declaration to the body of a struct
+func appendMembers(body, specs, decls ast.Attrib) (*structBody, error) {
+	b := body.(*structBody)
+	base := specs.(*declSpecifiers).base
+	for _, d := range decls.([]*declarator) {
+		t := d.wrap(base)
+		var err error
+		b.fields, err = ast.AppendStructField(b.fields, d.name, t)
+		if err != nil {
+			return nil, err
+		}
+		b.members = append(b.members, types.Field{Name: string(d.name.Value), Type: t})
+	}
+	return b, nil
+}
+
+// closeStructBody records the "}" ending the members of a struct
+func closeStructBody(body, rbrace ast.Attrib) (*structBody, error) {
+	b := body.(*structBody)
+	b.rbrace = rbrace.(*lexer.Token)
+	return b, nil
+}
+
+// nestedStruct returns the specifiers of a struct defined inside another one, it has to be anonymous
+func nestedStruct(specs ast.Attrib) (*declSpecifiers, error) {
+	s := specs.(*declSpecifiers)
+	if s.def != nil {
+		return nil, fmt.Errorf("Nested definition of 'struct %s' is not supported", s.def.(*ast.StructStatement).Name)
+	}
+	return s, nil
+}
+
+// structDefinition returns the specifiers defining a struct, name is nil for anonymous structs
+// It follows Parser.ParseStructDefinition
+func structDefinition(keyword, name, body ast.Attrib) (*declSpecifiers, error) {
+	b := body.(*structBody)
+	specs := &declSpecifiers{token: keyword.(*lexer.Token), isDefinition: true}
+	if name == nil {
+		// The members are resolved by the generator along the rest of the type name
+		specs.base = &types.Struct{Fields: b.members}
+		return specs, nil
+	}
+	stmt, err := ast.NewStructStatement(keyword, name, b.fields, b.rbrace)
+	if err != nil {
+		return nil, err
+	}
+	specs.def = stmt
+	specs.base = &types.Named{Name: "struct " + stmt.(*ast.StructStatement).Name}
+	return specs, nil
+}
+
+func appendDeclarator(list, d ast.Attrib) ([]*declarator, error) {
+	l, _ := list.([]*declarator)
+	return append(l, d.(*declarator)), nil
+}
+
+// directDeclarator holds the parts of a declarator read before its pointers, see newDeclarator
+type directDeclarator struct {
+	name     *lexer.Token
+	inner    *declarator
+	suffixes []types.Type
+	params   []ast.FormalArg
+}
+
+// newDirectDeclarator returns a declarator made of either a name or a nested declarator
+func newDirectDeclarator(name, inner ast.Attrib) (*directDeclarator, error) {
+	d := &directDeclarator{}
+	if name != nil {
+		d.name = name.(*lexer.Token)
+	}
+	if inner != nil {
+		d.inner = inner.(*declarator)
+	}
+	return d, nil
+}
+
+// appendDeclaratorSuffix appends an array or a parameter list to a declarator, a nil one is abstract
+func appendDeclaratorSuffix(direct, suffix ast.Attrib) (*directDeclarator, error) {
+	d, _ := direct.(*directDeclarator)
+	if d == nil {
+		d = &directDeclarator{}
+	}
+	var t types.Type
+	switch s := suffix.(type) {
+	case *parameterList:
+		if len(d.suffixes) == 0 {
+			d.params = s.args
+		}
+		t = s.fn
+	case *types.Array:
+		t = s
+	}
+	if len(d.suffixes) != 0 {
+		if err := checkSuffix(d.suffixes[len(d.suffixes)-1], t); err != nil {
+			return nil, err
+		}
+	}
+	d.suffixes = append(d.suffixes, t)
+	return d, nil
+}
+
+// newPointerDeclarator returns the declarator made of the pointers stars applied to direct, both can be nil
+func newPointerDeclarator(stars, direct ast.Attrib) (*declarator, error) {
+	s, _ := stars.([][2]bool)
+	d, _ := direct.(*directDeclarator)
+	if d == nil {
+		d = &directDeclarator{}
+	}
+	return newDeclarator(s, d.name, d.inner, d.suffixes, d.params), nil
+}
+
+// appendPointer appends a "*" followed by the qualifiers quals to the pointers of a declarator
+func appendPointer(stars, quals ast.Attrib) ([][2]bool, error) {
+	s, _ := stars.([][2]bool)
+	isConst, isVolatile := qualifiers(quals)
+	return append(s, [2]bool{isConst, isVolatile}), nil
+}
+
+// parameterList holds the parameters of a function declarator and the function type they make,
+// its return type is left for the declarator
+type parameterList struct {
+	args []ast.FormalArg
+	fn   *types.Func
+}
+
+// parameter is a declared parameter, name is nil when it is not named
+type parameter struct {
+	name *lexer.Token
+	t    types.Type
+}
+
+func newParameter(specs, d ast.Attrib) (*parameter, error) {
+	base := specs.(*declSpecifiers).base
+	if d == nil {
+		return &parameter{t: base}, nil
+	}
+	decl := d.(*declarator)
+	return &parameter{name: decl.name, t: decl.wrap(base)}, nil
+}
+
+// appendParameter appends a parameter to a parameter list, following Parser.ParseParameterList
+func appendParameter(list, param ast.Attrib) (*parameterList, error) {
+	l, _ := list.(*parameterList)
+	if l == nil {
+		args, err := ast.NewFormalArgList()
+		if err != nil {
+			return nil, err
+		}
+		l = &parameterList{args: args, fn: &types.Func{Params: make([]types.Type, 0)}}
+	}
+	prm := param.(*parameter)
+	var name ast.Attrib
+	if prm.name != nil {
+		name = prm.name
+	}
+	var err error
+	l.args, err = ast.AppendFormalArg(l.args, name, prm.t)
+	if err != nil {
+		return nil, err
+	}
+	l.fn.Params = append(l.fn.Params, prm.t)
+	return l, nil
+}
+
+// newParameterList returns the parameter list of a function declarator, list is nil for "()"
+func newParameterList(list ast.Attrib, variadic bool) (*parameterList, error) {
+	l, _ := list.(*parameterList)
+	if l == nil {
+		args, err := ast.NewFormalArgList()
+		if err != nil {
+			return nil, err
+		}
+		// The parameters of a function declared with "()" are not specified
+		l = &parameterList{args: args, fn: &types.Func{Params: make([]types.Type, 0), Unprototyped: true}}
+	}
+	l.fn.Variadic = variadic
+	return l, nil
+}
+
+// typeName returns the type named by specifiers and an abstract declarator, as used in casts and sizeof
+func typeName(specs, d ast.Attrib) (types.Type, error) {
+	t := specs.(*declSpecifiers).base
+	if d != nil {
+		t = d.(*declarator).wrap(t)
+	}
+	return t, nil
+}
+
+// newInitializerList returns the InitializerList of a brace enclosed initializer, elements is nil for "{}"
+func newInitializerList(brace, elements ast.Attrib) (ast.Expression, error) {
+	if elements == nil {
+		var err error
+		elements, err = ast.NewInitializerElementList()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ast.NewInitializerList(brace, elements)
+}
+
+func appendInitializer(list, designators, value ast.Attrib) ([]ast.Initializer, error) {
+	if list == nil {
+		var err error
+		list, err = ast.NewInitializerElementList()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ast.AppendInitializer(list, designators, value)
+}
+
+func appendDesignator(list, designator ast.Attrib) ([]ast.Designator, error) {
+	if list == nil {
+		var err error
+		list, err = ast.NewDesignatorList()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ast.AppendDesignator(list, designator)
+}
+
+// enterScope opens the scope of a block once its "{" is read
+func (p *LRParser) enterScope(brace ast.Attrib) (ast.Attrib, error) {
+	p.EnterScope()
+	return brace, nil
+}
+
+// blockStatement closes the scope of a block and returns it
+func (p *LRParser) blockStatement(brace, stmts, rbrace ast.Attrib) (*ast.BlockStatement, error) {
+	p.LeaveScope()
+	return ast.NewBlockStatement(brace, stmts, rbrace)
+}
+
+// asmSections holds the operands and the clobbers of an extended asm statement
+type asmSections struct {
+	outputs  []ast.AsmOperand
+	inputs   []ast.AsmOperand
+	clobbers []string
+}
+
+// newAsmSections returns the sections of an extended asm statement, the missing ones are nil
+func newAsmSections(outputs, inputs, clobbers ast.Attrib) (*asmSections, error) {
+	s := &asmSections{outputs: []ast.AsmOperand{}, inputs: []ast.AsmOperand{}, clobbers: []string{}}
+	if outputs != nil {
+		s.outputs = outputs.([]ast.AsmOperand)
+	}
+	if inputs != nil {
+		s.inputs = inputs.([]ast.AsmOperand)
+	}
+	if clobbers != nil {
+		s.clobbers = clobbers.([]string)
+	}
+	return s, nil
+}
+
+// asmStatement returns an AsmStatement, a nil sections gives a basic asm statement
+func asmStatement(token, volatile, template, sections ast.Attrib) (ast.Statement, error) {
+	if sections == nil {
+		return ast.NewAsmStatement(token, volatile, template, nil, nil, nil)
+	}
+	s := sections.(*asmSections)
+	return ast.NewAsmStatement(token, volatile, template, s.outputs, s.inputs, s.clobbers)
+}
+
+func appendAsmOperand(list, constraint, exp ast.Attrib) ([]ast.AsmOperand, error) {
+	if list == nil {
+		var err error
+		list, err = ast.NewAsmOperandList()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ast.AppendAsmOperand(list, constraint, exp)
+}
+
+func appendString(list, s ast.Attrib) ([]string, error) {
+	l, _ := list.([]string)
+	return append(l, s.(string)), nil
+}
+
+// concatString appends the value of the string literal lit to prefix
+func concatString(prefix, lit ast.Attrib) (string, error) {
+	s, err := unescapeString(lit.(*lexer.Token).Value)
+	if err != nil {
+		return "", err
+	}
+	p, _ := prefix.(string)
+	return p + s, nil
+}
+
+func appendExpression(list, exp ast.Attrib) ([]ast.Expression, error) {
+	if list == nil {
+		var err error
+		list, err = ast.NewExpressionList()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ast.AppendExpression(list, exp)
+}
+
+// newVaArg returns the BuiltinExpression of va_arg, taking an expression and a type name
+func newVaArg(name, arg, typeName ast.Attrib) (*ast.BuiltinExpression, error) {
+	args, err := appendExpression(nil, arg)
+	if err != nil {
+		return nil, err
+	}
+	return ast.NewBuiltinExpression(name, args, typeName)
+}
@@ -0,0 +1,170 @@
+package parser
+
+import (
+	"compiler/ast"
+	"compiler/lexer"
+)
+
+// Binding powers of the C operators, an operator binds its operands tighter than the operators below it
+// The levels without any C operator registered are there for dialects to use
+const (
+	PrecLowest = iota
+	PrecComma
+	PrecAssign
+	PrecConditional
+	PrecLogicalOr
+	PrecLogicalAnd
+	PrecBitOr
+	PrecBitXor
+	PrecBitAnd
+	PrecEquality
+	PrecRelational
+	PrecShift
+	PrecAdditive
+	PrecMultiplicative
+	PrecUnary
+	PrecPostfix
+)
+
+// Associativity tells how a sequence of infix operators of the same binding power is grouped
+type Associativity uint32
+
+// Both possible associativities, `a - b - c` is `(a - b) - c` and `a = b = c` is `a = (b = c)`
+const (
+	LeftAssoc Associativity = iota
+	RightAssoc
+)
+
+// PrefixFunc parses an expression starting with the operator op, op is already consumed
+type PrefixFunc func(p *Parser, op *lexer.Token) (ast.Expression, error)
+
+// InfixFunc parses the rest of an expression made of left followed by the operator op, op is already consumed
+// An infix operator reads its right operand with ParseRightOperand, a postfix operator reads what it needs
+type InfixFunc func(p *Parser, left ast.Expression, op *lexer.Token) (ast.Expression, error)
+
+// Operator holds how a token is parsed when it is used as an operator
+// Precedence and Associativity apply to the infix or postfix form, a token cannot have both
+type Operator struct {
+	Precedence    int
+	Associativity Associativity
+	Prefix        PrefixFunc
+	Infix         InfixFunc
+	Postfix       InfixFunc
+}
+
+// OperatorTable maps the operator tokens of a dialect to the way they are parsed
+type OperatorTable struct {
+	operators map[string]*Operator
+}
+
+// NewOperatorTable returns a table holding the C operators
+func NewOperatorTable() *OperatorTable {
+	t := &OperatorTable{operators: map[string]*Operator{}}
+	for _, op := range []string{"-", "!", "~", "&", "*", "++", "--"} {
+		t.RegisterPrefix(op, ParsePrefix)
+	}
+	t.RegisterPrefix("(", parseParenthesized)
+	t.RegisterPrefix("sizeof", parseSizeof)
+
+	t.RegisterInfix(",", PrecComma, LeftAssoc, parseComma)
+	for _, op := range []string{"=", "+=", "-=", "*=", "/="} {
+		t.RegisterInfix(op, PrecAssign, RightAssoc, parseAssign)
+	}
+	binary := []struct {
+		precedence int
+		ops        []string
+	}{
+		{PrecLogicalOr, []string{"||"}},
+		{PrecLogicalAnd, []string{"&&"}},
+		{PrecEquality, []string{"==", "!="}},
+		{PrecRelational, []string{"<", ">", "<=", ">="}},
+		{PrecAdditive, []string{"+", "-"}},
+		{PrecMultiplicative, []string{"*", "/", "%"}},
+	}
+	for _, level := range binary {
+		for _, op := range level.ops {
+			t.RegisterInfix(op, level.precedence, LeftAssoc, ParseInfix)
+		}
+	}
+
+	t.RegisterPostfix("(", parseCall)
+	t.RegisterPostfix("[", parseIndex)
+	t.RegisterPostfix(".", parseMember)
+	t.RegisterPostfix("->", parseMember)
+	t.RegisterPostfix("++", ParsePostfix)
+	t.RegisterPostfix("--", ParsePostfix)
+	return t
+}
+
+// operator returns the entry of a token, creating it if needed
+func (t *OperatorTable) operator(token string) *Operator {
+	op, ok := t.operators[token]
+	if !ok {
+		op = &Operator{}
+		t.operators[token] = op
+	}
+	return op
+}
+
+// RegisterPrefix makes token a prefix operator parsed by fn, replacing its previous prefix form
+func (t *OperatorTable) RegisterPrefix(token string, fn PrefixFunc) {
+	t.operator(token).Prefix = fn
+}
+
+// RegisterInfix makes token an infix operator parsed by fn, replacing its previous infix or postfix form
+func (t *OperatorTable) RegisterInfix(token string, precedence int, assoc Associativity, fn InfixFunc) {
+	op := t.operator(token)
+	op.Precedence, op.Associativity, op.Infix, op.Postfix = precedence, assoc, fn, nil
+}
+
+// RegisterPostfix makes token a postfix operator parsed by fn, replacing its previous infix or postfix form
+func (t *OperatorTable) RegisterPostfix(token string, fn InfixFunc) {
+	op := t.operator(token)
+	op.Precedence, op.Associativity, op.Infix, op.Postfix = PrecPostfix, LeftAssoc, nil, fn
+}
+
+// Unregister removes every form of the operator token from the dialect
+func (t *OperatorTable) Unregister(token string) {
+	delete(t.operators, token)
+}
+
+// Lookup returns how token is parsed as an operator
+func (t *OperatorTable) Lookup(token string) (Operator, bool) {
+	op, ok := t.operators[token]
+	if !ok {
+		return Operator{}, false
+	}
+	return *op, true
+}
+
+// lookupToken returns the entry of an operator token, nil if the token is not an operator
+// Only punctuators and identifiers such as "sizeof" can be operators
+func (t *OperatorTable) lookupToken(token *lexer.Token) *Operator {
+	if token.Type != lexer.PunctuatorToken && token.Type != lexer.IdentifierToken {
+		return nil
+	}
+	return t.operators[string(token.Value)]
+}
+
+// ParsePrefix parses the operand of a unary operator and returns a PrefixExpression
+func ParsePrefix(p *Parser, op *lexer.Token) (ast.Expression, error) {
+	operand, err := p.ParseUnaryExpression()
+	if err != nil {
+		return nil, err
+	}
+	return ast.NewPrefixExpression(op, operand)
+}
+
+// ParseInfix parses the right operand of a binary operator and returns an InfixExpression
+func ParseInfix(p *Parser, left ast.Expression, op *lexer.Token) (ast.Expression, error) {
+	right, err := p.ParseRightOperand(op)
+	if err != nil {
+		return nil, err
+	}
+	return ast.NewInfixExpression(op, left, right)
+}
+
+// ParsePostfix returns a PostfixExpression applying op to left
+func ParsePostfix(p *Parser, left ast.Expression, op *lexer.Token) (ast.Expression, error) {
+	return ast.NewPostfixExpression(left, op)
+}
@@ -3,286 +3,544 @@ package parser
 import (
 	"compiler/ast"
 	"compiler/lexer"
-	"errors"
+	"compiler/types"
 	"fmt"
 	"io"
 )
 
+// lookahead is the number of tokens the parser can look at before consuming them
+// The grammar needs at most three, to tell `struct S {` apart from `struct S x`
+const lookahead = 4
+
 // Parser holds the Lexer to generate the AST
+// Tokens are pulled from the lexer on demand, the ones looked at but not consumed yet wait in a ring
 type Parser struct {
-	l           *lexer.Lexer
-	TokenBuffer []*lexer.Token
+	l *lexer.Lexer
+	// ring holds the tokens read ahead, count of them starting at head
+	ring  [lookahead]*lexer.Token
+	head  int
+	count int
+	// err is set when the lexer could not read its source
+	err error
+	// operators holds the operators of the dialect parsed by ParseExpression
+	operators *OperatorTable
+	// comments holds the comments skipped so far
+	comments []*lexer.Token
+	// tokens holds every token read when keepTokens is set, whitespace and comments included
+	tokens     []*lexer.Token
+	keepTokens bool
+	*Scopes
 }
 
 // NewParser creates a new parser
 func NewParser(l *lexer.Lexer) *Parser {
 	return &Parser{
-		l:           l,
-		TokenBuffer: make([]*lexer.Token, 0),
+		l:         l,
+		operators: NewOperatorTable(),
+		Scopes:    NewScopes(),
 	}
 }
 
-func (p *Parser) PeekNextValidToken() (*lexer.Token, error) {
-	if len(p.TokenBuffer) != 0 {
-		t := p.TokenBuffer[0]
-		return t, nil
+// Peek returns the token n positions after the next one without consuming it, Peek(0) is the next token
+// Once the input is exhausted every token is an EOFToken
+func (p *Parser) Peek(n int) *lexer.Token {
+	if n >= lookahead {
+		panic(fmt.Sprintf("Cannot look %d tokens ahead", n+1))
 	}
-	t, err := p.NextValidToken()
-	if err != nil {
-		return nil, err
+	for p.count <= n {
+		p.ring[(p.head+p.count)%lookahead] = p.read()
+		p.count++
 	}
-	p.TokenBuffer = append([]*lexer.Token{t}, p.TokenBuffer...)
-	return t, nil
+	return p.ring[(p.head+n)%lookahead]
 }
 
-// NextValidToken finds the next non whitespace or line return token
-func (p *Parser) NextValidToken() (*lexer.Token, error) {
-	if len(p.TokenBuffer) != 0 {
-		t := p.TokenBuffer[0]
-		p.TokenBuffer = p.TokenBuffer[1:]
-		return t, nil
-	}
+// Next consumes the next token and returns it, the EOFToken is never consumed
+func (p *Parser) Next() *lexer.Token {
+	t := p.Peek(0)
+	if t.Type != lexer.EOFToken {
+		p.head = (p.head + 1) % lookahead
+		p.count--
+	}
+	return t
+}
+
+// read returns the next token from the lexer that is neither whitespace, a line return nor a comment
+// The comments are kept for Comments
+func (p *Parser) read() *lexer.Token {
 	for {
 		t := p.l.Next()
-		err := p.l.Err()
-		if err != nil {
-			return nil, err
+		if p.keepTokens && (len(p.tokens) == 0 || p.tokens[len(p.tokens)-1].Type != lexer.EOFToken) {
+			p.tokens = append(p.tokens, t)
 		}
-		if t.Type != lexer.WhitespaceToken && t.Type != lexer.LineTerminatorToken {
-			return t, nil
+		switch t.Type {
+		case lexer.EOFToken:
+			if err := p.l.Err(); err != io.EOF && p.err == nil {
+				p.err = err
+			}
+			return t
+		case lexer.CommentToken:
+			p.comments = append(p.comments, t)
+		case lexer.WhitespaceToken, lexer.LineTerminatorToken, lexer.ErrToken:
+			// Invalid tokens were reported by the lexer, skipping them lets parsing go on
+		default:
+			return t
 		}
 	}
-	return nil, errors.New("Could not find next token")
 }
 
-// ParseReturnStatement will return a Statement from a set of tokens
+// Comments returns the comments read so far in the order they are written, once the program is parsed
+// they are all there for a printer to put back between the nodes
+func (p *Parser) Comments() []*lexer.Token {
+	return p.comments
+}
+
+// KeepTokens makes the parser keep every token read from the lexer, once the program is parsed
+// Tokens returns the whole source with its whitespace so cst.Build can attach it to the nodes
+func (p *Parser) KeepTokens() {
+	p.keepTokens = true
+}
+
+// Tokens returns the tokens read so far when KeepTokens was called, ending with the EOFToken
+// once the program is parsed
+func (p *Parser) Tokens() []*lexer.Token {
+	return p.tokens
+}
+
+// Is returns whether or not the next token is val
+func (p *Parser) Is(val string) bool {
+	return string(p.Peek(0).Value) == val
+}
+
+// Accept consumes the next token if it is val and returns whether or not it did
+func (p *Parser) Accept(val string) bool {
+	if !p.Is(val) {
+		return false
+	}
+	p.Next()
+	return true
+}
+
+// Expect consumes the next token and returns it, it has to be val
+func (p *Parser) Expect(val string) (*lexer.Token, error) {
+	if !p.Is(val) {
+		return nil, p.errorf(p.Peek(0), "Expected '%s' got %s", val, describe(p.Peek(0)))
+	}
+	return p.Next(), nil
+}
+
+// errorf returns a syntax error found at the token t, its message starts with the position of t
+// like the lexical errors
+func (p *Parser) errorf(t *lexer.Token, format string, args ...interface{}) error {
+	return errorAt(p.l, t, format, args...)
+}
+
+// errorAt returns an error prefixed with the position of t, a token read by l
+func errorAt(l *lexer.Lexer, t *lexer.Token, format string, args ...interface{}) error {
+	return fmt.Errorf("%s: %s", l.Position(t.Pos), fmt.Sprintf(format, args...))
+}
+
+// describe returns a token as written in error messages
+func describe(t *lexer.Token) string {
+	if t.Type == lexer.EOFToken {
+		return "end of input"
+	}
+	return "'" + string(t.Value) + "'"
+}
+
+// ParseReturnStatement will return a Statement from the next tokens
 // It follows this grammar
 // <return_statement> ::= "return" <exp> ";"
-func (p *Parser) ParseReturnStatement(token *lexer.Token) (ast.Statement, error) {
-	tokens, err := p.GetTokensUntil(";", false)
+func (p *Parser) ParseReturnStatement() (ast.Statement, error) {
+	token := p.Next()
+	exp, err := p.ParseExpression()
 	if err != nil {
 		return nil, err
 	}
-	exp, _, err := p.ParseExpression(tokens)
-	if err != nil {
-		return nil, err
-	}
-	stmt, err := ast.NewReturnStatement(exp)
-	if err != nil {
+	if _, err := p.Expect(";"); err != nil {
 		return nil, err
 	}
-	return stmt, nil
+	return ast.NewReturnStatement(token, exp)
 }
 
-// ParseExpressionStatement will return a Statement from a set of tokens
+// ParseExpressionStatement will return a Statement from the next tokens
 // It follows this grammar
 // <expression_statement> ::= <exp> ";"
-func (p *Parser) ParseExpressionStatement(token *lexer.Token) (ast.Statement, error) {
-	tokens, err := p.GetTokensUntil(";", false)
+func (p *Parser) ParseExpressionStatement() (ast.Statement, error) {
+	exp, err := p.ParseExpression()
 	if err != nil {
 		return nil, err
 	}
-	// Create an array with the first token and the rest of the line
-	tokens = append([]*lexer.Token{token}, tokens...)
-	exp, tokens, err := p.ParseExpression(tokens)
-	if err != nil {
+	if _, err := p.Expect(";"); err != nil {
 		return nil, err
 	}
 	return ast.NewExpStatement(exp)
 }
 
-// ParseDeclStatement will return a Statement from a set of tokens
+// declSpecifiers holds what comes before the declarators of a declaration
+type declSpecifiers struct {
+	// token is the first token of the declaration
+	token   *lexer.Token
+	storage string
+	base    types.Type
+	// def is the statement of an enum or named struct defined by the specifiers
+	def ast.Statement
+	// isDefinition is set when the specifiers define an enum or a struct, even an anonymous one
+	isDefinition bool
+}
+
+// ParseDeclStatement will return a Statement from the next tokens
 // It follows this grammar
-// <decl_statement> ::= "int" <id> [ = <exp> ] ";"
-func (p *Parser) ParseDeclStatement(token *lexer.Token) (ast.Statement, error) {
-	tokens, err := p.GetTokensUntil(";", false)
+// <decl_statement> ::= <decl_specifiers> [ <init_declarator> { "," <init_declarator> } ] ";"
+func (p *Parser) ParseDeclStatement() (ast.Statement, error) {
+	specs, err := p.ParseDeclSpecifiers()
 	if err != nil {
 		return nil, err
 	}
-	tName, tokens := tokens[0], tokens[1:]
-	if tName.Type != lexer.IdentifierToken {
-		return nil, fmt.Errorf("Expected identifier, got '%s'", tName.Value)
-	}
-	if len(tokens) == 0 {
-		return ast.NewDeclStatement(token, tName, nil)
+	return p.parseInitDeclarators(specs, nil)
+}
+
+// ParseDeclSpecifiers will return the storage class and the type specifiers of a declaration
+// The storage class and the qualifiers can appear anywhere among the specifiers of the type
+// <decl_specifiers> ::= { <storage_class> | <qualifier> } <decl_type> { <storage_class> | <qualifier> }
+// <decl_type> ::= <type_specifier> { <type_specifier> | <storage_class> | <qualifier> } | <typedef_name>
+//
+//	| ( "enum" | "struct" ) <id> | <enum_definition> | <struct_definition>
+//
+// <storage_class> ::= "typedef" | "static" | "extern"
+func (p *Parser) ParseDeclSpecifiers() (*declSpecifiers, error) {
+	specs := &declSpecifiers{token: p.Peek(0)}
+	keywords := make([]string, 0)
+	isConst, isVolatile := false, false
+	for {
+		t := p.Peek(0)
+		hasType := specs.base != nil || len(keywords) != 0
+		var err error
+		switch {
+		case IsStorageClass(t):
+			if specs.storage != "" {
+				return nil, p.errorf(t, "Multiple storage classes in declaration")
+			}
+			specs.storage = string(p.Next().Value)
+			continue
+		case IsTypeQualifier(t):
+			if string(p.Next().Value) == "const" {
+				isConst = true
+			} else {
+				isVolatile = true
+			}
+			continue
+		case types.IsSpecifier(string(t.Value)) && t.Type == lexer.IdentifierToken && specs.base == nil:
+			keywords = append(keywords, string(p.Next().Value))
+			continue
+		case IsTypeSpecifier(t) && hasType:
+			return nil, p.errorf(t, "Two or more data types in declaration specifiers")
+		case hasType:
+			// What follows the type is the first declarator, a typedef name there is redeclared
+		case p.IsEnumDefinition():
+			specs.def, err = p.ParseEnumDefinition()
+			if err != nil {
+				return nil, err
+			}
+			specs.isDefinition = true
+			// Anonymous enums can only be referred to as ints
+			specs.base = types.Int
+			if name := specs.def.(*ast.EnumStatement).Name; name != "" {
+				specs.base = &types.Named{Name: "enum " + name}
+			}
+			continue
+		case p.IsStructDefinition():
+			specs.def, specs.base, err = p.ParseStructDefinition()
+			if err != nil {
+				return nil, err
+			}
+			specs.isDefinition = true
+			continue
+		case p.Is("enum") || p.Is("struct") || p.IsTypedefName(t):
+			specs.base, err = p.ParseSpecifiers()
+			if err != nil {
+				return nil, err
+			}
+			// ParseSpecifiers read the qualifiers following the type
+			continue
+		default:
+			return nil, p.errorf(t, "Expected type name, got %s", describe(t))
+		}
+		break
 	}
-	t, tokens := tokens[0], tokens[1:]
-	if string(t.Value) != "=" {
-		return nil, fmt.Errorf("Expected '=' got '%s'", t.Value)
+	if specs.base == nil {
+		var err error
+		specs.base, err = types.FromSpecifiers(keywords)
+		if err != nil {
+			return nil, err
+		}
 	}
-	exp, tokens, err := p.ParseExpression(tokens)
-	if err != nil {
-		return nil, err
+	specs.base = types.Qualify(specs.base, isConst, isVolatile)
+	if specs.isDefinition && specs.def == nil && p.Is(";") {
+		return nil, p.errorf(p.Peek(0), "Anonymous struct declares nothing")
 	}
-	return ast.NewDeclStatement(token, tName, exp)
+	return specs, nil
 }
 
-func (p *Parser) GetTokensBetween(start string, end string) ([]*lexer.Token, error) {
-	t, err := p.NextValidToken()
+// parseInitDeclarators will read the declarators following the specifiers of a declaration and its final ";"
+// first is a declaration already read by the caller, nil if there is none
+func (p *Parser) parseInitDeclarators(specs *declSpecifiers, first ast.Statement) (ast.Statement, error) {
+	decls, err := ast.NewDeclList()
 	if err != nil {
 		return nil, err
 	}
-	if string(t.Value) != start {
-		return nil, fmt.Errorf("Expected '%s', got '%s'", start, t.Value)
-	}
-	tokens := make([]*lexer.Token, 0)
-	depth := 0
-	for {
-		t, err = p.NextValidToken()
+	if specs.def != nil {
+		// A definition alone is not grouped
+		if first == nil && specs.storage != "typedef" && p.Accept(";") {
+			return specs.def, nil
+		}
+		decls, err = ast.AppendDecl(decls, specs.def)
 		if err != nil {
 			return nil, err
 		}
-		// Same as start, add to list, increase depth
-		if string(t.Value) == start {
-			tokens = append(tokens, t)
-			depth++
-		} else if string(t.Value) == end {
-			// Found end token, but maybe it's matching another start
-			if depth == 0 {
-				return tokens, nil
+	}
+	for decl := first; ; decl = nil {
+		if decl == nil {
+			decl, err = p.ParseDeclarator(specs.base, specs.storage)
+			if err != nil {
+				return nil, err
 			}
-			tokens = append(tokens, t)
-			depth--
-		} else {
-			tokens = append(tokens, t)
+		}
+		decls, err = ast.AppendDecl(decls, decl)
+		if err != nil {
+			return nil, err
+		}
+		if p.Accept(";") {
+			break
+		}
+		if !p.Accept(",") {
+			return nil, p.errorf(p.Peek(0), "Expected ',' or ';' got %s", describe(p.Peek(0)))
 		}
 	}
+	return ast.NewDeclGroup(specs.token, decls)
 }
 
-func (p *Parser) ParseIfStatement() (ast.Statement, error) {
-	tokens, err := p.GetTokensBetween("(", ")")
+// ParseDeclarator will return a single declaration
+// A TypedefStatement is returned instead when the storage class is "typedef"
+// It follows this grammar
+// <init_declarator> ::= <declarator> [ = <initializer> ]
+func (p *Parser) ParseDeclarator(base types.Type, storage string) (ast.Statement, error) {
+	d, err := p.parseDeclarator()
 	if err != nil {
 		return nil, err
 	}
-	exp, tokens, err := p.ParseExpression(tokens)
-	if err != nil {
-		return nil, err
+	return p.parseInitDeclarator(base, storage, d)
+}
+
+// parseInitDeclarator will return the declaration of an already parsed declarator
+func (p *Parser) parseInitDeclarator(base types.Type, storage string, d *declarator) (ast.Statement, error) {
+	if d.name == nil {
+		return nil, p.errorf(p.Peek(0), "Expected identifier, got %s", describe(p.Peek(0)))
+	}
+	declType := d.wrap(base)
+	p.DeclareName(string(d.name.Value), storage == "typedef")
+	if storage == "typedef" {
+		if p.Is("=") {
+			return nil, p.errorf(p.Peek(0), "Typedef '%s' cannot be initialized", d.name.Value)
+		}
+		return ast.NewTypedefStatement(declType, d.name)
 	}
-	t, err := p.NextValidToken()
-	if err != nil {
-		return nil, err
+	if !p.Accept("=") {
+		return ast.NewDeclStatement(storage, declType, d.name, nil)
 	}
-	s, err := p.ParseStatement(t)
+	exp, err := p.ParseInitializer()
 	if err != nil {
 		return nil, err
 	}
-	stmt, err := ast.NewIfStatement(exp, s, nil)
+	return ast.NewDeclStatement(storage, declType, d.name, exp)
+}
+
+// ParseInitializer will return the initializer of a declaration
+// <initializer> ::= <assignment_exp> | "{" [ <initializer_element> { "," <initializer_element> } [ "," ] ] "}"
+func (p *Parser) ParseInitializer() (ast.Expression, error) {
+	if !p.Is("{") {
+		return p.ParseAssignmentExpression()
+	}
+	brace := p.Next()
+	elements, err := ast.NewInitializerElementList()
 	if err != nil {
 		return nil, err
 	}
-	t, err = p.PeekNextValidToken()
+	for !p.Accept("}") {
+		designators, err := p.ParseDesignation()
+		if err != nil {
+			return nil, err
+		}
+		value, err := p.ParseInitializer()
+		if err != nil {
+			return nil, err
+		}
+		elements, err = ast.AppendInitializer(elements, designators, value)
+		if err != nil {
+			return nil, err
+		}
+		if p.Accept("}") {
+			break
+		}
+		if !p.Accept(",") {
+			return nil, p.errorf(p.Peek(0), "Expected ',' or '}' got %s", describe(p.Peek(0)))
+		}
+	}
+	return ast.NewInitializerList(brace, elements)
+}
+
+// ParseDesignation will return the designators starting an element of an initializer list
+// the list is empty when the element has no designation
+// <initializer_element> ::= [ <designator> { <designator> } "=" ] <initializer>
+// <designator> ::= "[" <logical_or_exp> "]" | "." <id>
+func (p *Parser) ParseDesignation() ([]ast.Designator, error) {
+	designators, err := ast.NewDesignatorList()
 	if err != nil {
 		return nil, err
 	}
-	if string(t.Value) != "else" {
-		return stmt, nil
+	for p.Is("[") || p.Is(".") {
+		var designator ast.Attrib
+		if p.Accept(".") {
+			if p.Peek(0).Type != lexer.IdentifierToken {
+				return nil, p.errorf(p.Peek(0), "Expected member name after '.'")
+			}
+			designator = p.Next()
+		} else {
+			p.Next()
+			index, err := p.ParseLogicalOrExpression()
+			if err != nil {
+				return nil, err
+			}
+			if _, err := p.Expect("]"); err != nil {
+				return nil, err
+			}
+			designator = index
+		}
+		designators, err = ast.AppendDesignator(designators, designator)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(designators) != 0 && !p.Accept("=") {
+		return nil, p.errorf(p.Peek(0), "Expected '=' after designator got %s", describe(p.Peek(0)))
 	}
-	// Consume the "else" from the buffer
-	t, err = p.NextValidToken()
-	if err != nil {
+	return designators, nil
+}
+
+// ParseIfStatement will return an IfStatement from the next tokens
+// <if_statement> ::= "if" "(" <exp> ")" <statement> [ "else" <statement> ]
+func (p *Parser) ParseIfStatement() (ast.Statement, error) {
+	token := p.Next()
+	if _, err := p.Expect("("); err != nil {
 		return nil, err
 	}
-	// Get the real next token
-	t, err = p.NextValidToken()
+	exp, err := p.ParseExpression()
 	if err != nil {
 		return nil, err
 	}
-	elseS, err := p.ParseStatement(t)
+	if _, err := p.Expect(")"); err != nil {
+		return nil, err
+	}
+	s, err := p.ParseStatement()
 	if err != nil {
 		return nil, err
 	}
-	stmt, err = ast.NewIfStatement(exp, s, elseS)
+	if !p.Accept("else") {
+		return ast.NewIfStatement(token, exp, s, nil)
+	}
+	elseS, err := p.ParseStatement()
 	if err != nil {
 		return nil, err
 	}
-	return stmt, nil
+	return ast.NewIfStatement(token, exp, s, elseS)
 }
 
-func (p *Parser) ParseBlockItem(t *lexer.Token) (ast.Statement, error) {
-	switch string(t.Value) {
-	case "int":
-		s, err := p.ParseDeclStatement(t)
-		if err != nil {
-			return nil, err
-		}
-		return s, nil
-	default:
-		s, err := p.ParseStatement(t)
-		if err != nil {
-			return nil, err
-		}
-		return s, nil
+// ParseBlockItem will return a declaration or a statement
+// <block_item> ::= <decl_statement> | <statement>
+func (p *Parser) ParseBlockItem() (ast.Statement, error) {
+	if p.StartsDeclaration(p.Peek(0)) {
+		return p.ParseDeclStatement()
 	}
+	return p.ParseStatement()
 }
 
 // ParseStatement will return the correct Statement for the tokens to follow
-// It will get all tokens until the next ";"
-// Right now only return statements exists
-// <statement> ::= <return_statement>
-func (p *Parser) ParseStatement(t *lexer.Token) (ast.Statement, error) {
-	switch string(t.Value) {
+// <statement> ::= <block_statement> | <if_statement> | <return_statement> | <asm_statement> | <expression_statement>
+func (p *Parser) ParseStatement() (ast.Statement, error) {
+	switch string(p.Peek(0).Value) {
 	case "{":
-		s, err := p.ParseBlockStatement()
-		if err != nil {
-			return nil, err
-		}
-		return s, nil
+		return p.ParseBlockStatement()
 	case "if":
-		s, err := p.ParseIfStatement()
-		if err != nil {
-			return nil, err
-		}
-		return s, nil
+		return p.ParseIfStatement()
 	case "return":
-		s, err := p.ParseReturnStatement(t)
-		if err != nil {
-			return nil, err
-		}
-		return s, nil
+		return p.ParseReturnStatement()
+	case "asm", "__asm", "__asm__":
+		return p.ParseAsmStatement()
 	default:
-		s, err := p.ParseExpressionStatement(t)
-		if err != nil {
-			return nil, err
-		}
-		return s, nil
+		return p.ParseExpressionStatement()
 	}
 }
 
 // ParseBlockStatement will return a statement list of all statements in a block
-// <block_statement> ::= { <statement> }
+// <block_statement> ::= "{" { <block_item> } "}"
 func (p *Parser) ParseBlockStatement() (*ast.BlockStatement, error) {
-	stmts, err := ast.NewStatementList()
+	brace, err := p.Expect("{")
 	if err != nil {
 		return nil, err
 	}
-	for {
-		t, err := p.NextValidToken()
-		if err != nil {
-			return nil, err
-		}
-		// Indicates the end of the block
-		if string(t.Value) == "}" {
-			break
+	p.EnterScope()
+	defer p.LeaveScope()
+	stmts, rbrace, err := p.parseBlockItems()
+	if err != nil {
+		return nil, err
+	}
+	return ast.NewBlockStatement(brace, stmts, rbrace)
+}
+
+// parseBlockItems will return the items of a block and its "}", the "{" is already consumed
+func (p *Parser) parseBlockItems() ([]ast.Statement, *lexer.Token, error) {
+	stmts, err := ast.NewStatementList()
+	if err != nil {
+		return nil, nil, err
+	}
+	// Indicates the end of the block
+	for !p.Is("}") {
+		if p.Peek(0).Type == lexer.EOFToken {
+			return nil, nil, p.errorf(p.Peek(0), "Expected '}' at the end of the block got end of input")
 		}
-		stmt, err := p.ParseStatement(t)
+		stmt, err := p.ParseBlockItem()
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		stmts, err = ast.AppendStatement(stmts, stmt)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 	}
-	block, err := ast.NewBlockStatement(stmts)
-	if err != nil {
-		return nil, err
-	}
-	return block, nil
+	return stmts, p.Next(), nil
 }
 
 // ParseProgram will parse the entire source by consuming all tokens from the lexer
 // and building an AST with a Program as the root
+// The lexical errors come first since they often explain the parsing errors that follow them
 func (p *Parser) ParseProgram() (*ast.Program, error) {
+	program, err := p.parseProgram()
+	if err != nil {
+		// Read the rest of the source to report all of its lexical errors
+		for p.l.Err() == nil {
+			p.l.Next()
+		}
+	}
+	if lexErr := p.l.Errors().Err(); lexErr != nil {
+		return nil, lexErr
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	return program, err
+}
+
+// <program> ::= { <external_declaration> }
+func (p *Parser) parseProgram() (*ast.Program, error) {
 	// Prepare the function list of the program
 	fns, err := ast.NewStatementList()
 	if err != nil {
@@ -293,127 +551,77 @@ func (p *Parser) ParseProgram() (*ast.Program, error) {
 	if err != nil {
 		return nil, err
 	}
-	for {
-		t, err := p.NextValidToken()
-		// Stop if hit the end of the file
-		if err == io.EOF {
-			break
+	// Stop when hitting the end of the file
+	for p.Peek(0).Type != lexer.EOFToken {
+		// Only declarations are allowed at the top level
+		if !p.StartsDeclaration(p.Peek(0)) {
+			return nil, p.errorf(p.Peek(0), "Expected declaration got %s", describe(p.Peek(0)))
 		}
+		s, err := p.ParseExternalDeclaration()
 		if err != nil {
 			return nil, err
 		}
-		// We only support top level functions with int return type so far
-		if string(t.Value) == "int" {
-			f, err := p.ParseFunction(t)
-			if err != nil {
-				return nil, err
-			}
-			fns, err = ast.AppendStatement(fns, f)
-			if err != nil {
-				return nil, err
-			}
+		if _, ok := s.(*ast.FunctionStatement); ok {
+			fns, err = ast.AppendStatement(fns, s)
 		} else {
-			break
+			stmts, err = ast.AppendStatement(stmts, s)
+		}
+		if err != nil {
+			return nil, err
 		}
-
-	}
-	program, err := ast.NewProgram(fns, stmts)
-	if err != nil {
-		return nil, err
 	}
-	return program, nil
+	return ast.NewProgram(fns, stmts)
 }
 
-// ParseFunction will return a Function node from the next tokens in the lexer
-// <function> ::= "int" <identifier> "(" ")" <block_statement>
-func (p *Parser) ParseFunction(token *lexer.Token) (ast.Statement, error) {
-	if token.Type != lexer.IdentifierToken {
-		return nil, fmt.Errorf("Failed to parse: Expected identifier got %s", token.Value)
-	}
-	// Second token in the list is the function name
-	nameToken, err := p.NextValidToken()
+// ParseExternalDeclaration will return a function or a declaration found at the top level
+// A declarator made of a name and a parameter list followed by a body or a ";" is a function
+// <external_declaration> ::= <function> | <decl_statement>
+func (p *Parser) ParseExternalDeclaration() (ast.Statement, error) {
+	specs, err := p.ParseDeclSpecifiers()
 	if err != nil {
 		return nil, err
 	}
-	if nameToken.Type != lexer.IdentifierToken {
-		return nil, errors.New("Failed to parse")
+	if specs.storage == "typedef" || specs.isDefinition || p.Is(";") {
+		return p.parseInitDeclarators(specs, nil)
 	}
-	t, err := p.NextValidToken()
+	d, err := p.parseDeclarator()
 	if err != nil {
 		return nil, err
 	}
-	if t.Value[0] != '(' {
-		return nil, fmt.Errorf("Unexpected %s, expected (", string(t.Value))
+	if d.params != nil && (p.Is("{") || p.Is(";")) {
+		return p.ParseFunction(specs.storage, d.name, d.params, d.wrap(specs.base).(*types.Func))
 	}
-	// Ignore this for now, we don't parse the function parameters yet
-	t, err = p.NextValidToken()
+	decl, err := p.parseInitDeclarator(specs.base, specs.storage, d)
 	if err != nil {
 		return nil, err
 	}
-	if t.Value[0] != ')' {
-		return nil, fmt.Errorf("Unexpected %s, expected )", string(t.Value))
-	}
-	t, err = p.NextValidToken()
+	return p.parseInitDeclarators(specs, decl)
+}
+
+// ParseFunction will return a Function node once its declarator was read, the body is read from the next tokens
+// A function declared without a body gets a nil Body
+// <function> ::= [ <storage_class> ] <specifiers> { "*" { <qualifier> } } <id> "(" <param_list> ")" ( <block_statement> | ";" )
+func (p *Parser) ParseFunction(storage string, name *lexer.Token, args []ast.FormalArg, fn *types.Func) (ast.Statement, error) {
+	p.DeclareName(string(name.Value), false)
+	if p.Accept(";") {
+		return ast.NewFunctionStatement(storage, name, args, fn.Variadic, fn.Unprototyped, fn.Return, nil)
+	}
+	brace, err := p.Expect("{")
 	if err != nil {
 		return nil, err
 	}
-	// Make sure we got the beginning of a block statement
-	if t.Value[0] != '{' {
-		return nil, fmt.Errorf("Unexpected %s, expected {", string(t.Value))
+	p.EnterScope()
+	defer p.LeaveScope()
+	for _, arg := range args {
+		p.DeclareName(arg.Arg, false)
 	}
-	stmts, err := ast.NewStatementList()
+	stmts, rbrace, err := p.parseBlockItems()
 	if err != nil {
 		return nil, err
 	}
-	for {
-		t, err := p.NextValidToken()
-		if err != nil {
-			return nil, err
-		}
-		// Found the end of the function
-		if string(t.Value) == "}" {
-			break
-		}
-		// This will get the function body as a block statement
-		blockItem, err := p.ParseBlockItem(t)
-		if err != nil {
-			return nil, err
-		}
-		stmts, err = ast.AppendStatement(stmts, blockItem)
-		if err != nil {
-			return nil, err
-		}
-	}
-	body, err := ast.NewBlockStatement(stmts)
+	body, err := ast.NewBlockStatement(brace, stmts, rbrace)
 	if err != nil {
 		return nil, err
 	}
-	fun, err := ast.NewFunctionStatement(nameToken, nil, token, body)
-	if err != nil {
-		return nil, fmt.Errorf("Error")
-	}
-	return fun, nil
-}
-
-// GetTokensUntil will read the valid tokens from the lexer until it finds the token provided
-func (p *Parser) GetTokensUntil(val string, include bool) ([]*lexer.Token, error) {
-	tokens := make([]*lexer.Token, 0)
-	for {
-		t, err := p.NextValidToken()
-		if err != nil {
-			return nil, err
-		}
-		if string(t.Value) == val {
-			if include {
-				tokens = append(tokens, t)
-			}
-			break
-		}
-		tokens = append(tokens, t)
-	}
-	return tokens, nil
-}
-
-func (p *Parser) GetStatementTokens() ([]*lexer.Token, error) {
-	return p.GetTokensUntil(";", false)
+	return ast.NewFunctionStatement(storage, name, args, fn.Variadic, fn.Unprototyped, fn.Return, body)
 }
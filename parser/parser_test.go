@@ -0,0 +1,70 @@
+package parser
+
+import (
+	"compiler/ast"
+	"compiler/lexer"
+	"compiler/source"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// parseFile reads the program of a source file with the parser called name, "rd" or "lr"
+func parseFile(t *testing.T, path, name string) *ast.Program {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	l := lexer.NewFileLexer(f, source.NewFileSet().AddFile(path, -1))
+	var program *ast.Program
+	if name == "rd" {
+		program, err = NewParser(l).ParseProgram()
+	} else {
+		program, err = NewLRParser(l).ParseProgram()
+	}
+	if err != nil {
+		t.Fatalf("%s parser: %s", name, err)
+	}
+	return program
+}
+
+// TestParsersAgree parses the sources of testdata with both parsers, they have to give the same tree
+func TestParsersAgree(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("testdata", "*.c"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Fatal("No source in testdata")
+	}
+	for _, path := range paths {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			rd, lr := parseFile(t, path, "rd"), parseFile(t, path, "lr")
+			if !ast.Equal(rd, lr) {
+				var rdTree, lrTree strings.Builder
+				ast.FprintSExpr(&rdTree, rd)
+				ast.FprintSExpr(&lrTree, lr)
+				t.Errorf("The parsers disagree\nrd:\n%s\nlr:\n%s", rdTree.String(), lrTree.String())
+			}
+		})
+	}
+}
+
+// TestParseErrorPosition checks that both parsers start their errors with the position of the token
+func TestParseErrorPosition(t *testing.T) {
+	for _, name := range []string{"rd", "lr"} {
+		l := lexer.NewFileLexer(strings.NewReader("int main() {\n    return 1 +;\n}\n"), source.NewFileSet().AddFile("bad.c", -1))
+		var err error
+		if name == "rd" {
+			_, err = NewParser(l).ParseProgram()
+		} else {
+			_, err = NewLRParser(l).ParseProgram()
+		}
+		if err == nil || !strings.HasPrefix(err.Error(), "bad.c:2:15: ") {
+			t.Errorf("%s parser: expected an error at bad.c:2:15, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package parser
+
+import "compiler/lexer"
+
+// Scopes tracks the names declared in each open scope, starting with the file scope
+// It is shared by the parsers since telling a typedef name apart from an ordinary identifier needs it
+type Scopes struct {
+	// names tells for each name declared in a scope whether or not it is a typedef name
+	names []map[string]bool
+}
+
+// NewScopes returns the scopes of a new translation unit, only the file scope is open
+func NewScopes() *Scopes {
+	return &Scopes{
+		// va_list is built in since there is no stdarg.h to include
+		names: []map[string]bool{{"va_list": true}},
+	}
+}
+
+// EnterScope opens a new block scope
+func (s *Scopes) EnterScope() {
+	s.names = append(s.names, map[string]bool{})
+}
+
+// LeaveScope drops every name declared since the matching EnterScope
+func (s *Scopes) LeaveScope() {
+	s.names = s.names[:len(s.names)-1]
+}
+
+// DeclareName records an identifier declared in the current scope
+// Ordinary identifiers are recorded too since they hide typedef names from outer scopes
+func (s *Scopes) DeclareName(name string, isTypedef bool) {
+	s.names[len(s.names)-1][name] = isTypedef
+}
+
+// IsTypedefName will return a boolean indicating whether or not a token
+// names a type in the current scope
+func (s *Scopes) IsTypedefName(t *lexer.Token) bool {
+	if t.Type != lexer.IdentifierToken {
+		return false
+	}
+	for i := len(s.names) - 1; i >= 0; i-- {
+		if isTypedef, ok := s.names[i][string(t.Value)]; ok {
+			return isTypedef
+		}
+	}
+	return false
+}
+
+// StartsDeclaration will return a boolean indicating whether or not a token
+// starts a declaration rather than a statement
+// This is how `T * x;` is told apart from a multiplication
+func (p *Parser) StartsDeclaration(t *lexer.Token) bool {
+	return IsTypeSpecifier(t) || IsTypeQualifier(t) || IsStorageClass(t) || p.IsTypedefName(t)
+}
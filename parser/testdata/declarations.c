@@ -0,0 +1,37 @@
+/* Declarations of every kind the parsers read */
+typedef unsigned long size_t;
+typedef int (*binop)(int, int);
+typedef struct Point Point;
+
+enum Color { RED, GREEN = 4, BLUE, LAST = BLUE * 2 };
+enum { N = 3 };
+
+struct Point {
+    int x, y;
+    const char *name;
+    struct Point *next;
+    int grid[N][N + 1];
+};
+
+static int counter;
+extern volatile int flags;
+const int limit = 10, *plimit = &limit;
+int table[2 * N] = { [1] = 4, 5, [N + 1] = 6 };
+char sizes[sizeof(long)];
+int casted[(int)3];
+int compared[1 < 2];
+int digraphs<:2:> = <% 1, 2 %>;
+int (*rows)[N - 1];
+int (*(*handlers)[4])(int);
+unsigned long long big = 0xffffffffffffffffULL;
+Point origin = { .x = 1, .y = 2, .name = 0 };
+struct Point corners[] = { { 0, 0 }, { .x = 3 } };
+size_t count = sizeof(struct Point);
+
+int add(int a, int b);
+int apply(binop op, int n, ...);
+int reset();
+int (*pick(int which))(int, int);
+
+int clear() { return reset(0); }
+int reset(int value) { return value; }
@@ -0,0 +1,51 @@
+// Expressions and statements
+int add(int a, int b) {
+    return a + b;
+}
+
+int next(va_list *pap) {
+    return va_arg(*pap, int);
+}
+
+int vsum(int n, va_list ap) {
+    if (n == 0)
+        return 0;
+    int first = va_arg(ap, int);
+    return first + vsum(n - 1, ap);
+}
+
+int sum(int n, ...) {
+    va_list ap;
+    va_start(ap, n);
+    int total = next(&ap);
+    if (n > 0) {
+        total += va_arg(ap, int);
+        n--;
+        total += vsum(n, ap);
+    } else if (n < 0)
+        total = -1;
+    else {
+        total = 0;
+    }
+    va_end(ap);
+    return total;
+}
+
+int main() {
+    int a = 8, b = 7, *p = &a;
+    int arr[3] = { 1, 2, 3 };
+    long l = (long)a * b - (a - b) / 2 % 3;
+    a = b = 1;
+    a -= 2, b *= 3;
+    *p += arr[1] + 1[arr];
+    ++a;
+    b--;
+    l /= -(long)~a;
+    if (!(a == b) && (a != b || a <= b) && a >= b)
+        return a;
+    asm volatile("nop");
+    asm("movl %1, %0" : "=r"(a) : "r"(b) : "memory");
+    struct { int x; } s, *ps = &s;
+    ps->x = s.x;
+    return add(a, b) + sizeof a + sizeof(int[4]) + (a, b);
+}
@@ -0,0 +1,452 @@
+package parser
+
+import (
+	"compiler/ast"
+	"compiler/lexer"
+	"compiler/types"
+	"errors"
+	"fmt"
+)
+
+// IsTypeSpecifier will return a boolean indicating whether or not a given token
+// starts a type name
+func IsTypeSpecifier(t *lexer.Token) bool {
+	return t.Type == lexer.IdentifierToken && (types.IsSpecifier(string(t.Value)) || string(t.Value) == "enum" || string(t.Value) == "struct")
+}
+
+// IsTypeQualifier will return a boolean indicating whether or not a given token
+// is a type qualifier
+func IsTypeQualifier(t *lexer.Token) bool {
+	return t.Type == lexer.IdentifierToken && types.IsQualifier(string(t.Value))
+}
+
+// ParseQualifiers will consume the qualifiers found in the next tokens and return which ones were found
+// <qualifier> ::= "const" | "volatile"
+func (p *Parser) ParseQualifiers() (bool, bool) {
+	isConst, isVolatile := false, false
+	for IsTypeQualifier(p.Peek(0)) {
+		if string(p.Next().Value) == "const" {
+			isConst = true
+		} else {
+			isVolatile = true
+		}
+	}
+	return isConst, isVolatile
+}
+
+// IsStorageClass will return a boolean indicating whether or not a given token
+// is a storage class specifier, typedef is one too
+func IsStorageClass(t *lexer.Token) bool {
+	switch string(t.Value) {
+	case "typedef", "static", "extern":
+		return t.Type == lexer.IdentifierToken
+	}
+	return false
+}
+
+// IsEnumDefinition will return a boolean indicating whether or not the next tokens
+// start an enum definition rather than a reference to an enum type
+func (p *Parser) IsEnumDefinition() bool {
+	return p.isTagDefinition("enum")
+}
+
+// IsStructDefinition will return a boolean indicating whether or not the next tokens
+// start a struct definition rather than a reference to a struct type
+func (p *Parser) IsStructDefinition() bool {
+	return p.isTagDefinition("struct")
+}
+
+func (p *Parser) isTagDefinition(keyword string) bool {
+	if !p.Is(keyword) {
+		return false
+	}
+	if string(p.Peek(1).Value) == "{" {
+		return true
+	}
+	return p.Peek(1).Type == lexer.IdentifierToken && string(p.Peek(2).Value) == "{"
+}
+
+// ParseSpecifiers will return the type named by the specifiers found in the next tokens
+// typedef names, enum and struct tags are returned as types.Named, the generator resolves them
+// Qualifiers can appear anywhere among the specifiers
+// <specifiers> ::= { <qualifier> } ( <type_specifier> { <type_specifier> | <qualifier> } | ( "enum" | "struct" ) <id> | <typedef_name> ) { <qualifier> }
+func (p *Parser) ParseSpecifiers() (types.Type, error) {
+	isConst, isVolatile := p.ParseQualifiers()
+	var t types.Type
+	if p.IsTypedefName(p.Peek(0)) {
+		t = &types.Named{Name: string(p.Next().Value)}
+	} else if p.Is("enum") || p.Is("struct") {
+		keyword := string(p.Next().Value)
+		if p.Peek(0).Type != lexer.IdentifierToken {
+			return nil, p.errorf(p.Peek(0), "Expected %s name after '%s'", keyword, keyword)
+		}
+		t = &types.Named{Name: keyword + " " + string(p.Next().Value)}
+	} else {
+		specs := make([]string, 0)
+		for IsTypeSpecifier(p.Peek(0)) || IsTypeQualifier(p.Peek(0)) {
+			switch s := string(p.Next().Value); s {
+			case "const":
+				isConst = true
+			case "volatile":
+				isVolatile = true
+			default:
+				specs = append(specs, s)
+			}
+		}
+		if len(specs) == 0 {
+			return nil, p.errorf(p.Peek(0), "Expected type name, got %s", describe(p.Peek(0)))
+		}
+		var err error
+		t, err = types.FromSpecifiers(specs)
+		if err != nil {
+			return nil, err
+		}
+	}
+	c, v := p.ParseQualifiers()
+	return types.Qualify(t, isConst || c, isVolatile || v), nil
+}
+
+// ParseTypeName will return the type named by the next tokens, as used in casts and sizeof
+// <type_name> ::= <specifiers> <abstract_declarator>
+func (p *Parser) ParseTypeName() (types.Type, error) {
+	base, err := p.ParseSpecifiers()
+	if err != nil {
+		return nil, err
+	}
+	name, t, err := p.ParseDeclaratorType(base)
+	if err != nil {
+		return nil, err
+	}
+	if name != nil {
+		return nil, p.errorf(name, "Unexpected identifier '%s' in type name", name.Value)
+	}
+	return t, nil
+}
+
+// ParseDeclaratorType applies the declarator of the next tokens to the base type and returns the declared name
+// and the declared type
+// The name is nil for abstract declarators
+// <declarator> ::= { "*" { <qualifier> } } <direct_declarator>
+// <direct_declarator> ::= [ <id> | "(" <declarator> ")" ] { "(" <param_list> ")" | "[" [ <logical_or_exp> ] "]" }
+func (p *Parser) ParseDeclaratorType(base types.Type) (*lexer.Token, types.Type, error) {
+	d, err := p.parseDeclarator()
+	if err != nil {
+		return nil, nil, err
+	}
+	return d.name, d.wrap(base), nil
+}
+
+// declarator is a parsed declarator, it still needs the base type of the declaration to give the declared type
+type declarator struct {
+	// name is nil for abstract declarators
+	name *lexer.Token
+	// wrap wraps the base type into the declared type since the type is built inside out,
+	// e.g. in `int (*op)(int)` the pointer applies to the function type
+	wrap func(types.Type) types.Type
+	// params holds the parameters when the declarator is a name followed by a single parameter list,
+	// possibly nested, the form of the declarator of a function definition, it is nil otherwise
+	params []ast.FormalArg
+}
+
+func (p *Parser) parseDeclarator() (*declarator, error) {
+	// Each "*" can be followed by the qualifiers of the pointer
+	stars := make([][2]bool, 0)
+	for p.Accept("*") {
+		isConst, isVolatile := p.ParseQualifiers()
+		stars = append(stars, [2]bool{isConst, isVolatile})
+	}
+	var name *lexer.Token
+	var inner *declarator
+	if p.Is("(") && p.IsNestedDeclarator(p.Peek(1)) {
+		p.Next()
+		var err error
+		inner, err = p.parseDeclarator()
+		if err != nil {
+			return nil, err
+		}
+		if !p.Is(")") {
+			return nil, p.errorf(p.Peek(0), "Unexpected %s in declarator", describe(p.Peek(0)))
+		}
+		p.Next()
+	} else if t := p.Peek(0); t.Type == lexer.IdentifierToken && !IsTypeSpecifier(t) && !IsTypeQualifier(t) {
+		// A typedef name is redeclared when it is used as the declared name like in `T T = 5;`
+		name = p.Next()
+	}
+	suffixes := make([]types.Type, 0)
+	var params []ast.FormalArg
+	for p.Is("(") || p.Is("[") {
+		var suffix types.Type
+		var err error
+		if p.Is("[") {
+			suffix, err = p.parseArraySuffix()
+		} else {
+			var args []ast.FormalArg
+			args, suffix, err = p.ParseParameterList()
+			if len(suffixes) == 0 {
+				params = args
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(suffixes) != 0 {
+			if err := checkSuffix(suffixes[len(suffixes)-1], suffix); err != nil {
+				return nil, err
+			}
+		}
+		suffixes = append(suffixes, suffix)
+	}
+	return newDeclarator(stars, name, inner, suffixes, params), nil
+}
+
+// newDeclarator returns the declarator made of its pointers, its name or the nested declarator inner
+// and its suffixes, either a *types.Func or a *types.Array whose element type is set once the base is known
+// params are the parameters of the first suffix, they are kept for the declarator of a function definition
+// when the declared name is a function
+func newDeclarator(stars [][2]bool, name *lexer.Token, inner *declarator, suffixes []types.Type, params []ast.FormalArg) *declarator {
+	d := &declarator{name: name}
+	wrapInner := func(t types.Type) types.Type { return t }
+	if inner != nil {
+		d.name, wrapInner = inner.name, inner.wrap
+	}
+	// The name of a nested declarator is a function when it is one in the nested declarator,
+	// like in `int (*f(int n))(int)` where f returns a function pointer
+	if inner != nil {
+		d.params = inner.params
+	} else if d.name != nil && len(suffixes) == 1 {
+		d.params = params
+	}
+	d.wrap = func(t types.Type) types.Type {
+		for _, quals := range stars {
+			t = types.Qualify(&types.Pointer{Elem: t}, quals[0], quals[1])
+		}
+		for i := len(suffixes) - 1; i >= 0; i-- {
+			switch s := suffixes[i].(type) {
+			case *types.Func:
+				t = &types.Func{Return: t, Params: s.Params, Variadic: s.Variadic, Unprototyped: s.Unprototyped}
+			case *types.Array:
+				t = &types.Array{Elem: t, Len: s.Len, LenExpr: s.LenExpr}
+			}
+		}
+		return wrapInner(t)
+	}
+	return d
+}
+
+// checkSuffix returns an error when the declarator suffix cannot follow the suffix prev
+func checkSuffix(prev, suffix types.Type) error {
+	switch prev.(type) {
+	case *types.Func:
+		if _, ok := suffix.(*types.Array); ok {
+			return errors.New("Function cannot return an array")
+		}
+		return errors.New("Function cannot return a function")
+	case *types.Array:
+		if _, ok := suffix.(*types.Func); ok {
+			return errors.New("Declaration of an array of functions")
+		}
+		if a := suffix.(*types.Array); a.Len < 0 && a.LenExpr == nil {
+			return fmt.Errorf("Array type has incomplete element type '%s'", prev)
+		}
+	}
+	return nil
+}
+
+// parseArraySuffix returns the array type of an array declarator suffix
+func (p *Parser) parseArraySuffix() (types.Type, error) {
+	// Consume the "["
+	p.Next()
+	if p.Accept("]") {
+		return &types.Array{Len: -1}, nil
+	}
+	exp, err := p.ParseLogicalOrExpression()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.Expect("]"); err != nil {
+		return nil, err
+	}
+	return ArrayType(exp)
+}
+
+// ArrayType returns the array type of length size
+// The length is computed by the generator, the parser keeps it as it is written
+func ArrayType(size ast.Expression) (*types.Array, error) {
+	return &types.Array{Len: -1, LenExpr: size}, nil
+}
+
+// IsNestedDeclarator will return a boolean indicating whether or not the token following a "(" in a declarator
+// starts a nested declarator such as in `int (*op)(int)` rather than a parameter list
+func (p *Parser) IsNestedDeclarator(t *lexer.Token) bool {
+	s := string(t.Value)
+	if s == "*" || s == "(" {
+		return true
+	}
+	return t.Type == lexer.IdentifierToken && !IsTypeSpecifier(t) && !IsTypeQualifier(t) && !p.IsTypedefName(t)
+}
+
+// ParseParameterList will return the parameters of a function declarator, read from the next tokens
+// along the parenthesis, and the function type they make, its return type is left for the caller
+// Names are optional, they are left empty in the returned arguments
+// "(" <param_list> ")"
+// <param_list> ::= [ <param> { "," <param> } [ "," "..." ] ]
+// <param> ::= <specifiers> <declarator>
+func (p *Parser) ParseParameterList() ([]ast.FormalArg, *types.Func, error) {
+	if _, err := p.Expect("("); err != nil {
+		return nil, nil, err
+	}
+	args, err := ast.NewFormalArgList()
+	if err != nil {
+		return nil, nil, err
+	}
+	fn := &types.Func{Params: make([]types.Type, 0)}
+	if p.Accept(")") {
+		// The parameters of a function declared with "()" are not specified
+		fn.Unprototyped = true
+		return args, fn, nil
+	}
+	for {
+		if p.Accept("...") {
+			if len(fn.Params) == 0 {
+				return nil, nil, p.errorf(p.Peek(0), "Expected a named parameter before '...'")
+			}
+			if !p.Accept(")") {
+				return nil, nil, p.errorf(p.Peek(0), "Expected ')' after '...' got %s", describe(p.Peek(0)))
+			}
+			fn.Variadic = true
+			return args, fn, nil
+		}
+		base, err := p.ParseSpecifiers()
+		if err != nil {
+			return nil, nil, err
+		}
+		name, t, err := p.ParseDeclaratorType(base)
+		if err != nil {
+			return nil, nil, err
+		}
+		var arg ast.Attrib
+		if name != nil {
+			arg = name
+		}
+		args, err = ast.AppendFormalArg(args, arg, t)
+		if err != nil {
+			return nil, nil, err
+		}
+		fn.Params = append(fn.Params, t)
+		if p.Accept(")") {
+			return args, fn, nil
+		}
+		if !p.Accept(",") {
+			return nil, nil, p.errorf(p.Peek(0), "Expected ',' or ')' got %s", describe(p.Peek(0)))
+		}
+	}
+}
+
+// ParseStructDefinition will return the StructStatement of a named struct read from the next tokens
+// and the type to use for the declarators following the definition
+// Anonymous structs get no statement, their type holds the members
+// It follows this grammar
+// <struct_definition> ::= "struct" [ <id> ] "{" { <specifiers> <declarator> { "," <declarator> } ";" } "}"
+func (p *Parser) ParseStructDefinition() (ast.Statement, types.Type, error) {
+	structToken := p.Next()
+	var name ast.Attrib
+	if p.Peek(0).Type == lexer.IdentifierToken {
+		name = p.Next()
+	}
+	if _, err := p.Expect("{"); err != nil {
+		return nil, nil, err
+	}
+	fields, err := ast.NewStructFieldList()
+	if err != nil {
+		return nil, nil, err
+	}
+	members := make([]types.Field, 0)
+	for !p.Is("}") {
+		var base types.Type
+		if p.IsStructDefinition() {
+			var nested ast.Statement
+			nested, base, err = p.ParseStructDefinition()
+			if err == nil && nested != nil {
+				err = fmt.Errorf("Nested definition of 'struct %s' is not supported", nested.(*ast.StructStatement).Name)
+			}
+		} else {
+			base, err = p.ParseSpecifiers()
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		for {
+			fieldName, t, err := p.ParseDeclaratorType(base)
+			if err != nil {
+				return nil, nil, err
+			}
+			if fieldName == nil {
+				return nil, nil, p.errorf(p.Peek(0), "Expected member name in struct")
+			}
+			fields, err = ast.AppendStructField(fields, fieldName, t)
+			if err != nil {
+				return nil, nil, err
+			}
+			members = append(members, types.Field{Name: string(fieldName.Value), Type: t})
+			if p.Accept(";") {
+				break
+			}
+			if !p.Accept(",") {
+				return nil, nil, p.errorf(p.Peek(0), "Expected ',' or ';' got %s", describe(p.Peek(0)))
+			}
+		}
+	}
+	rbrace := p.Next()
+	if name == nil {
+		// The members are resolved by the generator along the rest of the type name
+		return nil, &types.Struct{Fields: members}, nil
+	}
+	stmt, err := ast.NewStructStatement(structToken, name, fields, rbrace)
+	if err != nil {
+		return nil, nil, err
+	}
+	return stmt, &types.Named{Name: "struct " + stmt.(*ast.StructStatement).Name}, nil
+}
+
+// ParseEnumDefinition will return an EnumStatement read from the next tokens
+// It follows this grammar
+// <enum_definition> ::= "enum" [ <id> ] "{" <enumerator> { "," <enumerator> } [ "," ] "}"
+// <enumerator> ::= <id> [ "=" <logical_or_exp> ]
+func (p *Parser) ParseEnumDefinition() (ast.Statement, error) {
+	enumToken := p.Next()
+	var name ast.Attrib
+	if p.Peek(0).Type == lexer.IdentifierToken {
+		name = p.Next()
+	}
+	if _, err := p.Expect("{"); err != nil {
+		return nil, err
+	}
+	enumerators, err := ast.NewEnumeratorList()
+	if err != nil {
+		return nil, err
+	}
+	for !p.Is("}") {
+		tName := p.Peek(0)
+		if tName.Type != lexer.IdentifierToken {
+			return nil, p.errorf(tName, "Expected enumerator name, got %s", describe(tName))
+		}
+		p.Next()
+		var value ast.Attrib
+		if p.Accept("=") {
+			exp, err := p.ParseLogicalOrExpression()
+			if err != nil {
+				return nil, err
+			}
+			value = exp
+		}
+		p.DeclareName(string(tName.Value), false)
+		enumerators, err = ast.AppendEnumerator(enumerators, tName, value)
+		if err != nil {
+			return nil, err
+		}
+		if !p.Accept(",") && !p.Is("}") {
+			return nil, p.errorf(p.Peek(0), "Expected ',' or '}' after enumerator '%s'", tName.Value)
+		}
+	}
+	return ast.NewEnumStatement(enumToken, name, enumerators, p.Next())
+}
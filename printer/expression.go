@@ -0,0 +1,133 @@
+package printer
+
+import (
+	"compiler/ast"
+	"compiler/parser"
+	"fmt"
+	"strings"
+)
+
+// precPrimary binds tighter than every operator, identifiers, constants and builtins never need parentheses
+const precPrimary = parser.PrecPostfix + 1
+
+// precedence returns the binding power of the operator at the root of an expression
+func (p *printer) precedence(e ast.Expression) int {
+	switch e := e.(type) {
+	case *ast.CommaExpression:
+		return parser.PrecComma
+	case *ast.AssignExpression:
+		return parser.PrecAssign
+	case *ast.InfixExpression:
+		if op, ok := p.operators.Lookup(e.Operator); ok && op.Infix != nil {
+			return op.Precedence
+		}
+		return parser.PrecLowest
+	case *ast.PrefixExpression, *ast.CastExpression, *ast.SizeofExpression:
+		return parser.PrecUnary
+	case *ast.PostfixExpression, *ast.CallExpression, *ast.IndexExpression, *ast.MemberExpression:
+		return parser.PrecPostfix
+	}
+	return precPrimary
+}
+
+// expression returns the source of an expression, it is parenthesized when its operator binds looser than prec
+func (p *printer) expression(e ast.Expression, prec int) string {
+	s := p.operand(e)
+	if p.precedence(e) < prec {
+		return "(" + s + ")"
+	}
+	return s
+}
+
+// infix returns the operands of a binary operator around it, the operand on the side the operator
+// does not group must bind tighter than the operator
+func (p *printer) infix(op string, left, right ast.Expression, prec int) string {
+	leftPrec, rightPrec := prec, prec+1
+	if entry, ok := p.operators.Lookup(op); ok && entry.Associativity == parser.RightAssoc {
+		leftPrec, rightPrec = prec+1, prec
+	}
+	return p.expression(left, leftPrec) + " " + op + " " + p.expression(right, rightPrec)
+}
+
+func (p *printer) operand(e ast.Expression) string {
+	switch e := e.(type) {
+	case *ast.Identifier:
+		return e.Value
+	case *ast.IntegerLiteral:
+		return e.Value
+	case *ast.CommaExpression:
+		return p.expression(e.Left, parser.PrecComma) + ", " + p.expression(e.Right, parser.PrecComma+1)
+	case *ast.AssignExpression:
+		return p.infix(e.Operator, e.Left, e.Right, parser.PrecAssign)
+	case *ast.InfixExpression:
+		return p.infix(e.Operator, e.Left, e.Right, p.precedence(e))
+	case *ast.PrefixExpression:
+		operand := p.expression(e.Expression, parser.PrecUnary)
+		// "- -x" is not "--x" and "& &x" is not "&&x"
+		if strings.ContainsAny(e.Operator[len(e.Operator)-1:], "+-&") && strings.HasPrefix(operand, e.Operator[len(e.Operator)-1:]) {
+			return e.Operator + " " + operand
+		}
+		return e.Operator + operand
+	case *ast.PostfixExpression:
+		return p.expression(e.Expression, parser.PrecPostfix) + e.Operator
+	case *ast.CastExpression:
+		return "(" + e.Type.String() + ")" + p.expression(e.Expression, parser.PrecUnary)
+	case *ast.SizeofExpression:
+		if e.Expression == nil {
+			return "sizeof(" + e.Type.String() + ")"
+		}
+		// An operand that is not a postfix expression is parenthesized, "sizeof (int)x" would read a type name
+		if p.precedence(e.Expression) < parser.PrecPostfix {
+			return "sizeof(" + p.expression(e.Expression, parser.PrecLowest) + ")"
+		}
+		return "sizeof " + p.expression(e.Expression, parser.PrecPostfix)
+	case *ast.BuiltinExpression:
+		args := p.arguments(e.Arguments)
+		if e.Type != nil {
+			args = append(args, e.Type.String())
+		}
+		return e.Name + "(" + strings.Join(args, ", ") + ")"
+	case *ast.CallExpression:
+		return p.expression(e.Function, parser.PrecPostfix) + "(" + strings.Join(p.arguments(e.Arguments), ", ") + ")"
+	case *ast.IndexExpression:
+		return p.expression(e.Left, parser.PrecPostfix) + "[" + p.expression(e.Index, parser.PrecLowest) + "]"
+	case *ast.MemberExpression:
+		return p.expression(e.Left, parser.PrecPostfix) + e.Operator + e.Member
+	case *ast.InitializerList:
+		return p.initializerList(e)
+	}
+	panic(fmt.Sprintf("printer: unexpected expression %T", e))
+}
+
+// arguments returns the arguments of a call, a comma expression among them is parenthesized
+func (p *printer) arguments(args []ast.Expression) []string {
+	list := make([]string, len(args))
+	for i, arg := range args {
+		list[i] = p.expression(arg, parser.PrecAssign)
+	}
+	return list
+}
+
+// initializerList returns an initializer list on a single line such as "{ 1, [2] = 5, .x = { 0 } }"
+func (p *printer) initializerList(l *ast.InitializerList) string {
+	if len(l.Elements) == 0 {
+		return "{}"
+	}
+	elements := make([]string, len(l.Elements))
+	for i, e := range l.Elements {
+		var b strings.Builder
+		for _, d := range e.Designators {
+			if d.Index != nil {
+				b.WriteString("[" + p.expression(d.Index, parser.PrecLogicalOr) + "]")
+			} else {
+				b.WriteString("." + d.Field)
+			}
+		}
+		if b.Len() != 0 {
+			b.WriteString(" = ")
+		}
+		b.WriteString(p.expression(e.Value, parser.PrecAssign))
+		elements[i] = b.String()
+	}
+	return "{ " + strings.Join(elements, ", ") + " }"
+}
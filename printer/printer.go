@@ -0,0 +1,534 @@
+package printer
+
+import (
+	"bytes"
+	"compiler/ast"
+	"compiler/lexer"
+	"compiler/parser"
+	"compiler/source"
+	"compiler/types"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// indentation is written once per level of nesting
+const indentation = "    "
+
+// printer writes the C source of a tree, the comments are put back between the statements
+// the closest to where they were found
+type printer struct {
+	fset      *source.FileSet
+	comments  []*lexer.Token
+	operators *parser.OperatorTable
+	out       bytes.Buffer
+	indent    int
+	// bol is set at the beginning of a line, before its indentation is written
+	bol bool
+	// lastLine is the source line of the last node or comment printed, 0 when nothing was printed
+	// in the current block
+	lastLine int
+	// blank asks for an empty line before the next statement or comment
+	blank bool
+}
+
+// Fprint writes the C source of a program, a statement or an expression
+// comments are the comments of the source as returned by the parsers, each one is written before
+// the statement following it or at the end of the line of the statement it follows
+// The positions of the nodes are decoded with fset, the comments are left out when fset is nil
+func Fprint(w io.Writer, fset *source.FileSet, node ast.Node, comments []*lexer.Token) error {
+	p := &printer{fset: fset, operators: parser.NewOperatorTable(), bol: true}
+	if fset != nil {
+		p.comments = comments
+	}
+	switch n := node.(type) {
+	case *ast.Program:
+		p.program(n)
+	case ast.Statement:
+		p.statement(n)
+		p.newline()
+	case ast.Expression:
+		p.write(p.expression(n, parser.PrecLowest))
+		p.newline()
+	default:
+		return fmt.Errorf("Cannot print node %T", node)
+	}
+	_, err := p.out.WriteTo(w)
+	return err
+}
+
+// Source returns the C source of a program, see Fprint
+func Source(fset *source.FileSet, program *ast.Program, comments []*lexer.Token) []byte {
+	var b bytes.Buffer
+	Fprint(&b, fset, program, comments)
+	return b.Bytes()
+}
+
+// write writes s on the current line, indenting it first at the beginning of a line
+func (p *printer) write(s string) {
+	if p.bol {
+		p.out.WriteString(strings.Repeat(indentation, p.indent))
+		p.bol = false
+	}
+	p.out.WriteString(s)
+}
+
+func (p *printer) newline() {
+	p.out.WriteByte('\n')
+	p.bol = true
+}
+
+// line returns the source line of a position, 0 when it is not known
+func (p *printer) line(pos source.Pos) int {
+	if p.fset == nil || !pos.IsValid() {
+		return 0
+	}
+	return p.fset.Position(pos).Line
+}
+
+// separate writes an empty line before something starting at line when it was preceded by one in the source
+// or when one was asked for, nothing is written at the start of a block
+func (p *printer) separate(line int) {
+	if p.lastLine == 0 && !p.blank {
+		return
+	}
+	if p.blank || line > p.lastLine+1 && p.lastLine != 0 {
+		p.newline()
+	}
+	p.blank = false
+}
+
+// leadingComments writes the comments found before pos, one per line
+func (p *printer) leadingComments(pos source.Pos) {
+	for len(p.comments) != 0 && pos.IsValid() && p.comments[0].Pos < pos {
+		c := p.comments[0]
+		p.comments = p.comments[1:]
+		p.separate(p.line(c.Pos))
+		p.comment(c)
+		p.newline()
+	}
+}
+
+// trailingComments writes the comments starting on the line of what was just printed after it
+func (p *printer) trailingComments() {
+	for len(p.comments) != 0 && p.lastLine != 0 && p.line(p.comments[0].Pos) == p.lastLine {
+		c := p.comments[0]
+		p.comments = p.comments[1:]
+		p.write(" ")
+		p.comment(c)
+	}
+}
+
+// remainingComments writes the comments left once the program is printed
+func (p *printer) remainingComments() {
+	for _, c := range p.comments {
+		p.separate(p.line(c.Pos))
+		p.comment(c)
+		p.newline()
+	}
+	p.comments = nil
+}
+
+// comment writes a comment as it was written, block comments keep their lines
+func (p *printer) comment(c *lexer.Token) {
+	text := strings.TrimRight(string(c.Value), " \t\r")
+	p.write(text)
+	p.lastLine = p.line(c.Pos) + strings.Count(text, "\n")
+}
+
+// startPos returns the first position found in the tree of a node
+func startPos(node ast.Node) source.Pos {
+	start := source.NoPos
+	ast.Inspect(node, func(n ast.Node) bool {
+		if pos := ast.Pos(n); pos.IsValid() && (pos < start || !start.IsValid()) {
+			start = pos
+		}
+		return true
+	})
+	return start
+}
+
+// endPos returns the last position found in the tree of a node, closing braces included
+func endPos(node ast.Node) source.Pos {
+	end := source.NoPos
+	ast.Inspect(node, func(n ast.Node) bool {
+		positions := []source.Pos{ast.Pos(n)}
+		switch n := n.(type) {
+		case *ast.BlockStatement:
+			positions = append(positions, tokenPos(n.Rbrace))
+		case *ast.StructStatement:
+			positions = append(positions, tokenPos(n.Rbrace))
+		case *ast.EnumStatement:
+			positions = append(positions, tokenPos(n.Rbrace))
+		}
+		for _, pos := range positions {
+			if pos > end {
+				end = pos
+			}
+		}
+		return true
+	})
+	return end
+}
+
+func tokenPos(t *lexer.Token) source.Pos {
+	if t == nil {
+		return source.NoPos
+	}
+	return t.Pos
+}
+
+// program writes the declarations and the functions in the order they are found in the source
+// Definitions written over several lines are set apart by empty lines
+func (p *printer) program(program *ast.Program) {
+	stmts := append(append([]ast.Statement(nil), program.Statements...), program.Functions...)
+	sort.SliceStable(stmts, func(i, j int) bool {
+		a, b := startPos(stmts[i]), startPos(stmts[j])
+		return a.IsValid() && b.IsValid() && a < b
+	})
+	for i, s := range stmts {
+		if i != 0 && (isMultiline(s) || isMultiline(stmts[i-1])) {
+			p.blank = true
+		}
+		p.item(s)
+	}
+	p.remainingComments()
+}
+
+// isMultiline returns whether or not a statement of the top level is written over several lines
+func isMultiline(s ast.Statement) bool {
+	switch s := s.(type) {
+	case *ast.FunctionStatement:
+		return s.Body != nil
+	case *ast.StructStatement, *ast.EnumStatement:
+		return true
+	case *ast.DeclGroup:
+		return len(s.Decls) != 0 && isMultiline(s.Decls[0])
+	}
+	return false
+}
+
+// item writes a statement on lines of its own, with the comments preceding it and the ones following it
+// on its last line
+func (p *printer) item(s ast.Statement) {
+	start := startPos(s)
+	p.leadingComments(start)
+	p.separate(p.line(start))
+	p.statement(s)
+	if line := p.line(endPos(s)); line > p.lastLine {
+		p.lastLine = line
+	}
+	p.trailingComments()
+	p.newline()
+}
+
+// block writes statements between braces, the comments found before rbrace stay in the block
+func (p *printer) block(stmts []ast.Statement, rbrace *lexer.Token) {
+	p.write("{")
+	p.newline()
+	p.indent++
+	p.lastLine, p.blank = 0, false
+	for _, s := range stmts {
+		p.item(s)
+	}
+	p.leadingComments(tokenPos(rbrace))
+	p.indent--
+	p.write("}")
+	p.lastLine = p.line(tokenPos(rbrace))
+}
+
+func (p *printer) statement(s ast.Statement) {
+	switch s := s.(type) {
+	case *ast.DeclGroup:
+		p.declGroup(s)
+	case *ast.DeclStatement:
+		p.declGroup(&ast.DeclGroup{Decls: []ast.Statement{s}})
+	case *ast.TypedefStatement:
+		p.declGroup(&ast.DeclGroup{Decls: []ast.Statement{s}})
+	case *ast.StructStatement:
+		p.structDefinition(s)
+		p.write(";")
+	case *ast.EnumStatement:
+		p.enumDefinition(s)
+		p.write(";")
+	case *ast.FunctionStatement:
+		p.function(s)
+	case *ast.BlockStatement:
+		p.block(s.Statements, s.Rbrace)
+	case *ast.ExpStatement:
+		if s.Expression != nil {
+			p.write(p.expression(s.Expression, parser.PrecLowest))
+		}
+		p.write(";")
+	case *ast.ReturnStatement:
+		p.write("return")
+		if s.ReturnValue != nil {
+			p.write(" " + p.expression(s.ReturnValue, parser.PrecLowest))
+		}
+		p.write(";")
+	case *ast.IfStatement:
+		p.ifStatement(s)
+	case *ast.AsmStatement:
+		p.asm(s)
+	default:
+		panic(fmt.Sprintf("printer: unexpected statement %T", s))
+	}
+}
+
+// body writes the statement of an if or an else, a block stays on the line of the if
+func (p *printer) body(s ast.Statement) {
+	if b, ok := s.(*ast.BlockStatement); ok {
+		p.write(" ")
+		p.block(b.Statements, b.Rbrace)
+		return
+	}
+	p.newline()
+	p.indent++
+	start := startPos(s)
+	p.leadingComments(start)
+	p.statement(s)
+	if line := p.line(endPos(s)); line > p.lastLine {
+		p.lastLine = line
+	}
+	p.trailingComments()
+	p.indent--
+}
+
+func (p *printer) ifStatement(s *ast.IfStatement) {
+	p.write("if (" + p.expression(s.Condition, parser.PrecLowest) + ")")
+	p.body(s.Body)
+	if s.ElseBody == nil {
+		return
+	}
+	if _, ok := s.Body.(*ast.BlockStatement); ok {
+		p.write(" else")
+	} else {
+		p.newline()
+		p.write("else")
+	}
+	if elseIf, ok := s.ElseBody.(*ast.IfStatement); ok {
+		p.write(" ")
+		p.ifStatement(elseIf)
+		return
+	}
+	p.body(s.ElseBody)
+}
+
+// declaration returns the storage class, the type and the name of a declaration of a group
+func declaration(s ast.Statement) (storage string, t types.Type, name string, init ast.Expression) {
+	switch s := s.(type) {
+	case *ast.DeclStatement:
+		return s.Storage, s.Type, s.Left.Value, s.Right
+	case *ast.TypedefStatement:
+		return "typedef", s.Type, s.Name, nil
+	}
+	panic(fmt.Sprintf("printer: unexpected declaration %T", s))
+}
+
+// declGroup writes the declarators of a group after the specifiers they share, a struct or an enum
+// defined by the declaration is written in place of its name
+func (p *printer) declGroup(g *ast.DeclGroup) {
+	decls := g.Decls
+	var def ast.Statement
+	if len(decls) != 0 {
+		switch decls[0].(type) {
+		case *ast.StructStatement, *ast.EnumStatement:
+			def, decls = decls[0], decls[1:]
+		}
+	}
+	if len(decls) == 0 {
+		if def != nil {
+			p.statement(def)
+		}
+		return
+	}
+	storage, t, _, _ := declaration(decls[0])
+	spec := types.Specifier(t)
+	prefix := types.Declaration(spec, "")
+	for _, d := range decls[1:] {
+		s, t, _, _ := declaration(d)
+		if s != storage || types.Declaration(types.Specifier(t), "") != prefix {
+			// Declarations without specifiers in common are written one after the other
+			for i, d := range g.Decls {
+				if i != 0 {
+					p.write(" ")
+				}
+				p.declGroup(&ast.DeclGroup{Decls: []ast.Statement{d}})
+			}
+			return
+		}
+	}
+	if storage != "" {
+		p.write(storage + " ")
+	}
+	if def != nil {
+		if q, ok := spec.(*types.Qualified); ok {
+			p.write(strings.TrimSuffix(types.Declaration(q, ""), " "+types.Declaration(q.Elem, "")) + " ")
+		}
+		if s, ok := def.(*ast.StructStatement); ok {
+			p.structDefinition(s)
+		} else {
+			p.enumDefinition(def.(*ast.EnumStatement))
+		}
+	} else {
+		p.write(prefix)
+	}
+	for i, d := range decls {
+		_, t, name, init := declaration(d)
+		if i != 0 {
+			p.write(",")
+		}
+		declarator := strings.TrimPrefix(types.Declaration(t, name), prefix)
+		if !strings.HasPrefix(declarator, " ") {
+			declarator = " " + declarator
+		}
+		p.write(declarator)
+		if init != nil {
+			p.write(" = " + p.expression(init, parser.PrecAssign))
+		}
+	}
+	p.write(";")
+}
+
+// members writes the lines of a struct or an enum body with their comments
+// pos is the position of each line, write writes it
+func (p *printer) members(n int, pos func(i int) source.Pos, write func(i int), rbrace *lexer.Token) {
+	p.write("{")
+	p.newline()
+	p.indent++
+	p.lastLine, p.blank = 0, false
+	for i := 0; i < n; i++ {
+		start := pos(i)
+		p.leadingComments(start)
+		p.separate(p.line(start))
+		write(i)
+		if line := p.line(start); line > p.lastLine {
+			p.lastLine = line
+		}
+		p.trailingComments()
+		p.newline()
+	}
+	p.leadingComments(tokenPos(rbrace))
+	p.indent--
+	p.write("}")
+	p.lastLine = p.line(tokenPos(rbrace))
+}
+
+func (p *printer) structDefinition(s *ast.StructStatement) {
+	p.write("struct ")
+	if s.Name != "" {
+		p.write(s.Name + " ")
+	}
+	p.members(len(s.Fields), func(i int) source.Pos {
+		return tokenPos(s.Fields[i].Token)
+	}, func(i int) {
+		p.write(types.Declaration(s.Fields[i].Type, s.Fields[i].Name) + ";")
+	}, s.Rbrace)
+}
+
+func (p *printer) enumDefinition(s *ast.EnumStatement) {
+	p.write("enum ")
+	if s.Name != "" {
+		p.write(s.Name + " ")
+	}
+	if len(s.Enumerators) == 0 {
+		p.write("{}")
+		return
+	}
+	p.members(len(s.Enumerators), func(i int) source.Pos {
+		return tokenPos(s.Enumerators[i].Token)
+	}, func(i int) {
+		e := s.Enumerators[i]
+		p.write(e.Name)
+		if e.Value != nil {
+			p.write(" = " + p.expression(e.Value, parser.PrecLogicalOr))
+		}
+		p.write(",")
+	}, s.Rbrace)
+}
+
+func (p *printer) function(f *ast.FunctionStatement) {
+	if f.Storage != "" {
+		p.write(f.Storage + " ")
+	}
+	params := make([]string, 0, len(f.Parameters)+1)
+	for _, param := range f.Parameters {
+		params = append(params, types.Declaration(param.Type, param.Arg))
+	}
+	if f.Variadic {
+		params = append(params, "...")
+	}
+	p.write(types.Declaration(f.Return, f.Name+"("+strings.Join(params, ", ")+")"))
+	if f.Body == nil {
+		p.write(";")
+		return
+	}
+	p.write(" ")
+	p.block(f.Body.Statements, f.Body.Rbrace)
+}
+
+// asm writes an asm statement, the sections of an extended asm are written up to the last one not empty
+func (p *printer) asm(s *ast.AsmStatement) {
+	p.write("asm ")
+	if s.Volatile {
+		p.write("volatile ")
+	}
+	p.write("(" + quote(s.Template))
+	if s.Extended {
+		sections := []string{p.asmOperands(s.Outputs), p.asmOperands(s.Inputs)}
+		clobbers := make([]string, len(s.Clobbers))
+		for i, c := range s.Clobbers {
+			clobbers[i] = quote(c)
+		}
+		sections = append(sections, strings.Join(clobbers, ", "))
+		last := 0
+		for i, section := range sections {
+			if section != "" {
+				last = i
+			}
+		}
+		for _, section := range sections[:last+1] {
+			p.write(" :")
+			if section != "" {
+				p.write(" " + section)
+			}
+		}
+	}
+	p.write(");")
+}
+
+func (p *printer) asmOperands(operands []ast.AsmOperand) string {
+	list := make([]string, len(operands))
+	for i, o := range operands {
+		list[i] = quote(o.Constraint) + " (" + p.expression(o.Expression, parser.PrecLowest) + ")"
+	}
+	return strings.Join(list, ", ")
+}
+
+// quote writes a string literal holding s, the bytes that are not printable ASCII are escaped in octal
+func quote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\n':
+			b.WriteString(`\n`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '"', '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		default:
+			if c < ' ' || c >= 0x7F {
+				fmt.Fprintf(&b, "\\%03o", c)
+			} else {
+				b.WriteByte(c)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
@@ -0,0 +1,84 @@
+package printer
+
+import (
+	"bytes"
+	"compiler/ast"
+	"compiler/lexer"
+	"compiler/parser"
+	"compiler/source"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// parse reads the program of src with the parser called name, "rd" or "lr", and returns its comments
+func parse(t *testing.T, fset *source.FileSet, path string, src []byte, name string) (*ast.Program, []*lexer.Token) {
+	t.Helper()
+	l := lexer.NewFileLexer(bytes.NewReader(src), fset.AddFile(path, -1))
+	var p interface {
+		ParseProgram() (*ast.Program, error)
+		Comments() []*lexer.Token
+	}
+	if name == "rd" {
+		p = parser.NewParser(l)
+	} else {
+		p = parser.NewLRParser(l)
+	}
+	program, err := p.ParseProgram()
+	if err != nil {
+		t.Fatalf("%s parser: %s\n%s", name, err, src)
+	}
+	return program, p.Comments()
+}
+
+// TestRoundTrip prints the sources of the corpus then parses them back with both parsers,
+// the trees have to be the ones of the sources and printing them again gives the same text
+func TestRoundTrip(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("..", "parser", "testdata", "*.c"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := filepath.Glob(filepath.Join("..", "files", "*.c"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths = append(paths, files...)
+	if len(paths) == 0 {
+		t.Fatal("No source in the corpus")
+	}
+	for _, path := range paths {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			src, err := ioutil.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			fset := source.NewFileSet()
+			program, comments := parse(t, fset, path, src, "rd")
+			printed := Source(fset, program, comments)
+			for _, name := range []string{"rd", "lr"} {
+				reparsed, reparsedComments := parse(t, fset, path+".printed", printed, name)
+				if !ast.Equal(program, reparsed) {
+					t.Errorf("%s parser: the printed source gives another tree\n%s", name, printed)
+					continue
+				}
+				if again := Source(fset, reparsed, reparsedComments); !bytes.Equal(printed, again) {
+					t.Errorf("%s parser: printing twice gives\n%s\nthen\n%s", name, printed, again)
+				}
+			}
+		})
+	}
+}
+
+// TestArrayLength checks that the length of an array is printed as it is written rather than computed
+func TestArrayLength(t *testing.T) {
+	src := "enum { N = 3 };\nint arr[N + 1];\nint (*p)[2 * (N - 1)];\nchar b[sizeof(long)];\n"
+	fset := source.NewFileSet()
+	program, comments := parse(t, fset, "arrays.c", []byte(src), "rd")
+	printed := string(Source(fset, program, comments))
+	for _, decl := range []string{"int arr[N + 1];", "int (*p)[2 * (N - 1)];", "char b[sizeof(long)];"} {
+		if !strings.Contains(printed, decl) {
+			t.Errorf("Expected %q in\n%s", decl, printed)
+		}
+	}
+}
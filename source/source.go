@@ -0,0 +1,206 @@
+package source
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Pos is a compact position in the files of a FileSet, it is the base of a file plus an offset in it
+// The zero value is NoPos, it belongs to no file
+type Pos int
+
+// NoPos is the position of what does not come from a source file
+const NoPos Pos = 0
+
+// IsValid returns whether or not the position belongs to a file
+func (p Pos) IsValid() bool {
+	return p != NoPos
+}
+
+// Position is a position decoded to a file name, a line and a column, all counted from 1 except Offset
+// Column counts bytes
+type Position struct {
+	Filename string
+	Offset   int
+	Line     int
+	Column   int
+}
+
+// IsValid returns whether or not the position has a line
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
+// String returns the position as file:line:col, the parts that are not known are left out
+func (p Position) String() string {
+	s := p.Filename
+	if p.IsValid() {
+		if s != "" {
+			s += ":"
+		}
+		s += fmt.Sprintf("%d:%d", p.Line, p.Column)
+	}
+	if s == "" {
+		s = "-"
+	}
+	return s
+}
+
+// File is a source file of a FileSet and the offsets where its lines start
+// A file created without a size grows as positions are taken in it, until another file is added
+type File struct {
+	name  string
+	base  int
+	size  int
+	open  bool
+	lines []int
+}
+
+// Name returns the name the file was added with
+func (f *File) Name() string {
+	return f.name
+}
+
+// Base returns the position of the first byte of the file
+func (f *File) Base() int {
+	return f.base
+}
+
+// Size returns the size of the file, only the bytes seen so far for a file still growing
+func (f *File) Size() int {
+	return f.size
+}
+
+// LineCount returns the number of lines of the file known so far
+func (f *File) LineCount() int {
+	return len(f.lines)
+}
+
+// Lines returns the offsets where the lines known so far start, the first one is always 0
+func (f *File) Lines() []int {
+	return append([]int(nil), f.lines...)
+}
+
+// AddLine records that a line starts at offset, offsets have to be added in increasing order
+// and an offset that is not past the last line start is ignored
+func (f *File) AddLine(offset int) {
+	if offset <= f.lines[len(f.lines)-1] {
+		return
+	}
+	f.grow(offset)
+	f.lines = append(f.lines, offset)
+}
+
+// SetLinesForContent builds the line table of the file from its content at once
+func (f *File) SetLinesForContent(content []byte) {
+	for i, c := range content {
+		if c == '\n' {
+			f.AddLine(i + 1)
+		}
+	}
+	f.grow(len(content))
+}
+
+// grow extends a file still growing so it holds offset
+func (f *File) grow(offset int) {
+	if f.open && offset > f.size {
+		f.size = offset
+	}
+}
+
+// Pos returns the position of the byte at offset
+func (f *File) Pos(offset int) Pos {
+	f.grow(offset)
+	if offset < 0 || offset > f.size {
+		panic(fmt.Sprintf("Offset %d is outside of file '%s' of size %d", offset, f.name, f.size))
+	}
+	return Pos(f.base + offset)
+}
+
+// contains returns whether or not the position is in the range of the file
+func (f *File) contains(p Pos) bool {
+	return int(p) >= f.base && (f.open || int(p) <= f.base+f.size)
+}
+
+// Offset returns the offset in the file of a position
+func (f *File) Offset(p Pos) int {
+	if !f.contains(p) {
+		panic(fmt.Sprintf("Position %d is outside of file '%s'", p, f.name))
+	}
+	return int(p) - f.base
+}
+
+// Line returns the line of a position
+func (f *File) Line(p Pos) int {
+	return f.Position(p).Line
+}
+
+// Position decodes a position of the file, the line is found with a binary search of the line starts
+func (f *File) Position(p Pos) Position {
+	offset := f.Offset(p)
+	// The line is the last one starting at or before offset
+	line := sort.Search(len(f.lines), func(i int) bool { return f.lines[i] > offset })
+	return Position{Filename: f.name, Offset: offset, Line: line, Column: offset - f.lines[line-1] + 1}
+}
+
+// FileSet holds the source files of a compilation, each one gets a range of positions of its own
+type FileSet struct {
+	base  int
+	files []*File
+	// last is the file of the previous lookup, positions are often decoded in the same file
+	last *File
+}
+
+// NewFileSet creates an empty FileSet
+func NewFileSet() *FileSet {
+	// Position 0 is NoPos
+	return &FileSet{base: 1}
+}
+
+// AddFile adds a file of the given size, a negative size makes it grow until the next file is added
+func (s *FileSet) AddFile(name string, size int) *File {
+	if n := len(s.files); n != 0 && s.files[n-1].open {
+		last := s.files[n-1]
+		last.open = false
+		s.base = last.base + last.size + 1
+	}
+	f := &File{name: name, base: s.base, size: size, lines: []int{0}}
+	if size < 0 {
+		f.open, f.size = true, 0
+	} else {
+		// One more position for the end of the file
+		s.base += size + 1
+	}
+	s.files = append(s.files, f)
+	return f
+}
+
+// Files returns the files in the order they were added
+func (s *FileSet) Files() []*File {
+	return s.files
+}
+
+// File returns the file holding a position, nil if there is none
+func (s *FileSet) File(p Pos) *File {
+	if f := s.last; f != nil && f.contains(p) {
+		return f
+	}
+	i := sort.Search(len(s.files), func(i int) bool { return s.files[i].base > int(p) }) - 1
+	if i < 0 || !s.files[i].contains(p) {
+		return nil
+	}
+	s.last = s.files[i]
+	return s.files[i]
+}
+
+// Position decodes a position, NoPos and positions outside of the files give an invalid Position
+func (s *FileSet) Position(p Pos) Position {
+	if !p.IsValid() {
+		return Position{}
+	}
+	f := s.File(p)
+	if f == nil {
+		return Position{}
+	}
+	return f.Position(p)
+}
@@ -0,0 +1,710 @@
+// Command lrgen generates the tables of an LALR(1) parser from a grammar written in BNF
+//
+// Usage:
+//
+//	lrgen [-o output] [-package name] [-imports path,...] grammar.bnf
+//
+// A grammar is a list of rules, the first one is the start symbol
+//
+//	Rule : Symbol Symbol << action >> | empty << action >> ;
+//
+// Rule names start with an upper case letter, terminals are either quoted like "int" and ";"
+// or token classes starting with a lower case letter like id
+// An action is a Go expression giving an ast.Attrib and an error, $0, $1... are the values of the symbols
+// of the alternative and p is the parser, an alternative without action gives the value of its first symbol
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+func main() {
+	output := flag.String("o", "lr_tables.go", "Output file")
+	pkg := flag.String("package", "parser", "Package of the output file")
+	imports := flag.String("imports", "compiler/ast", "Comma separated packages the actions can use")
+	flag.Parse()
+	if flag.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: lrgen [-o output] [-package name] [-imports path,...] grammar.bnf")
+		os.Exit(2)
+	}
+	src := flag.Arg(0)
+	b, err := ioutil.ReadFile(src)
+	check(err)
+	rules, err := parseGrammar(string(b))
+	check(err)
+	g, err := newGrammar(rules)
+	check(err)
+	t := g.build()
+	if len(t.conflicts) != 0 {
+		for _, c := range t.conflicts {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", src, c)
+		}
+		fmt.Fprintf(os.Stderr, "%s: %d conflicts\n", src, len(t.conflicts))
+		os.Exit(1)
+	}
+	code, err := g.generate(t, filepath.Base(src), *pkg, strings.Split(*imports, ","))
+	check(err)
+	check(ioutil.WriteFile(*output, code, 0644))
+}
+
+func check(err error) {
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// rawAlternative is an alternative of a rule as written in the grammar
+type rawAlternative struct {
+	symbols []string
+	action  string
+	line    int
+}
+
+// rawRule is a rule as written in the grammar
+type rawRule struct {
+	name         string
+	alternatives []rawAlternative
+	line         int
+}
+
+// bnfToken is a token of the grammar file, kind is one of "id", "string", "action" or the punctuation itself
+type bnfToken struct {
+	kind  string
+	value string
+	line  int
+}
+
+// scanGrammar splits a grammar into tokens, comments are dropped
+func scanGrammar(src string) ([]bnfToken, error) {
+	tokens := make([]bnfToken, 0)
+	line := 1
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case strings.HasPrefix(src[i:], "//"):
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("%d: Unterminated comment", line)
+			}
+			line += strings.Count(src[i:i+2+end], "\n")
+			i += end + 4
+		case strings.HasPrefix(src[i:], "<<"):
+			end := strings.Index(src[i+2:], ">>")
+			if end < 0 {
+				return nil, fmt.Errorf("%d: Unterminated action", line)
+			}
+			action := src[i+2 : i+2+end]
+			tokens = append(tokens, bnfToken{"action", strings.TrimSpace(action), line})
+			line += strings.Count(action, "\n")
+			i += end + 4
+		case c == '"':
+			end := strings.IndexAny(src[i+1:], "\"\n")
+			if end < 0 || src[i+1+end] != '"' {
+				return nil, fmt.Errorf("%d: Unterminated terminal", line)
+			}
+			tokens = append(tokens, bnfToken{"string", src[i : i+2+end], line})
+			i += end + 2
+		case c == ':' || c == '|' || c == ';':
+			tokens = append(tokens, bnfToken{string(c), string(c), line})
+			i++
+		case isLetter(c):
+			j := i
+			for j < len(src) && (isLetter(src[j]) || (src[j] >= '0' && src[j] <= '9')) {
+				j++
+			}
+			tokens = append(tokens, bnfToken{"id", src[i:j], line})
+			i = j
+		default:
+			return nil, fmt.Errorf("%d: Unexpected '%c'", line, c)
+		}
+	}
+	return tokens, nil
+}
+
+func isLetter(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// parseGrammar returns the rules of a grammar
+func parseGrammar(src string) ([]rawRule, error) {
+	tokens, err := scanGrammar(src)
+	if err != nil {
+		return nil, err
+	}
+	rules := make([]rawRule, 0)
+	for i := 0; i < len(tokens); {
+		name := tokens[i]
+		if name.kind != "id" || !isRuleName(name.value) {
+			return nil, fmt.Errorf("%d: Expected rule name got '%s'", name.line, name.value)
+		}
+		if i+1 >= len(tokens) || tokens[i+1].kind != ":" {
+			return nil, fmt.Errorf("%d: Expected ':' after '%s'", name.line, name.value)
+		}
+		rule := rawRule{name: name.value, line: name.line}
+		i += 2
+		alt := rawAlternative{line: name.line}
+		for {
+			if i >= len(tokens) {
+				return nil, fmt.Errorf("%d: Expected ';' at the end of '%s'", name.line, name.value)
+			}
+			t := tokens[i]
+			i++
+			switch t.kind {
+			case "id", "string":
+				if alt.action != "" {
+					return nil, fmt.Errorf("%d: Unexpected '%s' after the action", t.line, t.value)
+				}
+				if len(alt.symbols) == 0 {
+					alt.line = t.line
+				}
+				alt.symbols = append(alt.symbols, t.value)
+				continue
+			case "action":
+				if alt.action != "" {
+					return nil, fmt.Errorf("%d: Alternative has two actions", t.line)
+				}
+				alt.action = t.value
+				continue
+			case ":":
+				return nil, fmt.Errorf("%d: Unexpected ':', missing ';' at the end of the previous rule", t.line)
+			}
+			if len(alt.symbols) == 0 {
+				return nil, fmt.Errorf("%d: Empty alternative in '%s', use empty", alt.line, name.value)
+			}
+			if len(alt.symbols) == 1 && alt.symbols[0] == "empty" {
+				alt.symbols = nil
+			}
+			rule.alternatives = append(rule.alternatives, alt)
+			alt = rawAlternative{line: t.line}
+			if t.kind == ";" {
+				break
+			}
+		}
+		rules = append(rules, rule)
+	}
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("Grammar has no rule")
+	}
+	return rules, nil
+}
+
+func isRuleName(s string) bool {
+	return s[0] >= 'A' && s[0] <= 'Z'
+}
+
+// production is an alternative of a rule, its symbols are numbered as explained in grammar
+type production struct {
+	lhs    int
+	rhs    []int
+	action string
+	line   int
+}
+
+// grammar numbers the terminals first, the end of the input being 0, then the rules
+type grammar struct {
+	symbols     []string
+	terminals   int
+	productions []production
+	// byLHS holds the productions of each rule
+	byLHS    map[int][]int
+	nullable []bool
+	first    []bitset
+}
+
+// newGrammar numbers the symbols of the rules and adds the production accepting the start symbol
+func newGrammar(rules []rawRule) (*grammar, error) {
+	g := &grammar{symbols: []string{"$"}, byLHS: map[int][]int{}}
+	index := map[string]int{"$": 0}
+	for _, r := range rules {
+		for _, alt := range r.alternatives {
+			for _, s := range alt.symbols {
+				if isRuleName(s) || s == "empty" {
+					continue
+				}
+				name := s
+				if s[0] == '"' {
+					name = "'" + s[1:len(s)-1] + "'"
+				}
+				if _, ok := index[name]; !ok {
+					index[name] = len(g.symbols)
+					g.symbols = append(g.symbols, name)
+				}
+			}
+		}
+	}
+	g.terminals = len(g.symbols)
+	for _, r := range rules {
+		if _, ok := index[r.name]; ok {
+			return nil, fmt.Errorf("%d: Rule '%s' is defined twice", r.line, r.name)
+		}
+		index[r.name] = len(g.symbols)
+		g.symbols = append(g.symbols, r.name)
+	}
+	accept := len(g.symbols)
+	g.symbols = append(g.symbols, "$accept")
+	g.addProduction(production{lhs: accept, rhs: []int{g.terminals}})
+	for _, r := range rules {
+		for _, alt := range r.alternatives {
+			p := production{lhs: index[r.name], action: alt.action, line: alt.line}
+			for _, s := range alt.symbols {
+				if s == "empty" {
+					return nil, fmt.Errorf("%d: empty has to be alone in its alternative", alt.line)
+				}
+				if s[0] == '"' {
+					s = "'" + s[1:len(s)-1] + "'"
+				}
+				sym, ok := index[s]
+				if !ok {
+					return nil, fmt.Errorf("%d: Rule '%s' is not defined", alt.line, s)
+				}
+				p.rhs = append(p.rhs, sym)
+			}
+			g.addProduction(p)
+		}
+	}
+	g.computeFirst()
+	return g, nil
+}
+
+func (g *grammar) addProduction(p production) {
+	g.byLHS[p.lhs] = append(g.byLHS[p.lhs], len(g.productions))
+	g.productions = append(g.productions, p)
+}
+
+func (g *grammar) isTerminal(sym int) bool {
+	return sym < g.terminals
+}
+
+// computeFirst computes which rules derive the empty string and the terminals starting each rule
+func (g *grammar) computeFirst() {
+	g.nullable = make([]bool, len(g.symbols))
+	g.first = make([]bitset, len(g.symbols))
+	for i := range g.symbols {
+		g.first[i] = newBitset(g.terminals + 1)
+		if g.isTerminal(i) {
+			g.first[i].add(i)
+		}
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, p := range g.productions {
+			nullable := true
+			for _, s := range p.rhs {
+				if g.first[p.lhs].union(g.first[s]) {
+					changed = true
+				}
+				if !g.nullable[s] {
+					nullable = false
+					break
+				}
+			}
+			if nullable && !g.nullable[p.lhs] {
+				g.nullable[p.lhs] = true
+				changed = true
+			}
+		}
+	}
+}
+
+// firstOf returns the terminals starting the symbols, lookahead is added when all of them can be empty
+func (g *grammar) firstOf(symbols []int, lookahead bitset) bitset {
+	set := newBitset(g.terminals + 1)
+	for _, s := range symbols {
+		set.union(g.first[s])
+		if !g.nullable[s] {
+			return set
+		}
+	}
+	set.union(lookahead)
+	return set
+}
+
+// item is a production whose symbols before dot were read
+type item struct {
+	prod int
+	dot  int
+}
+
+// state is a state of the LR(0) automaton, its kernel items get LALR(1) lookaheads
+type state struct {
+	kernel      []item
+	lookaheads  []bitset
+	transitions map[int]int
+}
+
+// tables holds the generated automaton
+type tables struct {
+	states    []*state
+	actions   []map[int]int
+	defaults  []int
+	conflicts []string
+}
+
+// closure returns the LR(1) closure of items, the lookaheads are merged per item
+func (g *grammar) closure(kernel []item, lookaheads []bitset) ([]item, []bitset) {
+	items := append([]item{}, kernel...)
+	sets := make([]bitset, len(kernel))
+	index := map[item]int{}
+	for i, it := range kernel {
+		sets[i] = newBitset(g.terminals + 1)
+		if lookaheads[i] != nil {
+			sets[i].union(lookaheads[i])
+		}
+		index[it] = i
+	}
+	for changed := true; changed; {
+		changed = false
+		for i := 0; i < len(items); i++ {
+			rhs := g.productions[items[i].prod].rhs
+			if items[i].dot >= len(rhs) || g.isTerminal(rhs[items[i].dot]) {
+				continue
+			}
+			la := g.firstOf(rhs[items[i].dot+1:], sets[i])
+			for _, prod := range g.byLHS[rhs[items[i].dot]] {
+				it := item{prod, 0}
+				j, ok := index[it]
+				if !ok {
+					index[it] = len(items)
+					items = append(items, it)
+					sets = append(sets, la.clone())
+					changed = true
+					continue
+				}
+				if sets[j].union(la) {
+					changed = true
+				}
+			}
+		}
+	}
+	return items, sets
+}
+
+// build computes the LALR(1) automaton by propagating the lookaheads through the LR(0) one
+func (g *grammar) build() *tables {
+	t := &tables{}
+	index := map[string]int{}
+	key := func(kernel []item) string {
+		var sb strings.Builder
+		for _, it := range kernel {
+			fmt.Fprintf(&sb, "%d.%d ", it.prod, it.dot)
+		}
+		return sb.String()
+	}
+	add := func(kernel []item) int {
+		k := key(kernel)
+		if i, ok := index[k]; ok {
+			return i
+		}
+		s := &state{kernel: kernel, transitions: map[int]int{}}
+		for range kernel {
+			s.lookaheads = append(s.lookaheads, newBitset(g.terminals+1))
+		}
+		index[k] = len(t.states)
+		t.states = append(t.states, s)
+		return len(t.states) - 1
+	}
+	add([]item{{0, 0}})
+	for i := 0; i < len(t.states); i++ {
+		s := t.states[i]
+		items, _ := g.closure(s.kernel, make([]bitset, len(s.kernel)))
+		next := map[int][]item{}
+		symbols := make([]int, 0)
+		for _, it := range items {
+			rhs := g.productions[it.prod].rhs
+			if it.dot >= len(rhs) {
+				continue
+			}
+			sym := rhs[it.dot]
+			if _, ok := next[sym]; !ok {
+				symbols = append(symbols, sym)
+			}
+			next[sym] = append(next[sym], item{it.prod, it.dot + 1})
+		}
+		sort.Ints(symbols)
+		for _, sym := range symbols {
+			kernel := next[sym]
+			sort.Slice(kernel, func(a, b int) bool {
+				if kernel[a].prod != kernel[b].prod {
+					return kernel[a].prod < kernel[b].prod
+				}
+				return kernel[a].dot < kernel[b].dot
+			})
+			s.transitions[sym] = add(kernel)
+		}
+	}
+
+	// The lookahead '#' stands for the lookaheads of the kernel item the closure was computed from,
+	// it tells where they propagate to
+	hash := g.terminals
+	type edge struct{ state, item int }
+	propagate := map[edge][]edge{}
+	kernelIndex := func(s *state, it item) int {
+		for i, k := range s.kernel {
+			if k == it {
+				return i
+			}
+		}
+		panic("missing kernel item")
+	}
+	t.states[0].lookaheads[0].add(0)
+	for i, s := range t.states {
+		for k := range s.kernel {
+			la := newBitset(g.terminals + 1)
+			la.add(hash)
+			items, sets := g.closure(s.kernel[k:k+1], []bitset{la})
+			for j, it := range items {
+				rhs := g.productions[it.prod].rhs
+				if it.dot >= len(rhs) {
+					continue
+				}
+				target := s.transitions[rhs[it.dot]]
+				to := edge{target, kernelIndex(t.states[target], item{it.prod, it.dot + 1})}
+				for _, a := range sets[j].members() {
+					if a == hash {
+						propagate[edge{i, k}] = append(propagate[edge{i, k}], to)
+					} else {
+						t.states[to.state].lookaheads[to.item].add(a)
+					}
+				}
+			}
+		}
+	}
+	for changed := true; changed; {
+		changed = false
+		for i, s := range t.states {
+			for k := range s.kernel {
+				for _, to := range propagate[edge{i, k}] {
+					if t.states[to.state].lookaheads[to.item].union(s.lookaheads[k]) {
+						changed = true
+					}
+				}
+			}
+		}
+	}
+
+	for i, s := range t.states {
+		actions := map[int]int{}
+		for sym, target := range s.transitions {
+			if g.isTerminal(sym) {
+				actions[sym] = target + 1
+			}
+		}
+		items, sets := g.closure(s.kernel, s.lookaheads)
+		reduces := map[int]bool{}
+		for j, it := range items {
+			if it.dot < len(g.productions[it.prod].rhs) {
+				continue
+			}
+			for _, a := range sets[j].members() {
+				if a == hash {
+					continue
+				}
+				prev, ok := actions[a]
+				switch {
+				case !ok:
+					actions[a] = -(it.prod + 1)
+					reduces[it.prod] = true
+				case prev > 0:
+					t.conflicts = append(t.conflicts, fmt.Sprintf("state %d: shift/reduce conflict on %s between %s and %s",
+						i, g.symbols[a], g.describeShift(s, a), g.describeProduction(it.prod)))
+				case prev != -(it.prod + 1):
+					t.conflicts = append(t.conflicts, fmt.Sprintf("state %d: reduce/reduce conflict on %s between %s and %s",
+						i, g.symbols[a], g.describeProduction(-prev-1), g.describeProduction(it.prod)))
+				}
+			}
+		}
+		// A state that can only reduce by a single production does so without reading the lookahead,
+		// actions of the grammar can then change how the next token is read
+		def := 0
+		shifts := false
+		for _, a := range actions {
+			shifts = shifts || a > 0
+		}
+		if len(reduces) == 1 && !shifts {
+			for prod := range reduces {
+				def = prod
+			}
+		}
+		if def > 0 {
+			actions = map[int]int{}
+		}
+		t.actions = append(t.actions, actions)
+		t.defaults = append(t.defaults, def)
+	}
+	return t
+}
+
+// describeProduction returns a production as written in the grammar
+func (g *grammar) describeProduction(prod int) string {
+	p := g.productions[prod]
+	symbols := make([]string, 0, len(p.rhs))
+	for _, s := range p.rhs {
+		symbols = append(symbols, g.symbols[s])
+	}
+	if len(symbols) == 0 {
+		symbols = append(symbols, "empty")
+	}
+	return fmt.Sprintf("%s : %s (line %d)", g.symbols[p.lhs], strings.Join(symbols, " "), p.line)
+}
+
+// describeShift returns the items of a state reading the terminal sym
+func (g *grammar) describeShift(s *state, sym int) string {
+	items, _ := g.closure(s.kernel, make([]bitset, len(s.kernel)))
+	shifts := make([]string, 0)
+	for _, it := range items {
+		rhs := g.productions[it.prod].rhs
+		if it.dot < len(rhs) && rhs[it.dot] == sym {
+			shifts = append(shifts, g.describeProduction(it.prod))
+		}
+	}
+	return strings.Join(shifts, ", ")
+}
+
+var attribRef = regexp.MustCompile(`\$([0-9]+)`)
+
+// generate returns the Go source of the tables
+func (g *grammar) generate(t *tables, src, pkg string, imports []string) ([]byte, error) {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by lrgen from %s. DO NOT EDIT.\n\npackage %s\n\nimport (\n", src, pkg)
+	actions := ""
+	for _, p := range g.productions {
+		actions += p.action + "\n"
+	}
+	for _, imp := range imports {
+		name := path.Base(imp)
+		if name == "ast" || strings.Contains(actions, name+".") {
+			fmt.Fprintf(&b, "\t%q\n", imp)
+		}
+	}
+	b.WriteString(")\n\n")
+
+	b.WriteString("// lrTerminals holds the terminals of the grammar, the end of the input comes first\n")
+	b.WriteString("var lrTerminals = []string{\n")
+	for _, s := range g.symbols[:g.terminals] {
+		fmt.Fprintf(&b, "\t%q,\n", s)
+	}
+	b.WriteString("}\n\n")
+
+	b.WriteString("// lrProductions holds the productions of the grammar, reducing the first one accepts the input\n")
+	b.WriteString("var lrProductions = []lrProduction{\n")
+	for i, p := range g.productions {
+		fmt.Fprintf(&b, "\t// %s\n", strings.Replace(g.describeProduction(i), " (line 0)", "", 1))
+		if p.action == "" {
+			fmt.Fprintf(&b, "\t{lhs: %d, size: %d},\n", p.lhs-g.terminals, len(p.rhs))
+			continue
+		}
+		action := attribRef.ReplaceAllStringFunc(p.action, func(ref string) string {
+			return "X[" + ref[1:] + "]"
+		})
+		fmt.Fprintf(&b, "\t{lhs: %d, size: %d, reduce: func(p *LRParser, X []ast.Attrib) (ast.Attrib, error) {\n\t\treturn %s\n\t}},\n",
+			p.lhs-g.terminals, len(p.rhs), action)
+	}
+	b.WriteString("}\n\n")
+
+	b.WriteString("// lrActions holds the actions of each state as pairs of a terminal and an action,\n")
+	b.WriteString("// a positive action shifts to the state action-1 and a negative one reduces the production -action-1\n")
+	b.WriteString("var lrActions = [][]int{\n")
+	for _, actions := range t.actions {
+		terminals := make([]int, 0, len(actions))
+		for a := range actions {
+			terminals = append(terminals, a)
+		}
+		sort.Ints(terminals)
+		pairs := make([]string, 0, 2*len(terminals))
+		for _, a := range terminals {
+			pairs = append(pairs, fmt.Sprint(a), fmt.Sprint(actions[a]))
+		}
+		fmt.Fprintf(&b, "\t{%s},\n", strings.Join(pairs, ", "))
+	}
+	b.WriteString("}\n\n")
+
+	b.WriteString("// lrGotos holds the state following each state once a rule is reduced, as pairs of a rule and a state\n")
+	b.WriteString("var lrGotos = [][]int{\n")
+	for _, s := range t.states {
+		rules := make([]int, 0)
+		for sym := range s.transitions {
+			if !g.isTerminal(sym) {
+				rules = append(rules, sym)
+			}
+		}
+		sort.Ints(rules)
+		pairs := make([]string, 0, 2*len(rules))
+		for _, r := range rules {
+			pairs = append(pairs, fmt.Sprint(r-g.terminals), fmt.Sprint(s.transitions[r]))
+		}
+		fmt.Fprintf(&b, "\t{%s},\n", strings.Join(pairs, ", "))
+	}
+	b.WriteString("}\n\n")
+
+	b.WriteString("// lrDefaults holds for each state the production it reduces without reading the lookahead, 0 if none\n")
+	b.WriteString("var lrDefaults = []int{")
+	for i, d := range t.defaults {
+		if i%20 == 0 {
+			b.WriteString("\n\t")
+		} else {
+			b.WriteString(" ")
+		}
+		fmt.Fprintf(&b, "%d,", d)
+	}
+	b.WriteString("\n}\n")
+	return format.Source(b.Bytes())
+}
+
+// bitset is a set of small integers
+type bitset []uint64
+
+func newBitset(n int) bitset {
+	return make(bitset, (n+63)/64)
+}
+
+func (b bitset) add(i int) {
+	b[i/64] |= 1 << uint(i%64)
+}
+
+// union adds the members of o to b and returns whether or not b changed
+func (b bitset) union(o bitset) bool {
+	changed := false
+	for i := range o {
+		if b[i]|o[i] != b[i] {
+			b[i] |= o[i]
+			changed = true
+		}
+	}
+	return changed
+}
+
+func (b bitset) clone() bitset {
+	return append(bitset{}, b...)
+}
+
+func (b bitset) members() []int {
+	m := make([]int, 0)
+	for i, w := range b {
+		for j := 0; j < 64; j++ {
+			if w&(1<<uint(j)) != 0 {
+				m = append(m, i*64+j)
+			}
+		}
+	}
+	return m
+}
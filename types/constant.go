@@ -0,0 +1,67 @@
+package types
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// IntegerConstant returns the value and the type of an integer constant written in decimal, octal or hexadecimal
+// with an optional u, l or ll suffix
+// The type is the first one of the list of C11 6.4.4.1 able to hold the value, decimal constants without
+// a "u" suffix are never unsigned
+func IntegerConstant(literal string) (int64, Type, error) {
+	digits := strings.TrimRight(literal, "uUlL")
+	suffix := strings.ToLower(literal[len(digits):])
+	base := 10
+	switch {
+	case strings.HasPrefix(digits, "0x") || strings.HasPrefix(digits, "0X"):
+		base, digits = 16, digits[2:]
+	case len(digits) > 1 && digits[0] == '0':
+		base, digits = 8, digits[1:]
+	}
+	v, err := strconv.ParseUint(digits, base, 64)
+	if err != nil {
+		if ne, ok := err.(*strconv.NumError); ok && ne.Err == strconv.ErrRange {
+			return 0, nil, fmt.Errorf("Integer constant '%s' is too large for its type", literal)
+		}
+		return 0, nil, fmt.Errorf("Invalid integer constant '%s'", literal)
+	}
+
+	unsigned := strings.Contains(suffix, "u")
+	long := strings.Contains(suffix, "l")
+	var candidates []*Basic
+	switch {
+	case unsigned && long:
+		candidates = []*Basic{UnsignedLong}
+	case unsigned:
+		candidates = []*Basic{UnsignedInt, UnsignedLong}
+	case long && base == 10:
+		candidates = []*Basic{Long}
+	case long:
+		candidates = []*Basic{Long, UnsignedLong}
+	case base == 10:
+		candidates = []*Basic{Int, Long}
+	default:
+		candidates = []*Basic{Int, UnsignedInt, Long, UnsignedLong}
+	}
+	for _, t := range candidates {
+		if v <= maxValue(t) {
+			return int64(v), t, nil
+		}
+	}
+	return 0, nil, fmt.Errorf("Integer constant '%s' is too large for its type", literal)
+}
+
+// maxValue returns the largest value of an integer type
+func maxValue(t *Basic) uint64 {
+	bits := uint(t.Bytes * 8)
+	if t.Unsigned {
+		if bits == 64 {
+			return math.MaxUint64
+		}
+		return 1<<bits - 1
+	}
+	return 1<<(bits-1) - 1
+}
@@ -0,0 +1,465 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Scope gives Resolve what it needs to know about the scope a type is used in
+type Scope struct {
+	// Lookup is called for the names of the Named types such as typedef names, "enum Tag" or "struct Tag"
+	Lookup func(name string) (Type, error)
+	// Eval computes the lengths of arrays written as expressions
+	Eval func(e Expr) (int64, error)
+	// Structs holds the anonymous structs laid out so far by their members when it is not nil,
+	// the declarators of a declaration share their struct so they have to get the same type
+	Structs map[string]*Struct
+}
+
+// Resolve returns t with the types it refers to by name replaced by the types they name and the lengths
+// of its arrays computed, a nil scope only resolves the types that do not need one
+// The members of anonymous structs are laid out and parameters of array or function type are adjusted to pointers
+func Resolve(t Type, scope *Scope) (Type, error) {
+	if scope == nil {
+		scope = &Scope{}
+	}
+	switch t := t.(type) {
+	case *Named:
+		if scope.Lookup == nil {
+			return nil, fmt.Errorf("Unknown type '%s'", t.Name)
+		}
+		return scope.Lookup(t.Name)
+	case *Qualified:
+		elem, err := Resolve(t.Elem, scope)
+		if err != nil {
+			return nil, err
+		}
+		return Qualify(elem, t.Const, t.Volatile), nil
+	case *Pointer:
+		elem, err := Resolve(t.Elem, scope)
+		if err != nil {
+			return nil, err
+		}
+		return &Pointer{Elem: elem}, nil
+	case *Array:
+		elem, err := Resolve(t.Elem, scope)
+		if err != nil {
+			return nil, err
+		}
+		if t.LenExpr == nil {
+			return &Array{Elem: elem, Len: t.Len}, nil
+		}
+		n, err := arrayLength(t.LenExpr, scope.Eval)
+		if err != nil {
+			return nil, fmt.Errorf("Array size is not an integer constant: %s", err)
+		}
+		if n <= 0 {
+			return nil, fmt.Errorf("Array size %d is not positive", n)
+		}
+		return &Array{Elem: elem, Len: int(n)}, nil
+	case *Func:
+		ret, err := Resolve(t.Return, scope)
+		if err != nil {
+			return nil, err
+		}
+		fn := &Func{Return: ret, Params: make([]Type, len(t.Params)), Variadic: t.Variadic, Unprototyped: t.Unprototyped}
+		for i, param := range t.Params {
+			param, err = Resolve(param, scope)
+			if err != nil {
+				return nil, err
+			}
+			fn.Params[i] = Decay(param)
+		}
+		return fn, nil
+	case *Struct:
+		if t.Defined || t.Tag != "" {
+			return t, nil
+		}
+		// The members are written in the name of an anonymous struct
+		if s, ok := scope.Structs[t.String()]; ok {
+			return s, nil
+		}
+		fields := make([]Field, len(t.Fields))
+		for i, f := range t.Fields {
+			ft, err := Resolve(f.Type, scope)
+			if err != nil {
+				return nil, err
+			}
+			fields[i] = Field{Name: f.Name, Type: ft}
+		}
+		s := &Struct{}
+		if err := s.Define(fields); err != nil {
+			return nil, err
+		}
+		if scope.Structs != nil {
+			scope.Structs[t.String()] = s
+		}
+		return s, nil
+	}
+	return t, nil
+}
+
+// arrayLength computes the length of an array with eval, a length read by Parse is only known by its text
+// and has to be an integer literal unless it is replaced by the expression it was written with
+func arrayLength(e Expr, eval func(e Expr) (int64, error)) (int64, error) {
+	if text, ok := e.(ExprText); ok {
+		v, _, err := IntegerConstant(string(text))
+		if err != nil {
+			return 0, fmt.Errorf("'%s' is not an integer literal", text)
+		}
+		return v, nil
+	}
+	if eval == nil {
+		return 0, fmt.Errorf("'%s' cannot be computed here", e)
+	}
+	return eval(e)
+}
+
+// Parse returns the type for a type name as written by String such as "unsigned long" or "int (*)(int, char *)"
+// Typedef names, "enum Tag" and "struct Tag" are returned as Named types for Resolve
+func Parse(name string) (Type, error) {
+	r := &resolver{name: name, tokens: tokenizeTypeName(name)}
+	t, err := r.typeName()
+	if err != nil {
+		return nil, err
+	}
+	if len(r.tokens) != 0 {
+		return nil, r.unexpected()
+	}
+	return t, nil
+}
+
+// marshalName writes a type as its type name in JSON, it is used by the MarshalJSON method of every type
+// so the AST shows the types as they are written in C, Parse reads them back
+func marshalName(t Type) ([]byte, error) {
+	return json.Marshal(t.String())
+}
+
+// tokenizeTypeName splits a type name into words and punctuators
+func tokenizeTypeName(name string) []string {
+	tokens := make([]string, 0)
+	word := ""
+	for _, c := range name {
+		switch c {
+		case ' ', '*', '(', ')', ',', '[', ']', '{', '}', ';':
+			if word != "" {
+				tokens = append(tokens, word)
+				word = ""
+			}
+			if c != ' ' {
+				tokens = append(tokens, string(c))
+			}
+		default:
+			word += string(c)
+		}
+	}
+	if word != "" {
+		tokens = append(tokens, word)
+	}
+	return tokens
+}
+
+// resolver parses the type names written by format
+// <type_name> ::= <specifiers> <abstract_declarator>
+// <abstract_declarator> ::= { "*" { <qualifier> } } [ "(" <abstract_declarator> ")" | <id> ] { <suffix> }
+// <suffix> ::= "(" [ <type_name> { "," <type_name> } [ "," "..." ] ] ")" | "[" [ <int> | <expression> ] "]"
+// <struct> ::= "struct" ( <id> | "{" { <specifiers> <abstract_declarator> ";" } "}" )
+// The declarators of the members of an anonymous struct hold their names
+type resolver struct {
+	name   string
+	tokens []string
+}
+
+func (r *resolver) peek(i int) string {
+	if i < len(r.tokens) {
+		return r.tokens[i]
+	}
+	return ""
+}
+
+func (r *resolver) unexpected() error {
+	if len(r.tokens) == 0 {
+		return fmt.Errorf("Unexpected end of type name '%s'", r.name)
+	}
+	return fmt.Errorf("Unexpected '%s' in type name '%s'", r.tokens[0], r.name)
+}
+
+func (r *resolver) expect(s string) error {
+	if r.peek(0) != s {
+		return r.unexpected()
+	}
+	r.tokens = r.tokens[1:]
+	return nil
+}
+
+func (r *resolver) typeName() (Type, error) {
+	base, err := r.specifiers()
+	if err != nil {
+		return nil, err
+	}
+	name, wrap, err := r.declarator()
+	if err != nil {
+		return nil, err
+	}
+	if name != "" {
+		return nil, fmt.Errorf("Unexpected '%s' in type name '%s'", name, r.name)
+	}
+	return wrap(base), nil
+}
+
+// isWord returns whether or not a token is a name rather than a punctuator
+func isWord(s string) bool {
+	return s != "" && s != "..." && !strings.ContainsAny(s, "*(),[]{};")
+}
+
+// structBody builds an anonymous struct from its members
+func (r *resolver) structBody() (Type, error) {
+	if err := r.expect("{"); err != nil {
+		return nil, err
+	}
+	fields := make([]Field, 0)
+	for r.peek(0) != "}" {
+		base, err := r.specifiers()
+		if err != nil {
+			return nil, err
+		}
+		name, wrap, err := r.declarator()
+		if err != nil {
+			return nil, err
+		}
+		if err := r.expect(";"); err != nil {
+			return nil, err
+		}
+		fields = append(fields, Field{Name: name, Type: wrap(base)})
+	}
+	r.tokens = r.tokens[1:]
+	return &Struct{Fields: fields}, nil
+}
+
+func (r *resolver) specifiers() (Type, error) {
+	isConst, isVolatile := r.qualifiers()
+	var t Type
+	if r.peek(0) == "struct" && r.peek(1) == "{" {
+		r.tokens = r.tokens[1:]
+		var err error
+		t, err = r.structBody()
+		if err != nil {
+			return nil, err
+		}
+	} else if isWord(r.peek(0)) && !IsSpecifier(r.peek(0)) && !IsQualifier(r.peek(0)) {
+		name := r.tokens[0]
+		r.tokens = r.tokens[1:]
+		if name == "enum" || name == "struct" {
+			if r.peek(0) == "" {
+				return nil, r.unexpected()
+			}
+			name += " " + r.tokens[0]
+			r.tokens = r.tokens[1:]
+		}
+		t = &Named{Name: name}
+	} else {
+		specs := make([]string, 0)
+		for IsSpecifier(r.peek(0)) || IsQualifier(r.peek(0)) {
+			if r.peek(0) == "const" {
+				isConst = true
+			} else if r.peek(0) == "volatile" {
+				isVolatile = true
+			} else {
+				specs = append(specs, r.tokens[0])
+			}
+			r.tokens = r.tokens[1:]
+		}
+		var err error
+		t, err = FromSpecifiers(specs)
+		if err != nil {
+			return nil, err
+		}
+	}
+	c, v := r.qualifiers()
+	return Qualify(t, isConst || c, isVolatile || v), nil
+}
+
+// qualifiers consumes the qualifiers found at the start of the tokens
+func (r *resolver) qualifiers() (isConst bool, isVolatile bool) {
+	for IsQualifier(r.peek(0)) {
+		if r.tokens[0] == "const" {
+			isConst = true
+		} else {
+			isVolatile = true
+		}
+		r.tokens = r.tokens[1:]
+	}
+	return isConst, isVolatile
+}
+
+// declarator returns the declared name if any and a function wrapping the base type into the declared type
+func (r *resolver) declarator() (string, func(Type) Type, error) {
+	// Each "*" can be followed by the qualifiers of the pointer
+	stars := make([][2]bool, 0)
+	for r.peek(0) == "*" {
+		r.tokens = r.tokens[1:]
+		isConst, isVolatile := r.qualifiers()
+		stars = append(stars, [2]bool{isConst, isVolatile})
+	}
+	name := ""
+	inner := func(t Type) Type { return t }
+	if r.peek(0) == "(" && (r.peek(1) == "*" || r.peek(1) == "(") {
+		r.tokens = r.tokens[1:]
+		var err error
+		name, inner, err = r.declarator()
+		if err != nil {
+			return "", nil, err
+		}
+		if err := r.expect(")"); err != nil {
+			return "", nil, err
+		}
+	} else if isWord(r.peek(0)) {
+		name, r.tokens = r.tokens[0], r.tokens[1:]
+	}
+	// Suffixes are either a *Func or an *Array whose element type is set once the base is known
+	suffixes := make([]Type, 0)
+	for r.peek(0) == "(" || r.peek(0) == "[" {
+		if r.peek(0) == "[" {
+			r.tokens = r.tokens[1:]
+			a := &Array{Len: -1}
+			if r.peek(0) != "]" {
+				expr, err := r.expression()
+				if err != nil {
+					return "", nil, err
+				}
+				a.LenExpr = expr
+			}
+			if err := r.expect("]"); err != nil {
+				return "", nil, err
+			}
+			suffixes = append(suffixes, a)
+			continue
+		}
+		r.tokens = r.tokens[1:]
+		fn := &Func{Params: make([]Type, 0), Unprototyped: r.peek(0) == ")"}
+		for r.peek(0) != ")" {
+			if len(fn.Params) != 0 {
+				if err := r.expect(","); err != nil {
+					return "", nil, err
+				}
+			}
+			if r.peek(0) == "..." {
+				r.tokens = r.tokens[1:]
+				fn.Variadic = true
+				continue
+			}
+			param, err := r.typeName()
+			if err != nil {
+				return "", nil, err
+			}
+			fn.Params = append(fn.Params, param)
+		}
+		r.tokens = r.tokens[1:]
+		suffixes = append(suffixes, fn)
+	}
+	return name, func(t Type) Type {
+		for _, quals := range stars {
+			t = Qualify(&Pointer{Elem: t}, quals[0], quals[1])
+		}
+		for i := len(suffixes) - 1; i >= 0; i-- {
+			switch s := suffixes[i].(type) {
+			case *Func:
+				t = &Func{Return: t, Params: s.Params, Variadic: s.Variadic, Unprototyped: s.Unprototyped}
+			case *Array:
+				t = &Array{Elem: t, Len: s.Len, LenExpr: s.LenExpr}
+			}
+		}
+		return inner(t)
+	}, nil
+}
+
+// expression reads the tokens of an array length up to its "]" as an ExprText
+// The spaces between the tokens are not kept so the text is only meant to be replaced, see Lengths,
+// unless it is an integer literal
+func (r *resolver) expression() (Expr, error) {
+	depth, n := 0, 0
+	for ; depth > 0 || r.peek(n) != "]"; n++ {
+		switch r.peek(n) {
+		case "":
+			r.tokens = r.tokens[n:]
+			return nil, r.unexpected()
+		case "(", "[":
+			depth++
+		case ")", "]":
+			depth--
+		}
+	}
+	text := strings.Join(r.tokens[:n], " ")
+	r.tokens = r.tokens[n:]
+	return ExprText(text), nil
+}
+
+// Lengths returns the arrays of t whose length is an expression, a type read back by Parse from
+// the name of t gives its arrays in the same order, which lets the expressions be stored apart from the name
+func Lengths(t Type) []*Array {
+	arrays := make([]*Array, 0)
+	var visit func(t Type)
+	visit = func(t Type) {
+		switch t := t.(type) {
+		case *Qualified:
+			visit(t.Elem)
+		case *Pointer:
+			visit(t.Elem)
+		case *Array:
+			if t.LenExpr != nil {
+				arrays = append(arrays, t)
+			}
+			visit(t.Elem)
+		case *Func:
+			visit(t.Return)
+			for _, param := range t.Params {
+				visit(param)
+			}
+		case *Struct:
+			// The members of the anonymous structs are written in the name
+			if t.Tag == "" {
+				for _, f := range t.Fields {
+					visit(f.Type)
+				}
+			}
+		}
+	}
+	visit(t)
+	return arrays
+}
+
+// MapLengths returns a copy of t where the length of each array written as an expression is replaced by f of it
+// The parts of t without such an array are shared with t
+func MapLengths(t Type, f func(e Expr) Expr) Type {
+	if len(Lengths(t)) == 0 {
+		return t
+	}
+	switch t := t.(type) {
+	case *Qualified:
+		return &Qualified{Elem: MapLengths(t.Elem, f), Const: t.Const, Volatile: t.Volatile}
+	case *Pointer:
+		return &Pointer{Elem: MapLengths(t.Elem, f)}
+	case *Array:
+		a := &Array{Elem: MapLengths(t.Elem, f), Len: t.Len}
+		if t.LenExpr != nil {
+			a.LenExpr = f(t.LenExpr)
+		}
+		return a
+	case *Func:
+		fn := &Func{Return: MapLengths(t.Return, f), Params: make([]Type, len(t.Params)), Variadic: t.Variadic,
+			Unprototyped: t.Unprototyped}
+		for i, param := range t.Params {
+			fn.Params[i] = MapLengths(param, f)
+		}
+		return fn
+	case *Struct:
+		s := *t
+		s.Fields = make([]Field, len(t.Fields))
+		for i, field := range t.Fields {
+			s.Fields[i] = Field{Name: field.Name, Type: MapLengths(field.Type, f), Offset: field.Offset}
+		}
+		return &s
+	}
+	return t
+}
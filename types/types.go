@@ -0,0 +1,625 @@
+package types
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Kind identifies a basic type
+type Kind uint32
+
+// Lists all the supported basic types
+const (
+	InvalidKind Kind = iota
+	CharKind
+	UnsignedCharKind
+	ShortKind
+	UnsignedShortKind
+	IntKind
+	UnsignedIntKind
+	LongKind
+	UnsignedLongKind
+)
+
+// Type represents the type of an object or an expression
+type Type interface {
+	Size() int
+	String() string
+}
+
+// Basic is an integer type
+type Basic struct {
+	Kind     Kind
+	Name     string
+	Bytes    int
+	Unsigned bool
+}
+
+// Size returns the number of bytes used by the type
+func (b *Basic) Size() int { return b.Bytes }
+
+func (b *Basic) String() string { return b.Name }
+
+func (b *Basic) MarshalJSON() ([]byte, error) { return marshalName(b) }
+
+// rank orders the integer types for the usual arithmetic conversions
+func (b *Basic) rank() int { return b.Bytes }
+
+// All the basic types, the same pointer is always used for a given type
+// so they can be compared with ==
+var (
+	Char          = &Basic{Kind: CharKind, Name: "char", Bytes: 1}
+	UnsignedChar  = &Basic{Kind: UnsignedCharKind, Name: "unsigned char", Bytes: 1, Unsigned: true}
+	Short         = &Basic{Kind: ShortKind, Name: "short", Bytes: 2}
+	UnsignedShort = &Basic{Kind: UnsignedShortKind, Name: "unsigned short", Bytes: 2, Unsigned: true}
+	Int           = &Basic{Kind: IntKind, Name: "int", Bytes: 4}
+	UnsignedInt   = &Basic{Kind: UnsignedIntKind, Name: "unsigned int", Bytes: 4, Unsigned: true}
+	Long          = &Basic{Kind: LongKind, Name: "long", Bytes: 8}
+	UnsignedLong  = &Basic{Kind: UnsignedLongKind, Name: "unsigned long", Bytes: 8, Unsigned: true}
+)
+
+// Enum is an enumerated type, its values are stored as ints
+type Enum struct {
+	Tag string
+}
+
+// Size returns the number of bytes used by the type
+func (e *Enum) Size() int { return Int.Size() }
+
+func (e *Enum) String() string { return "enum " + e.Tag }
+
+func (e *Enum) MarshalJSON() ([]byte, error) { return marshalName(e) }
+
+// Pointer holds the address of a value of type Elem
+type Pointer struct {
+	Elem Type
+}
+
+// Size returns the number of bytes used by the type
+func (p *Pointer) Size() int { return 8 }
+
+func (p *Pointer) String() string { return format(p, "") }
+
+func (p *Pointer) MarshalJSON() ([]byte, error) { return marshalName(p) }
+
+// Func is the type of a function
+type Func struct {
+	Return Type
+	Params []Type
+	// Variadic is set when the parameters end with "..."
+	Variadic bool
+	// Unprototyped is set for a function declared with "()", its parameters are not specified
+	// so it takes any arguments, see Compatible
+	Unprototyped bool
+}
+
+// Size returns 1 like GCC does, functions are not objects
+func (f *Func) Size() int { return 1 }
+
+func (f *Func) String() string { return format(f, "") }
+
+func (f *Func) MarshalJSON() ([]byte, error) { return marshalName(f) }
+
+// Array is a sequence of Len values of type Elem stored one after the other
+// Len is -1 when the length is not written, e.g. in `int a[] = {1, 2}` until the initializer is read
+// LenExpr is the length as written in the source, Len is -1 until Resolve computes it
+type Array struct {
+	Elem    Type
+	Len     int
+	LenExpr Expr
+}
+
+// Expr is an integer constant expression written in a type such as the length of an array
+// Types do not compute it, the generator does when Resolve is called, String gives its source
+type Expr interface {
+	String() string
+}
+
+// ExprText is an expression known by its source only, as read by Parse
+type ExprText string
+
+func (e ExprText) String() string { return string(e) }
+
+// Size returns the number of bytes used by the type, 0 while the length is unknown
+func (a *Array) Size() int {
+	if a.Len < 0 {
+		return 0
+	}
+	return a.Len * a.Elem.Size()
+}
+
+func (a *Array) String() string { return format(a, "") }
+
+func (a *Array) MarshalJSON() ([]byte, error) { return marshalName(a) }
+
+// Field is a member of a struct, Offset is its position in bytes from the start of the struct
+type Field struct {
+	Name   string
+	Type   Type
+	Offset int
+}
+
+// Struct is a structure type, it stays incomplete until Define lays out its members
+// Each definition creates a new type so structs are compared by identity
+type Struct struct {
+	Tag     string
+	Fields  []Field
+	Defined bool
+	size    int
+	align   int
+}
+
+// Size returns the number of bytes used by the type, including the padding
+func (s *Struct) Size() int { return s.size }
+
+// String returns "struct Tag", anonymous structs are written with their members
+// so Resolve can build them back
+func (s *Struct) String() string {
+	if s.Tag != "" {
+		return "struct " + s.Tag
+	}
+	fields := make([]string, len(s.Fields))
+	for i, f := range s.Fields {
+		fields[i] = format(f.Type, f.Name) + ";"
+	}
+	return "struct { " + strings.Join(fields, " ") + " }"
+}
+
+func (s *Struct) MarshalJSON() ([]byte, error) { return marshalName(s) }
+
+// Field returns the member called name
+func (s *Struct) Field(name string) (Field, bool) {
+	for _, f := range s.Fields {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return Field{}, false
+}
+
+// Define sets the members of the struct and computes their offsets
+// Every member is aligned on its own alignment and the size is rounded up to the largest one
+func (s *Struct) Define(fields []Field) error {
+	if len(fields) == 0 {
+		return fmt.Errorf("'%s' has no members", s)
+	}
+	offset, align := 0, 1
+	for i, f := range fields {
+		if !IsComplete(f.Type) {
+			return fmt.Errorf("Member '%s' has incomplete type '%s'", f.Name, f.Type)
+		}
+		for _, prev := range fields[:i] {
+			if prev.Name == f.Name {
+				return fmt.Errorf("Duplicate member '%s'", f.Name)
+			}
+		}
+		a := Alignof(f.Type)
+		offset = (offset + a - 1) / a * a
+		fields[i].Offset = offset
+		offset += f.Type.Size()
+		if a > align {
+			align = a
+		}
+	}
+	s.Fields = fields
+	s.size = (offset + align - 1) / align * align
+	s.align = align
+	s.Defined = true
+	return nil
+}
+
+// Alignof returns the alignment in bytes of the values of type t
+func Alignof(t Type) int {
+	switch t := Unqualified(t).(type) {
+	case *Array:
+		return Alignof(t.Elem)
+	case *Struct:
+		return t.align
+	case *VaList, *VaListTag:
+		return 8
+	case *Func:
+		return 1
+	}
+	return t.Size()
+}
+
+// IsComplete returns whether or not the size of t is known so objects of type t can be declared
+func IsComplete(t Type) bool {
+	switch t := Unqualified(t).(type) {
+	case *Array:
+		return t.Len >= 0 && IsComplete(t.Elem)
+	case *Struct:
+		return t.Defined
+	case *Func, *Named:
+		return false
+	}
+	return true
+}
+
+// IsAggregate returns whether or not t is an array or a struct
+func IsAggregate(t Type) bool {
+	switch Unqualified(t).(type) {
+	case *Array, *Struct:
+		return true
+	}
+	return false
+}
+
+// Decay returns the type of the value of an expression of type t, values have no qualifiers
+// Arrays convert to a pointer to their first element and functions to a pointer to themselves,
+// a va_list is an array too
+func Decay(t Type) Type {
+	switch u := Unqualified(t).(type) {
+	case *Array:
+		return &Pointer{Elem: u.Elem}
+	case *Func:
+		return &Pointer{Elem: u}
+	case *VaList:
+		return &Pointer{Elem: &VaListTag{}}
+	}
+	return Unqualified(t)
+}
+
+// Qualified is a type with const or volatile qualifiers
+// The generator has no optimization pass so every read and write of a volatile object is emitted
+type Qualified struct {
+	Elem     Type
+	Const    bool
+	Volatile bool
+}
+
+// Size returns the number of bytes used by the type
+func (q *Qualified) Size() int { return q.Elem.Size() }
+
+func (q *Qualified) String() string { return format(q, "") }
+
+func (q *Qualified) MarshalJSON() ([]byte, error) { return marshalName(q) }
+
+// qualifiers returns the qualifiers as written in C
+func (q *Qualified) qualifiers() string {
+	quals := make([]string, 0)
+	if q.Const {
+		quals = append(quals, "const")
+	}
+	if q.Volatile {
+		quals = append(quals, "volatile")
+	}
+	return strings.Join(quals, " ")
+}
+
+// Qualify adds qualifiers to t, t is returned as is when no qualifier is set
+func Qualify(t Type, isConst, isVolatile bool) Type {
+	if q, ok := t.(*Qualified); ok {
+		t = q.Elem
+		isConst = isConst || q.Const
+		isVolatile = isVolatile || q.Volatile
+	}
+	if !isConst && !isVolatile {
+		return t
+	}
+	return &Qualified{Elem: t, Const: isConst, Volatile: isVolatile}
+}
+
+// Unqualified returns t without its top level qualifiers
+func Unqualified(t Type) Type {
+	if q, ok := t.(*Qualified); ok {
+		return q.Elem
+	}
+	return t
+}
+
+// IsConst returns whether or not t is const qualified
+func IsConst(t Type) bool {
+	q, ok := t.(*Qualified)
+	return ok && q.Const
+}
+
+// IsVolatile returns whether or not t is volatile qualified
+func IsVolatile(t Type) bool {
+	q, ok := t.(*Qualified)
+	return ok && q.Volatile
+}
+
+// IsQualifier returns whether or not a keyword is a type qualifier
+func IsQualifier(s string) bool {
+	return s == "const" || s == "volatile"
+}
+
+// VaList holds the state of a traversal of variadic arguments
+// It is laid out as the va_list of the System V ABI, an array of a single VaListTag, so it decays
+// to a pointer to its VaListTag, which is how a va_list is passed to a function
+type VaList struct{}
+
+// Size returns the number of bytes used by the type
+func (v *VaList) Size() int { return 24 }
+
+func (v *VaList) String() string { return "va_list" }
+
+func (v *VaList) MarshalJSON() ([]byte, error) { return marshalName(v) }
+
+// VaListTag is the element of a VaList, only used through the pointer a VaList decays to
+type VaListTag struct{}
+
+// Size returns the number of bytes used by the type
+func (v *VaListTag) Size() int { return 24 }
+
+func (v *VaListTag) String() string { return "__va_list_tag" }
+
+func (v *VaListTag) MarshalJSON() ([]byte, error) { return marshalName(v) }
+
+// Named is a reference to a type by name, such as a typedef name, "enum Tag" or "struct Tag"
+// It is only used to write type names, Resolve replaces it with the type it refers to
+type Named struct {
+	Name string
+}
+
+// Size returns 0 since the referenced type is not known
+func (n *Named) Size() int { return 0 }
+
+func (n *Named) String() string { return n.Name }
+
+func (n *Named) MarshalJSON() ([]byte, error) { return marshalName(n) }
+
+// Declaration returns the declaration of name with type t as written in C, such as "int (*fp)(int)"
+// The type name of t is returned when name is empty
+func Declaration(t Type, name string) string {
+	return format(t, name)
+}
+
+// Specifier returns the type a declaration of type t starts with, the one under its pointers, arrays
+// and function returns, e.g. "const int" for "const int *p[3]"
+// Declarators sharing a specifier can be written in a single declaration
+func Specifier(t Type) Type {
+	switch t := t.(type) {
+	case *Pointer:
+		return Specifier(t.Elem)
+	case *Array:
+		return Specifier(t.Elem)
+	case *Func:
+		return Specifier(t.Return)
+	case *Qualified:
+		// Qualifiers of a pointer are written in the declarator
+		if _, ok := t.Elem.(*Pointer); ok {
+			return Specifier(t.Elem)
+		}
+		return Qualify(Specifier(t.Elem), t.Const, t.Volatile)
+	}
+	return t
+}
+
+// format writes t around the declarator decl the same way C declarations are written
+// e.g. a pointer to a function taking an int and returning an int gives "int (*)(int)"
+func format(t Type, decl string) string {
+	switch t := t.(type) {
+	case *Pointer:
+		switch t.Elem.(type) {
+		case *Func, *Array:
+			return format(t.Elem, "(*"+decl+")")
+		}
+		return format(t.Elem, "*"+decl)
+	case *Array:
+		length := ""
+		if t.LenExpr != nil {
+			length = t.LenExpr.String()
+		} else if t.Len >= 0 {
+			length = strconv.Itoa(t.Len)
+		}
+		return format(t.Elem, decl+"["+length+"]")
+	case *Qualified:
+		// Qualifiers of a pointer are written after its "*"
+		if _, ok := t.Elem.(*Pointer); ok {
+			if decl != "" {
+				decl = " " + decl
+			}
+			return format(t.Elem, t.qualifiers()+decl)
+		}
+		return t.qualifiers() + " " + format(t.Elem, decl)
+	case *Func:
+		params := make([]string, len(t.Params))
+		for i, param := range t.Params {
+			params[i] = param.String()
+		}
+		if t.Variadic {
+			params = append(params, "...")
+		}
+		return format(t.Return, decl+"("+strings.Join(params, ", ")+")")
+	}
+	if decl == "" {
+		return t.String()
+	}
+	return t.String() + " " + decl
+}
+
+// Compatible returns whether or not a and b can be the types of two declarations of the same function or object
+// They are identical except that a function declared with "()" is compatible with a prototype returning
+// the same type whose parameters are not changed by the promotions, since the arguments of a call
+// without a prototype are only promoted
+func Compatible(a, b Type) bool {
+	switch a := a.(type) {
+	case *Pointer:
+		b, ok := b.(*Pointer)
+		return ok && Compatible(a.Elem, b.Elem)
+	case *Qualified:
+		b, ok := b.(*Qualified)
+		return ok && a.Const == b.Const && a.Volatile == b.Volatile && Compatible(a.Elem, b.Elem)
+	case *Func:
+		b, ok := b.(*Func)
+		if !ok || !a.Unprototyped && !b.Unprototyped {
+			return Identical(a, b)
+		}
+		if !Compatible(a.Return, b.Return) {
+			return false
+		}
+		proto := a
+		if a.Unprototyped {
+			proto = b
+		}
+		if proto.Unprototyped {
+			return true
+		}
+		if proto.Variadic {
+			return false
+		}
+		for _, param := range proto.Params {
+			if IsArithmetic(param) && !Identical(Promote(param), Unqualified(param)) {
+				return false
+			}
+		}
+		return true
+	}
+	return Identical(a, b)
+}
+
+// Identical returns whether or not a and b are the same type
+func Identical(a, b Type) bool {
+	switch a := a.(type) {
+	case *Pointer:
+		b, ok := b.(*Pointer)
+		return ok && Identical(a.Elem, b.Elem)
+	case *Func:
+		b, ok := b.(*Func)
+		if !ok || len(a.Params) != len(b.Params) || a.Variadic != b.Variadic || a.Unprototyped != b.Unprototyped ||
+			!Identical(a.Return, b.Return) {
+			return false
+		}
+		// Qualifiers of the parameters are not part of the function type
+		for i := range a.Params {
+			if !Identical(Unqualified(a.Params[i]), Unqualified(b.Params[i])) {
+				return false
+			}
+		}
+		return true
+	case *Array:
+		b, ok := b.(*Array)
+		if !ok || a.Len != b.Len || (a.LenExpr == nil) != (b.LenExpr == nil) || !Identical(a.Elem, b.Elem) {
+			return false
+		}
+		// Lengths not computed yet are compared as they are written
+		return a.LenExpr == nil || a.LenExpr.String() == b.LenExpr.String()
+	case *Enum:
+		b, ok := b.(*Enum)
+		return ok && a.Tag == b.Tag
+	case *VaList:
+		_, ok := b.(*VaList)
+		return ok
+	case *VaListTag:
+		_, ok := b.(*VaListTag)
+		return ok
+	case *Qualified:
+		b, ok := b.(*Qualified)
+		return ok && a.Const == b.Const && a.Volatile == b.Volatile && Identical(a.Elem, b.Elem)
+	}
+	return a == b
+}
+
+// IsArithmetic returns whether or not t is an integer type
+func IsArithmetic(t Type) bool {
+	_, ok := Underlying(t).(*Basic)
+	return ok
+}
+
+// Underlying returns the basic type used to store values of t
+func Underlying(t Type) Type {
+	t = Unqualified(t)
+	if _, ok := t.(*Enum); ok {
+		return Int
+	}
+	return t
+}
+
+var specifiers = map[string]bool{
+	"char":     true,
+	"short":    true,
+	"int":      true,
+	"long":     true,
+	"signed":   true,
+	"unsigned": true,
+}
+
+// IsSpecifier returns whether or not a keyword is a type specifier
+func IsSpecifier(s string) bool {
+	return specifiers[s]
+}
+
+// FromSpecifiers returns the type described by a list of type specifiers in any order
+// e.g. ["unsigned", "long", "int"] gives UnsignedLong
+func FromSpecifiers(specs []string) (Type, error) {
+	count := map[string]int{}
+	for _, s := range specs {
+		if !IsSpecifier(s) {
+			return nil, fmt.Errorf("Unknown type specifier '%s'", s)
+		}
+		count[s]++
+	}
+	invalid := fmt.Errorf("Invalid combination of type specifiers '%s'", strings.Join(specs, " "))
+	if count["signed"] > 0 && count["unsigned"] > 0 {
+		return nil, invalid
+	}
+	unsigned := count["unsigned"] > 0
+	for s, n := range count {
+		// Only long can be repeated, long long is the same as long on x86-64
+		if n > 1 && !(s == "long" && n == 2) {
+			return nil, invalid
+		}
+	}
+	switch {
+	case count["char"] == 1:
+		if count["short"]+count["int"]+count["long"] != 0 {
+			return nil, invalid
+		}
+		if unsigned {
+			return UnsignedChar, nil
+		}
+		return Char, nil
+	case count["short"] == 1:
+		if count["long"] != 0 {
+			return nil, invalid
+		}
+		if unsigned {
+			return UnsignedShort, nil
+		}
+		return Short, nil
+	case count["long"] > 0:
+		if unsigned {
+			return UnsignedLong, nil
+		}
+		return Long, nil
+	case len(specs) > 0:
+		if unsigned {
+			return UnsignedInt, nil
+		}
+		return Int, nil
+	}
+	return nil, invalid
+}
+
+// Promote applies the integer promotions, anything smaller than an int becomes an int
+func Promote(t Type) Type {
+	t = Underlying(t)
+	if b, ok := t.(*Basic); ok && b.rank() < Int.rank() {
+		return Int
+	}
+	return t
+}
+
+// Common returns the type of a binary arithmetic operation between a and b
+// following the usual arithmetic conversions
+func Common(a, b Type) Type {
+	a, b = Promote(a), Promote(b)
+	ba, okA := a.(*Basic)
+	bb, okB := b.(*Basic)
+	if !okA || !okB {
+		return a
+	}
+	if ba.rank() != bb.rank() {
+		if ba.rank() > bb.rank() {
+			return ba
+		}
+		return bb
+	}
+	if bb.Unsigned {
+		return bb
+	}
+	return ba
+}